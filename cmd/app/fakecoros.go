@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"fitgo/internal/service/coros/fakeserver"
+)
+
+const fakeCorosUsage = `用法: fitgo fakecoros [-addr :9094] [-fixtures dir] [-record upstream]
+
+启动模拟的高驰 API 服务。将配置文件中的 coros.address 指向该地址即可离线开发。
+
+  -addr      监听地址，默认 :9094
+  -fixtures  夹具目录，缺少的文件使用内置夹具；录制模式下为输出目录
+  -record    录制模式，代理到指定的高驰地址(如 https://teamcnapi.coros.com)并保存脱敏后的响应
+`
+
+// runFakeCoros 执行 fitgo fakecoros 子命令，返回进程退出码
+func runFakeCoros(args []string) int {
+	fs := flag.NewFlagSet("fakecoros", flag.ContinueOnError)
+	addr := fs.String("addr", ":9094", "监听地址")
+	fixtures := fs.String("fixtures", "", "夹具目录")
+	record := fs.String("record", "", "录制模式的上游地址")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, fakeCorosUsage) }
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var handler http.Handler
+	switch {
+	case *record != "":
+		if *fixtures == "" {
+			fmt.Fprintln(os.Stderr, "录制模式需要指定 -fixtures 输出目录")
+			return 2
+		}
+		handler = fakeserver.NewRecorder(*record, *fixtures)
+		fmt.Printf("Recording COROS API %s into %s on %s\n", *record, *fixtures, *addr)
+	case *fixtures != "":
+		handler = fakeserver.NewFromDir(*fixtures)
+		fmt.Printf("Fake COROS API serving %s on %s\n", *fixtures, *addr)
+	default:
+		handler = fakeserver.New(nil)
+		fmt.Printf("Fake COROS API serving built-in fixtures on %s\n", *addr)
+	}
+
+	if err := http.ListenAndServe(*addr, handler); err != nil {
+		fmt.Fprintf(os.Stderr, "Fake COROS server failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
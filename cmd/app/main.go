@@ -3,8 +3,19 @@ package main
 import (
 	"fitgo/internal/handler"
 	"fitgo/internal/middleware"
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/ai/prompt"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/analyzer/builtin"
+	"fitgo/internal/service/chat"
 	"fitgo/internal/service/coros"
+	"fitgo/internal/service/prediction"
+	"fitgo/internal/service/query"
+	"fitgo/internal/service/report"
+	"fitgo/internal/service/review"
 	"fitgo/internal/service/tcx"
+	"fitgo/internal/service/usage"
+	"fitgo/internal/service/workout"
 	"fitgo/pkg/config"
 	"fmt"
 	"net/http"
@@ -14,6 +25,16 @@ import (
 )
 
 func main() {
+	// 子命令: fitgo secrets ... / fitgo fakecoros ...
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "secrets":
+			os.Exit(runSecrets(os.Args[2:]))
+		case "fakecoros":
+			os.Exit(runFakeCoros(os.Args[2:]))
+		}
+	}
+
 	// Load configuration with default paths
 	cfg, err := config.LoadDefaultConfig()
 	if err != nil {
@@ -22,12 +43,46 @@ func main() {
 	}
 
 	// 创建服务实例
-	tcxService := tcx.NewTCXService()
-	corosService := coros.NewCorosService()
+	activityService := activity.NewActivityService(cfg.Storage.Dir())
+	tcxService := tcx.NewTCXService(activityService)
+	accountStore, err := coros.NewAccountStore(cfg.Storage.Dir())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load COROS accounts: %v\n", err)
+		os.Exit(1)
+	}
+	corosService := coros.NewCorosService(&cfg.Coros, accountStore, coros.NewDailyStore(cfg.Storage.Dir()), activityService)
+	workoutService := workout.NewWorkoutService(cfg.Storage.Dir())
+	reportService := report.NewReportService(cfg.Storage.Dir())
+	// AI 服务、用量和提示词模板只创建一次，熔断状态、当天花费和模板缓存在所有请求间共用
+	usageService := usage.NewUsageService(cfg.Storage.Dir(), cfg.AI.Usage)
+	prompts, err := prompt.NewStore(cfg.AI.Prompts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load prompt templates: %v\n", err)
+		os.Exit(1)
+	}
+	aiService, err := aiservice.NewAIService(&cfg.AI)
+	if err != nil {
+		// 未配置 AI 时其它功能照常可用，对话、分析等 AI 接口返回错误
+		fmt.Fprintf(os.Stderr, "AI service unavailable: %v\n", err)
+	} else {
+		aiService.WithUsage(usageService)
+	}
+	chatService := chat.NewChatService(cfg.Storage.Dir(), cfg.AI.Chat, activityService, reportService, aiService)
+	queryService := query.NewQueryService(cfg.Storage.Dir(), prompts, activityService, aiService)
+	predictionService := prediction.NewPredictionService(activityService)
+	reviewService := review.NewReviewService(prompts, activityService, reportService, aiService)
+	analyzers := builtin.Registry().WithPredictions(predictionService).WithAI(aiService, prompts, cfg.AI.MaxToolIterations)
 
 	// 创建处理器
 	tcxHandler := handler.NewTCXHandler(tcxService)
-	corosHandler := handler.NewCorosHandler(corosService)
+	corosHandler := handler.NewCorosHandler(corosService, reportService, activityService, analyzers)
+	activityHandler := handler.NewActivityHandler(activityService)
+	workoutHandler := handler.NewWorkoutHandler(workoutService, corosService)
+	chatHandler := handler.NewChatHandler(chatService)
+	queryHandler := handler.NewQueryHandler(queryService)
+	predictionHandler := handler.NewPredictionHandler(predictionService)
+	reviewHandler := handler.NewReviewHandler(reviewService)
+	usageHandler := handler.NewUsageHandler(usageService, cfg.AI.Usage.AdminToken)
 
 	// 创建 ServeMux
 	mux := http.NewServeMux()
@@ -35,9 +90,17 @@ func main() {
 	// 设置路由
 	router.SetupTcxRoutes(mux, tcxHandler)
 	router.SetCorosRoutes(mux, corosHandler)
+	router.SetWorkoutRoutes(mux, workoutHandler)
+	router.SetActivityRoutes(mux, activityHandler)
+	router.SetChatRoutes(mux, chatHandler)
+	router.SetQueryRoutes(mux, queryHandler)
+	router.SetPredictionRoutes(mux, predictionHandler)
+	router.SetReviewRoutes(mux, reviewHandler)
+	router.SetUsageRoutes(mux, usageHandler)
+	router.SetDebugRoutes(mux)
 
-	// 创建带 CORS 中间件的处理器
-	handler := middleware.CORS(mux)
+	// 创建带 CORS 和用户标识中间件的处理器
+	handler := middleware.CORS(middleware.User(mux))
 
 	// 启动服务器
 	port := cfg.Server.Port
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"fitgo/pkg/config"
+	"fitgo/pkg/secrets"
+)
+
+const secretsUsage = `用法: fitgo secrets [-config path] <command> [args]
+
+命令:
+  set <name> [value]   写入密钥，省略 value 时从标准输入读取
+  get <name>           读取密钥
+  list                 列出所有密钥名称
+  delete <name>        删除密钥
+  keygen <path>        生成随机密钥文件
+
+保险库路径为 FITGO_VAULT_PATH，未设置时为配置文件同目录下的 secrets.vault。
+口令通过 FITGO_VAULT_PASSPHRASE 提供，或通过 FITGO_VAULT_KEY_FILE 指定密钥文件。
+在配置文件中以 "secret://<name>" 引用密钥。
+`
+
+// runSecrets 执行 fitgo secrets 子命令，返回进程退出码
+func runSecrets(args []string) int {
+	fs := flag.NewFlagSet("secrets", flag.ContinueOnError)
+	configPath := fs.String("config", "configs/config.json", "配置文件路径，用于确定默认保险库位置")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, secretsUsage) }
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	command, rest := fs.Arg(0), fs.Args()[1:]
+	if command == "keygen" {
+		if len(rest) != 1 {
+			fs.Usage()
+			return 2
+		}
+		if err := secrets.GenerateKeyFile(rest[0]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	vault, err := secrets.OpenVault(config.VaultPath(*configPath), secrets.KeyFromEnv())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "打开保险库失败: %v\n", err)
+		return 1
+	}
+
+	switch {
+	case command == "set" && (len(rest) == 1 || len(rest) == 2):
+		value := ""
+		if len(rest) == 2 {
+			value = rest[1]
+		} else if value, err = readSecretValue(os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "读取密钥失败: %v\n", err)
+			return 1
+		}
+		if err := vault.Set(rest[0], value); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if err := vault.Save(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+	case command == "get" && len(rest) == 1:
+		value, err := vault.Get(rest[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Println(value)
+
+	case command == "list" && len(rest) == 0:
+		for _, name := range vault.Names() {
+			fmt.Println(name)
+		}
+
+	case command == "delete" && len(rest) == 1:
+		if err := vault.Delete(rest[0]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if err := vault.Save(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+	default:
+		fs.Usage()
+		return 2
+	}
+
+	return 0
+}
+
+// readSecretValue 读取标准输入的第一行，避免密钥出现在 shell 历史中
+func readSecretValue(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -2,4 +2,4 @@ module fitgo
 
 go 1.22
 
-require github.com/sashabaranov/go-openai v1.41.2 // indirect
+require github.com/sashabaranov/go-openai v1.41.2
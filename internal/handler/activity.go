@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"fitgo/internal/service/activity"
+)
+
+type ActivityHandler struct {
+	activityService activity.ActivityService
+}
+
+func NewActivityHandler(service activity.ActivityService) *ActivityHandler {
+	return &ActivityHandler{
+		activityService: service,
+	}
+}
+
+// ImportActivity 上传 FIT 或 TCX 文件，表单字段为 file
+func (h *ActivityHandler) ImportActivity(w http.ResponseWriter, r *http.Request) {
+	// 解析表单数据，最大内存32MB
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to parse form: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get file: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read file: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	a, err := h.activityService.Ingest(header.Filename, content, activity.Source{Name: activity.SourceUpload})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a)
+}
+
+func (h *ActivityHandler) ListActivities(w http.ResponseWriter, r *http.Request) {
+	activities, err := h.activityService.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(activities)
+}
+
+func (h *ActivityHandler) GetActivity(w http.ResponseWriter, r *http.Request) {
+	a, err := h.activityService.Get(r.PathValue("id"))
+	if errors.Is(err, activity.ErrActivityNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"fitgo/internal/service/activity"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/chat"
+	"fitgo/internal/service/usage"
+)
+
+type ChatHandler struct {
+	chatService chat.ChatService
+}
+
+func NewChatHandler(service chat.ChatService) *ChatHandler {
+	return &ChatHandler{
+		chatService: service,
+	}
+}
+
+// chatRequest 提问请求体
+type chatRequest struct {
+	Message string `json:"message"`
+}
+
+// chatResponse 提问的结果，Session 为不含历史的会话信息
+type chatResponse struct {
+	Session *chat.Session `json:"session"`
+	Reply   *chat.Message `json:"reply,omitempty"`
+}
+
+// writeChatError 会话或活动不存在返回 404，消息为空返回 400，未配置 AI 返回 503，模型调用失败返回 502
+func writeChatError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, chat.ErrSessionNotFound), errors.Is(err, activity.ErrActivityNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, chat.ErrEmptyMessage):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, aiservice.ErrNoProvider):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, usage.ErrSpendCapExceeded):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	}
+}
+
+// StartChat 为活动创建会话，请求体中带 message 时同时回答第一个问题
+func (h *ChatHandler) StartChat(w http.ResponseWriter, r *http.Request) {
+	var req chatRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "请求体必须是合法的JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	session, err := h.chatService.Create(r.PathValue("id"))
+	if err != nil {
+		writeChatError(w, err)
+		return
+	}
+
+	resp := chatResponse{Session: session}
+	if req.Message != "" {
+		reply, err := h.chatService.Send(r.Context(), session.ID, req.Message)
+		if err != nil {
+			writeChatError(w, err)
+			return
+		}
+		resp.Reply = reply
+		if session, err = h.chatService.Get(session.ID); err == nil {
+			resp.Session = session
+		}
+	}
+	resp.Session.Messages = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// SendMessage 在已有会话中继续提问
+func (h *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
+	var req chatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "请求体必须是合法的JSON", http.StatusBadRequest)
+		return
+	}
+
+	reply, err := h.chatService.Send(r.Context(), r.PathValue("id"), req.Message)
+	if err != nil {
+		writeChatError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reply)
+}
+
+func (h *ChatHandler) ListChats(w http.ResponseWriter, r *http.Request) {
+	sessions, err := h.chatService.List(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+func (h *ChatHandler) GetChat(w http.ResponseWriter, r *http.Request) {
+	session, err := h.chatService.Get(r.PathValue("id"))
+	if err != nil {
+		writeChatError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+func (h *ChatHandler) DeleteChat(w http.ResponseWriter, r *http.Request) {
+	if err := h.chatService.Delete(r.PathValue("id")); err != nil {
+		if errors.Is(err, chat.ErrSessionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
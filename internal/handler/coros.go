@@ -2,25 +2,131 @@ package handler
 
 import (
 	"encoding/json"
-	"fitgo/internal/service/analyzer/running"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/ai/structured"
+	"fitgo/internal/service/ai/verify"
+	"fitgo/internal/service/analyzer"
 	"fitgo/internal/service/coros"
+	"fitgo/internal/service/report"
+	"fitgo/internal/service/usage"
+	"fitgo/pkg/sport"
 )
 
 type CorosHandler struct {
 	corosService coros.CorosService
+	reports      report.ReportService
+	activities   activity.ActivityService
+	analyzers    *analyzer.Registry // 按运动类型选择的分析器
 }
 
-func NewCorosHandler(service coros.CorosService) *CorosHandler {
+func NewCorosHandler(service coros.CorosService, reports report.ReportService, activities activity.ActivityService, analyzers *analyzer.Registry) *CorosHandler {
 	return &CorosHandler{
 		corosService: service,
+		reports:      reports,
+		activities:   activities,
+		analyzers:    analyzers,
 	}
 }
 
+// accountResponse 对外返回的账号信息，不包含密码
+type accountResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Username  int    `json:"username"`
+	CreatedAt string `json:"created_at"`
+}
+
+func toAccountResponse(account *coros.Account) accountResponse {
+	return accountResponse{
+		ID:        account.ID,
+		Name:      account.Name,
+		Username:  account.Username,
+		CreatedAt: account.CreatedAt,
+	}
+}
+
+// writeAccountError 账号不存在返回 404，其余返回 500
+func writeAccountError(w http.ResponseWriter, err error) {
+	if errors.Is(err, coros.ErrAccountNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, usage.ErrSpendCapExceeded) {
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// writeAddAccountError 账号已存在返回 409，账号信息不完整返回 400，其余返回 500
+func writeAddAccountError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, coros.ErrAccountExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, coros.ErrInvalidAccount):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *CorosHandler) AddAccount(w http.ResponseWriter, r *http.Request) {
+	var account coros.Account
+	if err := json.NewDecoder(r.Body).Decode(&account); err != nil {
+		http.Error(w, "请求体必须是合法的JSON", http.StatusBadRequest)
+		return
+	}
+	if account.Username == 0 || account.Password == "" {
+		http.Error(w, "username and password are required", http.StatusBadRequest)
+		return
+	}
+
+	saved, err := h.corosService.AddAccount(&account)
+	if err != nil {
+		writeAddAccountError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toAccountResponse(saved))
+}
+
+func (h *CorosHandler) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := h.corosService.ListAccounts()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]accountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		result = append(result, toAccountResponse(account))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (h *CorosHandler) RemoveAccount(w http.ResponseWriter, r *http.Request) {
+	if err := h.corosService.RemoveAccount(r.PathValue("accountId")); err != nil {
+		writeAccountError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *CorosHandler) Login(w http.ResponseWriter, r *http.Request) {
-	h.corosService.Login()
+	if _, err := h.corosService.Login(r.Context(), r.PathValue("accountId")); err != nil {
+		writeAccountError(w, err)
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Login successful"))
 }
@@ -36,10 +142,16 @@ func (h *CorosHandler) SportsSummary(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sp, err := sport.Parse(sportType)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// 调用服务层方法
-	result, err := h.corosService.SportsSummary(labelId, sportType)
+	result, err := h.corosService.SportsSummary(r.Context(), r.PathValue("accountId"), labelId, sp)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeAccountError(w, err)
 		return
 	}
 
@@ -66,9 +178,56 @@ func (h *CorosHandler) ActivityList(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 调用服务层方法
-	result, err := h.corosService.ActivityList(size, pageNumber, 1)
+	result, err := h.corosService.ActivityList(r.Context(), r.PathValue("accountId"), size, pageNumber, 1)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeAccountError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// Dashboard 获取当前恢复状态
+func (h *CorosHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	result, err := h.corosService.Dashboard(r.Context(), r.PathValue("accountId"))
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// DailyMetrics 获取每日静息心率、HRV、睡眠与训练负荷，from/to 默认为最近 7 天
+func (h *CorosHandler) DailyMetrics(w http.ResponseWriter, r *http.Request) {
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		to = time.Now().Format(coros.DateLayout)
+	}
+	end, err := time.Parse(coros.DateLayout, to)
+	if err != nil {
+		http.Error(w, "to 参数格式应为 YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	from := r.URL.Query().Get("from")
+	if from == "" {
+		from = end.AddDate(0, 0, -6).Format(coros.DateLayout)
+	}
+	start, err := time.Parse(coros.DateLayout, from)
+	if err != nil {
+		http.Error(w, "from 参数格式应为 YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if end.Before(start) || end.Sub(start) > 90*24*time.Hour {
+		http.Error(w, "日期区间必须在 0 到 90 天之间", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.corosService.DailyMetrics(r.Context(), r.PathValue("accountId"), from, to)
+	if err != nil {
+		writeAccountError(w, err)
 		return
 	}
 
@@ -78,16 +237,30 @@ func (h *CorosHandler) ActivityList(w http.ResponseWriter, r *http.Request) {
 
 // GetAiSportsSummary 获取运动数据的AI分析结果
 // @Summary 获取运动数据的AI分析
-// @Description 根据用户ID和运动ID获取AI分析的运动数据报告
+// @Description 根据账号ID和运动ID获取AI分析的运动数据报告
 // @Tags AI分析
 // @Accept  json
 // @Produce text/plain; charset=utf-8
+// @Param   accountId  path     string     true        "账号ID"
 // @Param   labelId    query    string     true        "运动记录ID"
-// @Param   sportType  query    string     true        "运动类型"
+// @Param   sportType  query    string     true        "运动类型，高驰 sportType 或统一名称(如 run、bike、pool_swim)，按运动类型选择分析器"
+// @Param   refresh    query    bool       false       "为 true 时忽略缓存重新生成"
+// @Param   lang       query    string     false       "报告语言(zh、en)，默认使用配置的语言"
+// @Param   format     query    string     false       "为 json 时返回结构化分析及服务端渲染的 Markdown/HTML"
+// @Param   tools      query    bool       false       "为 true 时允许模型调用工具查询训练历史"
 // @Success 200 {string} string "成功返回AI分析结果"
+// @Header  200 {string} X-AI-Provider "应答的提供者名称"
+// @Header  200 {string} X-AI-Model "应答的模型"
+// @Header  200 {string} X-AI-Report-ID "报告版本ID"
+// @Header  200 {string} X-AI-Cached "是否命中缓存"
+// @Header  200 {string} X-AI-Tool-Calls "生成报告时的工具调用次数，详情见报告的 tool_calls"
+// @Header  200 {string} X-AI-Compacted "提示词超出 token 预算时分段数据是否被压缩"
+// @Header  200 {string} X-AI-Mismatches "报告中与运动数据不符的数值个数，详情见报告的 verification"
+// @Header  200 {string} X-AI-Regenerated "关键数值不符时报告是否已重新生成"
 // @Failure 400 {string} string "请求参数错误"
+// @Failure 429 {string} string "已达到今日 AI 花费上限"
 // @Failure 500 {string} string "服务器内部错误"
-// @Router /coros/ai/summary [get]
+// @Router /coros/accounts/{accountId}/ai/summary [get]
 func (h *CorosHandler) GetAiSportsSummary(w http.ResponseWriter, r *http.Request) {
 	// 获取查询参数
 	labelId := r.URL.Query().Get("labelId")
@@ -99,15 +272,247 @@ func (h *CorosHandler) GetAiSportsSummary(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	sp, err := sport.Parse(sportType)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// 调用分析器
-	result, err := running.RunAnalyzer(labelId, sportType)
+	opts := analyzer.Options{Refresh: r.URL.Query().Get("refresh") == "true", Language: r.URL.Query().Get("lang")}
+	if r.URL.Query().Get("tools") == "true" {
+		opts.Activities = h.activities
+	}
+	if r.URL.Query().Get("format") == "json" {
+		h.getStructuredSummary(w, r, labelId, sp, opts)
+		return
+	}
+	result, err := h.analyzers.Analyze(r.Context(), h.corosService, h.reports, r.PathValue("accountId"), labelId, sp, opts)
+	if errors.Is(err, analyzer.ErrUnsupportedSport) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeAccountError(w, err)
 		return
 	}
 
-	// 直接返回分析结果
+	// 直接返回分析结果，应答的提供者和模型放在响应头中
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-AI-Provider", result.Provider)
+	w.Header().Set("X-AI-Model", result.Model)
+	w.Header().Set("X-AI-Report-ID", result.ID)
+	w.Header().Set("X-AI-Cached", strconv.FormatBool(result.Cached))
+	w.Header().Set("X-AI-Tool-Calls", strconv.Itoa(len(result.ToolCalls)))
+	w.Header().Set("X-AI-Compacted", strconv.FormatBool(result.Compaction != ""))
+	if v := result.Verification; v != nil {
+		w.Header().Set("X-AI-Mismatches", strconv.Itoa(len(v.Mismatches)))
+		w.Header().Set("X-AI-Regenerated", strconv.FormatBool(v.Regenerated))
+	}
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(result))
+	w.Write([]byte(result.Content))
+}
+
+// structuredSummaryResponse 结构化分析的响应，markdown 与 html 由服务端根据 analysis 渲染
+type structuredSummaryResponse struct {
+	ID            string               `json:"id"`
+	Provider      string               `json:"provider"`
+	Model         string               `json:"model"`
+	PromptVersion string               `json:"prompt_version"`
+	Cached        bool                 `json:"cached"`
+	Compaction    string               `json:"compaction,omitempty"`
+	Verification  *verify.Result       `json:"verification,omitempty"`
+	Analysis      *structured.Analysis `json:"analysis"`
+	Markdown      string               `json:"markdown"`
+	HTML          string               `json:"html"`
+}
+
+// getStructuredSummary 返回结构化的AI分析结果(format=json)
+func (h *CorosHandler) getStructuredSummary(w http.ResponseWriter, r *http.Request, labelID string, sp sport.Sport, opts analyzer.Options) {
+	result, analysis, err := h.analyzers.AnalyzeStructured(r.Context(), h.corosService, h.reports, r.PathValue("accountId"), labelID, sp, opts)
+	if errors.Is(err, analyzer.ErrUnsupportedSport) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, structured.ErrInvalidOutput) {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(structuredSummaryResponse{
+		ID:            result.ID,
+		Provider:      result.Provider,
+		Model:         result.Model,
+		PromptVersion: result.PromptVersion,
+		Cached:        result.Cached,
+		Compaction:    result.Compaction,
+		Verification:  result.Verification,
+		Analysis:      analysis,
+		Markdown:      analysis.Markdown(opts.Language),
+		HTML:          analysis.HTML(opts.Language),
+	})
+}
+
+// GetAiSportsSummaryStream 以 Server-Sent Events 流式返回AI分析结果。
+// 事件: delta(data 为 {"content": 增量文本})、done(data 为 {"id", "provider", "model", "cached", "verification"})、error(data 为 {"error": 错误信息})。
+// 浏览器断开时请求的 context 被取消，上游模型调用随之中止
+// @Summary 流式获取运动数据的AI分析
+// @Description 与 /ai/summary 相同，但通过 SSE 逐段返回分析结果
+// @Tags AI分析
+// @Produce text/event-stream
+// @Param   accountId  path     string     true        "账号ID"
+// @Param   labelId    query    string     true        "运动记录ID"
+// @Param   sportType  query    string     true        "运动类型，高驰 sportType 或统一名称(如 run、bike、pool_swim)，按运动类型选择分析器"
+// @Param   refresh    query    bool       false       "为 true 时忽略缓存重新生成"
+// @Param   lang       query    string     false       "报告语言(zh、en)，默认使用配置的语言"
+// @Success 200 {string} string "SSE 事件流"
+// @Failure 400 {string} string "请求参数错误"
+// @Router /coros/accounts/{accountId}/ai/summary/stream [get]
+func (h *CorosHandler) GetAiSportsSummaryStream(w http.ResponseWriter, r *http.Request) {
+	labelId := r.URL.Query().Get("labelId")
+	sportType := r.URL.Query().Get("sportType")
+	if labelId == "" || sportType == "" {
+		http.Error(w, "labelId and sportType are required", http.StatusBadRequest)
+		return
+	}
+
+	sp, err := sport.Parse(sportType)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // 关闭反向代理缓冲
+	w.WriteHeader(http.StatusOK)
+	// 先发送注释行，让浏览器尽快确认连接
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	ctx := r.Context()
+	opts := analyzer.Options{Refresh: r.URL.Query().Get("refresh") == "true", Language: r.URL.Query().Get("lang")}
+	result, err := h.analyzers.AnalyzeStream(ctx, h.corosService, h.reports, r.PathValue("accountId"), labelId, sp, opts, func(delta string) error {
+		if err := writeSSE(w, "delta", map[string]string{"content": delta}); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return ctx.Err()
+	})
+	if ctx.Err() != nil {
+		// 客户端已断开，无需再写
+		return
+	}
+	if err != nil {
+		writeSSE(w, "error", map[string]string{"error": err.Error()})
+	} else {
+		writeSSE(w, "done", map[string]interface{}{"id": result.ID, "provider": result.Provider, "model": result.Model, "cached": result.Cached, "compaction": result.Compaction, "verification": result.Verification})
+	}
+	flusher.Flush()
+}
+
+// writeSSE 写出一个 SSE 事件，data 为 JSON
+func writeSSE(w http.ResponseWriter, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+// ListAiReports 列出活动的所有AI报告版本，按生成时间倒序
+// @Summary 列出AI报告版本
+// @Tags AI分析
+// @Produce json
+// @Param   accountId  path     string     true        "账号ID"
+// @Param   labelId    path     string     true        "运动记录ID"
+// @Success 200 {array} report.Report
+// @Router /coros/accounts/{accountId}/activities/{labelId}/ai/reports [get]
+func (h *CorosHandler) ListAiReports(w http.ResponseWriter, r *http.Request) {
+	reports, err := h.reports.List(activity.SourceCoros + "-" + r.PathValue("labelId"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
+
+// GetAiReport 获取活动的某个AI报告版本
+// @Summary 获取AI报告版本
+// @Tags AI分析
+// @Produce json
+// @Param   accountId  path     string     true        "账号ID"
+// @Param   labelId    path     string     true        "运动记录ID"
+// @Param   reportId   path     string     true        "报告版本ID"
+// @Success 200 {object} report.Report
+// @Failure 404 {string} string "报告不存在"
+// @Router /coros/accounts/{accountId}/activities/{labelId}/ai/reports/{reportId} [get]
+func (h *CorosHandler) GetAiReport(w http.ResponseWriter, r *http.Request) {
+	result, err := h.reports.Get(activity.SourceCoros+"-"+r.PathValue("labelId"), r.PathValue("reportId"))
+	if errors.Is(err, report.ErrReportNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ImportActivities 将最近的活动原始文件导入本地，size 默认 20，最大 100
+func (h *CorosHandler) ImportActivities(w http.ResponseWriter, r *http.Request) {
+	size := 20
+	if sizeStr := r.URL.Query().Get("size"); sizeStr != "" {
+		n, err := strconv.Atoi(sizeStr)
+		if err != nil || n <= 0 || n > 100 {
+			http.Error(w, "size must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		size = n
+	}
+
+	result, err := h.corosService.ImportActivities(r.Context(), r.PathValue("accountId"), size)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ImportActivity 导入单条活动的原始文件
+func (h *CorosHandler) ImportActivity(w http.ResponseWriter, r *http.Request) {
+	sp, err := sport.Parse(r.URL.Query().Get("sportType"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	a, err := h.corosService.ImportActivity(r.Context(), r.PathValue("accountId"), r.PathValue("labelId"), sp)
+	if err != nil {
+		writeAccountError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
 }
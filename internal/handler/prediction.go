@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fitgo/internal/service/coros"
+	"fitgo/internal/service/prediction"
+)
+
+type PredictionHandler struct {
+	predictionService prediction.PredictionService
+}
+
+func NewPredictionHandler(service prediction.PredictionService) *PredictionHandler {
+	return &PredictionHandler{
+		predictionService: service,
+	}
+}
+
+// predictionRequest 预测请求体，races 为本地活动之外的近期比赛成绩
+type predictionRequest struct {
+	Days  int                      `json:"days"`
+	Until string                   `json:"until"`
+	Races []prediction.Performance `json:"races"`
+}
+
+// writePredictionError 成绩不合法返回 400，没有可用成绩返回 404
+func writePredictionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, prediction.ErrInvalidPerformance):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, prediction.ErrNotEnoughData):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GetPrediction 根据账号最近 days 天(默认 90)的最好成绩预测比赛成绩，until(YYYY-MM-DD)指定截止日期
+func (h *PredictionHandler) GetPrediction(w http.ResponseWriter, r *http.Request) {
+	req := predictionRequest{Until: r.URL.Query().Get("until")}
+	if value := r.URL.Query().Get("days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			http.Error(w, "days 必须是非负整数", http.StatusBadRequest)
+			return
+		}
+		req.Days = days
+	}
+	h.predict(w, r.PathValue("accountId"), req)
+}
+
+// PostPrediction 与 GetPrediction 相同，请求体中可以附带近期的比赛成绩
+func (h *PredictionHandler) PostPrediction(w http.ResponseWriter, r *http.Request) {
+	var req predictionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "请求体必须是合法的JSON", http.StatusBadRequest)
+		return
+	}
+	h.predict(w, r.PathValue("accountId"), req)
+}
+
+func (h *PredictionHandler) predict(w http.ResponseWriter, accountID string, req predictionRequest) {
+	opts := prediction.Options{AccountID: accountID, Days: req.Days, Races: req.Races}
+	if req.Until != "" {
+		day, err := time.ParseInLocation(coros.DateLayout, req.Until, time.Local)
+		if err != nil {
+			http.Error(w, "until 格式应为 YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		opts.Until = day.AddDate(0, 0, 1) // 包含截止当天
+	}
+
+	result, err := h.predictionService.Predict(opts)
+	if err != nil {
+		writePredictionError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
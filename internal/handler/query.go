@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/query"
+	"fitgo/internal/service/usage"
+)
+
+type QueryHandler struct {
+	queryService query.QueryService
+}
+
+func NewQueryHandler(service query.QueryService) *QueryHandler {
+	return &QueryHandler{
+		queryService: service,
+	}
+}
+
+// queryRequest 查询请求体，带 query 时跳过模型直接执行 DSL
+type queryRequest struct {
+	Question string       `json:"question"`
+	Query    *query.Query `json:"query,omitempty"`
+}
+
+// writeQueryError 问题为空或缺少账号返回 400，查询不合法返回 422，超出花费上限返回 429，未配置 AI 返回 503，模型调用失败返回 502
+func writeQueryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, query.ErrEmptyQuestion), errors.Is(err, query.ErrNoAccount):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, query.ErrInvalidQuery):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, aiservice.ErrNoProvider):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, usage.ErrSpendCapExceeded):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	}
+}
+
+// Query 把自然语言问题翻译成查询并在账号的活动上执行，返回结果表格和简短回答
+func (h *QueryHandler) Query(w http.ResponseWriter, r *http.Request) {
+	accountID := r.PathValue("accountId")
+	var req queryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "请求体必须是合法的JSON", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if req.Query != nil {
+		table, err := h.queryService.Execute(accountID, req.Query)
+		if err != nil {
+			writeQueryError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(query.Answer{Query: req.Query, Table: table})
+		return
+	}
+
+	answer, err := h.queryService.Ask(r.Context(), accountID, req.Question)
+	if err != nil {
+		writeQueryError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(answer)
+}
+
+// QueryLog 账号最近生成的查询，用于复核，limit 默认 50
+func (h *QueryHandler) QueryLog(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			http.Error(w, "limit 必须是非负整数", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	entries, err := h.queryService.Log(r.PathValue("accountId"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/review"
+	"fitgo/internal/service/usage"
+)
+
+type ReviewHandler struct {
+	reviewService review.ReviewService
+}
+
+func NewReviewHandler(service review.ReviewService) *ReviewHandler {
+	return &ReviewHandler{
+		reviewService: service,
+	}
+}
+
+// writeReviewError 周期或日期不合法返回 400，周期内没有活动返回 404，超出花费上限返回 429，未配置 AI 返回 503，模型调用失败返回 502
+func writeReviewError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, review.ErrInvalidPeriod), errors.Is(err, review.ErrInvalidDate):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, review.ErrNoActivities):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, aiservice.ErrNoProvider):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case errors.Is(err, usage.ErrSpendCapExceeded):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	}
+}
+
+// GetReview 返回账号在 date 所在周或月的统计数据和AI回顾。
+// 查询参数: period(week 或 month，默认 week)、date(YYYY-MM-DD，默认今天)、refresh、lang；
+// stats=true 时只返回统计数据，不调用模型
+func (h *ReviewHandler) GetReview(w http.ResponseWriter, r *http.Request) {
+	accountID := r.PathValue("accountId")
+	period := r.URL.Query().Get("period")
+	date := r.URL.Query().Get("date")
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Query().Get("stats") == "true" {
+		stats, err := h.reviewService.Stats(accountID, period, date)
+		if err != nil {
+			writeReviewError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(review.Review{Stats: stats})
+		return
+	}
+
+	opts := review.Options{Refresh: r.URL.Query().Get("refresh") == "true", Language: r.URL.Query().Get("lang")}
+	result, err := h.reviewService.Review(r.Context(), accountID, period, date, opts)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(result)
+}
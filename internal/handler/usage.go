@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"fitgo/internal/service/usage"
+)
+
+type UsageHandler struct {
+	usageService usage.UsageService
+	adminToken   string
+}
+
+// NewUsageHandler 创建用量接口，adminToken 为空时拒绝所有请求
+func NewUsageHandler(service usage.UsageService, adminToken string) *UsageHandler {
+	return &UsageHandler{
+		usageService: service,
+		adminToken:   adminToken,
+	}
+}
+
+// authorized 请求头 Authorization: Bearer <token> 与配置的管理令牌一致
+func (h *UsageHandler) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && h.adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) == 1
+}
+
+// GetUsage 按天、模型或用户汇总 AI 用量
+// @Summary 汇总 AI 用量
+// @Tags 管理
+// @Produce json
+// @Param   Authorization header string true "Bearer 加 ai.usage.admin_token 中的管理令牌"
+// @Param   groupBy  query    string     false       "分组方式 day、model 或 user，默认 day"
+// @Param   from     query    string     false       "开始日期 YYYY-MM-DD，默认 to 之前 29 天"
+// @Param   to       query    string     false       "结束日期 YYYY-MM-DD，默认今天"
+// @Success 200 {object} usage.Summary
+// @Failure 400 {string} string "请求参数错误"
+// @Failure 401 {string} string "缺少或错误的管理令牌"
+// @Router /admin/ai/usage [get]
+func (h *UsageHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		http.Error(w, "需要管理令牌", http.StatusUnauthorized)
+		return
+	}
+	groupBy := r.URL.Query().Get("groupBy")
+	if groupBy == "" {
+		groupBy = usage.GroupByDay
+	}
+
+	summary, err := h.usageService.Summary(groupBy, r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if errors.Is(err, usage.ErrInvalidGroupBy) || errors.Is(err, usage.ErrInvalidRange) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"fitgo/internal/service/coros"
+	"fitgo/internal/service/workout"
+)
+
+type WorkoutHandler struct {
+	workoutService workout.WorkoutService
+	corosService   coros.CorosService
+}
+
+func NewWorkoutHandler(workoutService workout.WorkoutService, corosService coros.CorosService) *WorkoutHandler {
+	return &WorkoutHandler{
+		workoutService: workoutService,
+		corosService:   corosService,
+	}
+}
+
+// writeWorkoutError 训练课或账号不存在返回 404，其余返回 500
+func writeWorkoutError(w http.ResponseWriter, err error) {
+	if errors.Is(err, workout.ErrWorkoutNotFound) || errors.Is(err, coros.ErrAccountNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func (h *WorkoutHandler) CreateWorkout(w http.ResponseWriter, r *http.Request) {
+	var req workout.Workout
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "请求体必须是合法的JSON", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	saved, err := h.workoutService.Create(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(saved)
+}
+
+func (h *WorkoutHandler) ListWorkouts(w http.ResponseWriter, r *http.Request) {
+	workouts, err := h.workoutService.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workouts)
+}
+
+func (h *WorkoutHandler) GetWorkout(w http.ResponseWriter, r *http.Request) {
+	saved, err := h.workoutService.Get(r.PathValue("id"))
+	if err != nil {
+		writeWorkoutError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+func (h *WorkoutHandler) DeleteWorkout(w http.ResponseWriter, r *http.Request) {
+	if err := h.workoutService.Delete(r.PathValue("id")); err != nil {
+		writeWorkoutError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WorkoutHandler) ExportTCX(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	data, err := h.workoutService.ExportTCX(id)
+	if err != nil {
+		writeWorkoutError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.garmin.tcx+xml")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+id+`.tcx"`)
+	w.Write(data)
+}
+
+func (h *WorkoutHandler) ExportFIT(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	data, err := h.workoutService.ExportFIT(id)
+	if err != nil {
+		writeWorkoutError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.ant.fit")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+id+`.fit"`)
+	w.Write(data)
+}
+
+// UploadToCoros 将训练课推送到指定高驰账号
+func (h *WorkoutHandler) UploadToCoros(w http.ResponseWriter, r *http.Request) {
+	saved, err := h.workoutService.Get(r.PathValue("id"))
+	if err != nil {
+		writeWorkoutError(w, err)
+		return
+	}
+
+	programID, err := h.corosService.UploadWorkout(r.Context(), r.PathValue("accountId"), saved)
+	if err != nil {
+		writeWorkoutError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"workout_id": saved.ID,
+		"program_id": programID,
+	})
+}
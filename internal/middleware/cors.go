@@ -10,7 +10,7 @@ func CORS(next http.Handler) http.Handler {
 		// 允许的请求方法
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		// 允许的请求头
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-User-ID")
 
 		// 处理预检请求
 		if r.Method == "OPTIONS" {
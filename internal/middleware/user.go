@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"fitgo/internal/service/usage"
+)
+
+// UserHeader 调用方标识用户的请求头，用于按用户统计和限制 AI 用量。
+// 服务不校验该请求头，它是可信的输入，应由前置的网关设置；
+// 路由中带高驰账号的 AI 接口(分析、查询、回顾)改为按账号统计和限额，不使用该请求头
+const UserHeader = "X-User-ID"
+
+// User 把请求头中的用户标识放入请求的 ctx，只用于没有高驰账号的路由(如活动问答)
+func User(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user := r.Header.Get(UserHeader); user != "" {
+			r = r.WithContext(usage.WithUser(r.Context(), user))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,96 @@
+package activity
+
+import (
+	"errors"
+
+	"fitgo/pkg/sport"
+)
+
+// ErrActivityNotFound 活动不存在
+var ErrActivityNotFound = errors.New("活动不存在")
+
+// ErrUnsupportedFormat 无法识别的文件格式
+var ErrUnsupportedFormat = errors.New("仅支持 FIT 与 TCX 文件")
+
+// 文件格式
+const (
+	FormatFIT = "fit"
+	FormatTCX = "tcx"
+)
+
+// 活动来源
+const (
+	SourceUpload = "upload"
+	SourceCoros  = "coros"
+)
+
+// ActivityService 定义了本地活动文件的导入与查询接口，
+// 手动上传和从高驰下载的原始文件都经由 Ingest 解析入库
+type ActivityService interface {
+	// Ingest 解析 FIT/TCX 文件并保存，来源ID相同的活动已存在时直接返回已有活动
+	Ingest(filename string, data []byte, source Source) (*Activity, error)
+
+	// Get 根据ID获取活动，包含分段与轨迹点
+	Get(id string) (*Activity, error)
+
+	// List 列出所有活动摘要(不含轨迹点)，按开始时间倒序
+	List() ([]*Activity, error)
+
+	// Exists 来源ID对应的活动是否已导入
+	Exists(source Source) bool
+}
+
+// Source 活动来源，ID 为来源系统中的活动ID(如高驰 labelId)，为空表示手动上传。
+// Sport 为来源系统记录的运动类型，不为空时优先于文件中的类型(TCX 无法区分越野跑等)
+type Source struct {
+	Name      string      `json:"name"`
+	ID        string      `json:"id,omitempty"`
+	AccountID string      `json:"account_id,omitempty"`
+	Sport     sport.Sport `json:"sport,omitempty"`
+}
+
+// TrackPoint 逐秒轨迹点，缺失的数据为零值
+type TrackPoint struct {
+	Time      string  `json:"time"`
+	Lat       float64 `json:"lat,omitempty"`
+	Lon       float64 `json:"lon,omitempty"`
+	Altitude  float64 `json:"altitude,omitempty"`   // 米
+	Distance  float64 `json:"distance,omitempty"`   // 累计距离，米
+	HeartRate int     `json:"heart_rate,omitempty"` // bpm
+	Cadence   int     `json:"cadence,omitempty"`
+	Speed     float64 `json:"speed,omitempty"` // 米/秒
+	Power     int     `json:"power,omitempty"` // W
+}
+
+// Lap 分段
+type Lap struct {
+	StartTime  string  `json:"start_time"`
+	Duration   float64 `json:"duration"` // 计时时间，秒
+	Distance   float64 `json:"distance"` // 米
+	Calories   int     `json:"calories,omitempty"`
+	AvgHR      int     `json:"avg_hr,omitempty"`
+	MaxHR      int     `json:"max_hr,omitempty"`
+	AvgSpeed   float64 `json:"avg_speed,omitempty"` // 米/秒
+	AvgCadence int     `json:"avg_cadence,omitempty"`
+	AvgPower   int     `json:"avg_power,omitempty"`
+}
+
+// Activity 导入的活动
+type Activity struct {
+	ID         string       `json:"id"`
+	Source     Source       `json:"source"`
+	Filename   string       `json:"filename"`
+	Format     string       `json:"format"`
+	Sport      sport.Sport  `json:"sport"`
+	StartTime  string       `json:"start_time"`
+	Duration   float64      `json:"duration"`    // 总时间，秒
+	MovingTime float64      `json:"moving_time"` // 计时时间，秒
+	Distance   float64      `json:"distance"`    // 米
+	Calories   int          `json:"calories"`
+	AvgHR      int          `json:"avg_hr"`
+	MaxHR      int          `json:"max_hr"`
+	Ascent     float64      `json:"ascent"` // 米
+	Laps       []Lap        `json:"laps,omitempty"`
+	Points     []TrackPoint `json:"points,omitempty"`
+	CreatedAt  string       `json:"created_at"`
+}
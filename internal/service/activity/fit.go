@@ -0,0 +1,141 @@
+package activity
+
+import (
+	"fmt"
+	"time"
+
+	"fitgo/pkg/fit"
+	"fitgo/pkg/sport"
+)
+
+// FIT Profile 中 session/lap/record 的字段号
+const (
+	fieldStartTime    byte = 2
+	fieldSport        byte = 5
+	fieldSubSport     byte = 6
+	fieldElapsedTime  byte = 7
+	fieldTimerTime    byte = 8
+	fieldDistance     byte = 9
+	fieldCalories     byte = 11
+	fieldSessionAvgHR byte = 16
+	fieldSessionMaxHR byte = 17
+	fieldSessionClimb byte = 22
+	fieldLapAvgSpeed  byte = 13
+	fieldLapAvgHR     byte = 15
+	fieldLapMaxHR     byte = 16
+	fieldLapCadence   byte = 17
+	fieldLapPower     byte = 19
+
+	fieldRecordLat       byte = 0
+	fieldRecordLon       byte = 1
+	fieldRecordAltitude  byte = 2
+	fieldRecordHeartRate byte = 3
+	fieldRecordCadence   byte = 4
+	fieldRecordDistance  byte = 5
+	fieldRecordSpeed     byte = 6
+	fieldRecordPower     byte = 7
+	fieldEnhancedSpeed   byte = 73
+	fieldEnhancedAlt     byte = 78
+)
+
+// semicircles 转换为度
+const semicircleDegrees = 180.0 / (1 << 31)
+
+// ParseFIT 解析 FIT 活动文件。多 session 文件(如铁三)的汇总取各 session 之和，运动类型取第一个 session
+func ParseFIT(data []byte) (*Activity, error) {
+	messages, err := fit.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Activity{Format: FormatFIT, Sport: sport.Unknown}
+	sessions := 0
+	for _, m := range messages {
+		switch m.Global {
+		case fit.MesgSession:
+			if sessions == 0 {
+				a.StartTime = fitTime(m, fieldStartTime)
+				sp, _ := m.Int(fieldSport)
+				sub, _ := m.Int(fieldSubSport)
+				a.Sport = sport.FromFIT(byte(sp), byte(sub))
+			}
+			sessions++
+			a.Duration += fitFloat(m, fieldElapsedTime, 1000, 0)
+			a.MovingTime += fitFloat(m, fieldTimerTime, 1000, 0)
+			a.Distance += fitFloat(m, fieldDistance, 100, 0)
+			a.Calories += fitInt(m, fieldCalories)
+			a.Ascent += fitFloat(m, fieldSessionClimb, 1, 0)
+			a.AvgHR = fitInt(m, fieldSessionAvgHR)
+			if hr := fitInt(m, fieldSessionMaxHR); hr > a.MaxHR {
+				a.MaxHR = hr
+			}
+		case fit.MesgLap:
+			a.Laps = append(a.Laps, Lap{
+				StartTime:  fitTime(m, fieldStartTime),
+				Duration:   fitFloat(m, fieldTimerTime, 1000, 0),
+				Distance:   fitFloat(m, fieldDistance, 100, 0),
+				Calories:   fitInt(m, fieldCalories),
+				AvgHR:      fitInt(m, fieldLapAvgHR),
+				MaxHR:      fitInt(m, fieldLapMaxHR),
+				AvgSpeed:   fitFloat(m, fieldLapAvgSpeed, 1000, 0),
+				AvgCadence: fitInt(m, fieldLapCadence),
+				AvgPower:   fitInt(m, fieldLapPower),
+			})
+		case fit.MesgRecord:
+			a.Points = append(a.Points, fitPoint(m))
+		}
+	}
+
+	if sessions == 0 && len(a.Points) == 0 {
+		return nil, fmt.Errorf("%w: 没有 session 或 record 消息", fit.ErrInvalidFile)
+	}
+	fillFromPoints(a)
+	return a, nil
+}
+
+func fitPoint(m fit.Message) TrackPoint {
+	p := TrackPoint{
+		Time:      fitTime(m, fit.FieldNumTimestamp),
+		Distance:  fitFloat(m, fieldRecordDistance, 100, 0),
+		HeartRate: fitInt(m, fieldRecordHeartRate),
+		Cadence:   fitInt(m, fieldRecordCadence),
+		Power:     fitInt(m, fieldRecordPower),
+	}
+	if lat, ok := m.Int(fieldRecordLat); ok {
+		p.Lat = float64(lat) * semicircleDegrees
+	}
+	if lon, ok := m.Int(fieldRecordLon); ok {
+		p.Lon = float64(lon) * semicircleDegrees
+	}
+
+	// 优先使用 enhanced 字段
+	if v, ok := m.Float(fieldEnhancedSpeed, 1000, 0); ok {
+		p.Speed = v
+	} else {
+		p.Speed = fitFloat(m, fieldRecordSpeed, 1000, 0)
+	}
+	if v, ok := m.Float(fieldEnhancedAlt, 5, 500); ok {
+		p.Altitude = v
+	} else {
+		p.Altitude = fitFloat(m, fieldRecordAltitude, 5, 500)
+	}
+	return p
+}
+
+func fitTime(m fit.Message, num byte) string {
+	ts, ok := m.Int(num)
+	if !ok {
+		return ""
+	}
+	return fit.Time(uint32(ts)).Format(time.RFC3339)
+}
+
+func fitFloat(m fit.Message, num byte, scale, offset float64) float64 {
+	v, _ := m.Float(num, scale, offset)
+	return v
+}
+
+func fitInt(m fit.Message, num byte) int {
+	v, _ := m.Int(num)
+	return int(v)
+}
@@ -0,0 +1,59 @@
+package activity
+
+import "time"
+
+// StandardDistances 常用的最好成绩距离(米)：1 公里、5 公里、10 公里、半程和全程马拉松
+var StandardDistances = []float64{1000, 5000, 10000, 21097.5, 42195}
+
+// BestEffort 一次活动中覆盖某个距离用时最短的区间
+type BestEffort struct {
+	Distance   float64 `json:"distance"` // 米
+	Duration   float64 `json:"duration"` // 秒，按轨迹点时间计算，包含区间内的暂停
+	ActivityID string  `json:"activity_id"`
+	StartTime  string  `json:"start_time"` // 活动开始时间
+}
+
+// BestEfforts 在逐点轨迹上用滑动窗口找出每个距离的最快区间。
+// 轨迹点缺少累计距离或时间、或活动距离不足时，该距离不出现在结果中
+func BestEfforts(a *Activity, distances []float64) []BestEffort {
+	type sample struct {
+		at       time.Time
+		distance float64
+	}
+	samples := make([]sample, 0, len(a.Points))
+	for _, p := range a.Points {
+		if p.Distance <= 0 {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, p.Time)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, sample{at, p.Distance})
+	}
+
+	var efforts []BestEffort
+	for _, target := range distances {
+		best := 0.0
+		start := 0
+		for end := range samples {
+			// 收缩窗口起点，直到再收缩就不够目标距离
+			for start+1 < end && samples[end].distance-samples[start+1].distance >= target {
+				start++
+			}
+			if samples[end].distance-samples[start].distance < target {
+				continue
+			}
+			covered := samples[end].distance - samples[start].distance
+			elapsed := samples[end].at.Sub(samples[start].at).Seconds()
+			// 按比例折算到恰好目标距离，避免采样间隔带来的偏差
+			if duration := elapsed * target / covered; duration > 0 && (best == 0 || duration < best) {
+				best = duration
+			}
+		}
+		if best > 0 {
+			efforts = append(efforts, BestEffort{Distance: target, Duration: best, ActivityID: a.ID, StartTime: a.StartTime})
+		}
+	}
+	return efforts
+}
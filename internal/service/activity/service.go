@@ -0,0 +1,216 @@
+package activity
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// activityService 是ActivityService接口的具体实现。
+// 每个活动保存为 activities/<id>.json，原始文件保存在 activities/raw/<id>.<format>
+type activityService struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewActivityService 创建活动服务，数据保存在 dataDir/activities 下
+func NewActivityService(dataDir string) ActivityService {
+	return &activityService{dir: filepath.Join(dataDir, "activities")}
+}
+
+func (s *activityService) Ingest(filename string, data []byte, source Source) (*Activity, error) {
+	if source.Name == "" {
+		source.Name = SourceUpload
+	}
+	if source.ID != "" {
+		if existing, err := s.Get(sourceKey(source)); err == nil {
+			return existing, nil
+		}
+	}
+
+	var (
+		a   *Activity
+		err error
+	)
+	switch DetectFormat(filename, data) {
+	case FormatFIT:
+		a, err = ParseFIT(data)
+	case FormatTCX:
+		a, err = ParseTCX(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a.ID = newActivityID()
+	if source.ID != "" {
+		a.ID = sourceKey(source)
+	}
+	a.Source = source
+	if source.Sport.Valid() {
+		a.Sport = source.Sport
+	}
+	a.Filename = filepath.Base(filename)
+	a.CreatedAt = time.Now().Format(time.RFC3339)
+
+	content, err := json.Marshal(a)
+	if err != nil {
+		return nil, fmt.Errorf("序列化活动失败: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Join(s.dir, "raw"), 0o755); err != nil {
+		return nil, fmt.Errorf("创建数据目录失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, "raw", a.ID+"."+a.Format), data, 0o644); err != nil {
+		return nil, fmt.Errorf("保存原始文件失败: %v", err)
+	}
+	if err := os.WriteFile(s.path(a.ID), content, 0o644); err != nil {
+		return nil, fmt.Errorf("写入活动失败: %v", err)
+	}
+	return a, nil
+}
+
+func (s *activityService) Get(id string) (*Activity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.read(s.path(id))
+}
+
+func (s *activityService) List() ([]*Activity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("列出活动失败: %v", err)
+	}
+
+	activities := make([]*Activity, 0, len(files))
+	for _, file := range files {
+		a, err := s.read(file)
+		if err != nil {
+			return nil, err
+		}
+		a.Points = nil
+		activities = append(activities, a)
+	}
+	sort.Slice(activities, func(i, j int) bool { return activities[i].StartTime > activities[j].StartTime })
+	return activities, nil
+}
+
+func (s *activityService) Exists(source Source) bool {
+	if source.ID == "" {
+		return false
+	}
+	_, err := os.Stat(s.path(sourceKey(source)))
+	return err == nil
+}
+
+func (s *activityService) path(id string) string {
+	return filepath.Join(s.dir, filepath.Base(id)+".json")
+}
+
+func (s *activityService) read(path string) (*Activity, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrActivityNotFound, strings.TrimSuffix(filepath.Base(path), ".json"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取活动失败: %v", err)
+	}
+
+	var a Activity
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("解析活动失败: %v", err)
+	}
+	return &a, nil
+}
+
+// DetectFormat 根据文件内容识别格式，内容无法判断时按扩展名
+func DetectFormat(filename string, data []byte) string {
+	if len(data) >= 12 && string(data[8:12]) == ".FIT" {
+		return FormatFIT
+	}
+	if bytes.Contains(data[:min(len(data), 512)], []byte("TrainingCenterDatabase")) {
+		return FormatTCX
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".fit":
+		return FormatFIT
+	case ".tcx":
+		return FormatTCX
+	}
+	return ""
+}
+
+// fillFromPoints 文件中缺少的汇总数据由轨迹点补齐
+func fillFromPoints(a *Activity) {
+	if len(a.Points) == 0 {
+		return
+	}
+	first, last := a.Points[0], a.Points[len(a.Points)-1]
+
+	if a.StartTime == "" {
+		a.StartTime = first.Time
+	}
+	if a.Duration == 0 {
+		start, err1 := time.Parse(time.RFC3339, first.Time)
+		end, err2 := time.Parse(time.RFC3339, last.Time)
+		if err1 == nil && err2 == nil {
+			a.Duration = end.Sub(start).Seconds()
+		}
+	}
+	if a.MovingTime == 0 {
+		a.MovingTime = a.Duration
+	}
+	if a.Distance == 0 {
+		a.Distance = last.Distance
+	}
+
+	var hrSum, hrCount int
+	var ascent float64
+	for i, p := range a.Points {
+		if p.HeartRate > 0 {
+			hrSum += p.HeartRate
+			hrCount++
+		}
+		if p.HeartRate > a.MaxHR {
+			a.MaxHR = p.HeartRate
+		}
+		if i > 0 && p.Altitude > 0 && a.Points[i-1].Altitude > 0 && p.Altitude > a.Points[i-1].Altitude {
+			ascent += p.Altitude - a.Points[i-1].Altitude
+		}
+	}
+	if a.AvgHR == 0 && hrCount > 0 {
+		a.AvgHR = hrSum / hrCount
+	}
+	if a.Ascent == 0 {
+		a.Ascent = ascent
+	}
+}
+
+// sourceKey 有来源ID的活动使用 <来源>-<ID> 作为本地ID，重复导入时据此去重
+func sourceKey(source Source) string {
+	return source.Name + "-" + source.ID
+}
+
+func newActivityID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,114 @@
+package activity
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"fitgo/pkg/sport"
+)
+
+type tcxDatabase struct {
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+type tcxActivity struct {
+	Sport string   `xml:"Sport,attr"`
+	ID    string   `xml:"Id"`
+	Laps  []tcxLap `xml:"Lap"`
+}
+
+type tcxLap struct {
+	StartTime        string          `xml:"StartTime,attr"`
+	TotalTimeSeconds float64         `xml:"TotalTimeSeconds"`
+	DistanceMeters   float64         `xml:"DistanceMeters"`
+	Calories         int             `xml:"Calories"`
+	AvgHR            int             `xml:"AverageHeartRateBpm>Value"`
+	MaxHR            int             `xml:"MaximumHeartRateBpm>Value"`
+	Cadence          int             `xml:"Cadence"`
+	AvgSpeed         float64         `xml:"Extensions>LX>AvgSpeed"`
+	AvgWatts         int             `xml:"Extensions>LX>AvgWatts"`
+	Trackpoints      []tcxTrackpoint `xml:"Track>Trackpoint"`
+}
+
+type tcxTrackpoint struct {
+	Time      string   `xml:"Time"`
+	Lat       *float64 `xml:"Position>LatitudeDegrees"`
+	Lon       *float64 `xml:"Position>LongitudeDegrees"`
+	Altitude  float64  `xml:"AltitudeMeters"`
+	Distance  float64  `xml:"DistanceMeters"`
+	HeartRate int      `xml:"HeartRateBpm>Value"`
+	Cadence   int      `xml:"Cadence"`
+	Speed     float64  `xml:"Extensions>TPX>Speed"`
+	RunCad    int      `xml:"Extensions>TPX>RunCadence"`
+	Watts     int      `xml:"Extensions>TPX>Watts"`
+}
+
+// ParseTCX 解析 TCX 活动文件，只取第一个 Activity。TCX 只区分跑步和骑行，其余运动为 Unknown
+func ParseTCX(data []byte) (*Activity, error) {
+	var db tcxDatabase
+	if err := xml.Unmarshal(data, &db); err != nil {
+		return nil, fmt.Errorf("解析 TCX 失败: %v", err)
+	}
+	if len(db.Activities) == 0 {
+		return nil, fmt.Errorf("TCX 文件中没有活动")
+	}
+
+	src := db.Activities[0]
+	a := &Activity{Format: FormatTCX, Sport: sport.FromTCX(src.Sport), StartTime: normalizeTime(src.ID)}
+	for _, l := range src.Laps {
+		lap := Lap{
+			StartTime:  normalizeTime(l.StartTime),
+			Duration:   l.TotalTimeSeconds,
+			Distance:   l.DistanceMeters,
+			Calories:   l.Calories,
+			AvgHR:      l.AvgHR,
+			MaxHR:      l.MaxHR,
+			AvgSpeed:   l.AvgSpeed,
+			AvgCadence: l.Cadence,
+			AvgPower:   l.AvgWatts,
+		}
+		if lap.AvgSpeed == 0 && lap.Duration > 0 {
+			lap.AvgSpeed = lap.Distance / lap.Duration
+		}
+		a.Laps = append(a.Laps, lap)
+
+		a.MovingTime += l.TotalTimeSeconds
+		a.Distance += l.DistanceMeters
+		a.Calories += l.Calories
+		if l.MaxHR > a.MaxHR {
+			a.MaxHR = l.MaxHR
+		}
+
+		for _, tp := range l.Trackpoints {
+			p := TrackPoint{
+				Time:      normalizeTime(tp.Time),
+				Altitude:  tp.Altitude,
+				Distance:  tp.Distance,
+				HeartRate: tp.HeartRate,
+				Cadence:   tp.Cadence,
+				Speed:     tp.Speed,
+				Power:     tp.Watts,
+			}
+			if p.Cadence == 0 {
+				p.Cadence = tp.RunCad
+			}
+			if tp.Lat != nil && tp.Lon != nil {
+				p.Lat, p.Lon = *tp.Lat, *tp.Lon
+			}
+			a.Points = append(a.Points, p)
+		}
+	}
+
+	fillFromPoints(a)
+	return a, nil
+}
+
+// normalizeTime 统一为 RFC3339，无法解析时原样返回
+func normalizeTime(value string) string {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return value
+	}
+	return t.UTC().Format(time.RFC3339)
+}
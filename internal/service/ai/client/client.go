@@ -4,9 +4,68 @@ import "context"
 
 type AIClient interface {
 	Chat(ctx context.Context, message []ChatMessage) (string, error)
+
+	// ChatStream 以流式方式调用模型，每收到一段增量文本调用一次 onDelta，返回完整回答。
+	// ctx 取消或 onDelta 返回错误时中止上游请求
+	ChatStream(ctx context.Context, message []ChatMessage, onDelta StreamHandler) (string, error)
+}
+
+// ToolCaller 支持工具调用(function calling)的客户端，目前只有 OpenAI 兼容协议的提供者实现。
+// 返回模型的 assistant 消息：ToolCalls 不为空时需要执行工具并把结果以 tool 消息追加后再次调用
+type ToolCaller interface {
+	ChatWithTools(ctx context.Context, message []ChatMessage, tools []Tool) (*ChatMessage, error)
 }
 
+// StreamHandler 接收流式回答的增量文本
+type StreamHandler func(delta string) error
+
+// ChatMessage 一条消息，字段与 OpenAI Chat Completions 协议一致
 type ChatMessage struct {
-	Role    string `json:"role"` // system, user, assistant
-	Content string `json:"content"`
+	Role       string     `json:"role"` // system, user, assistant, tool
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // assistant 消息中模型请求的工具调用
+	ToolCallID string     `json:"tool_call_id,omitempty"` // tool 消息对应的调用ID
+}
+
+// Tool 提供给模型的工具定义
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{} // 参数的 JSON Schema
+}
+
+// ToolCall 模型请求的一次工具调用
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"` // 固定为 function
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall 工具名称和 JSON 格式的参数
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// Usage 一次调用的 token 用量，由提供者的响应给出
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	Reported         bool // 提供者返回了用量
+}
+
+type usageKey struct{}
+
+// WithUsage 返回的 ctx 用于接收客户端从响应中解析到的用量
+func WithUsage(ctx context.Context, u *Usage) context.Context {
+	return context.WithValue(ctx, usageKey{}, u)
+}
+
+// RecordUsage 客户端解析到响应中的用量后调用，ctx 中没有接收者时忽略
+func RecordUsage(ctx context.Context, promptTokens, completionTokens int) {
+	if u, ok := ctx.Value(usageKey{}).(*Usage); ok {
+		u.PromptTokens = promptTokens
+		u.CompletionTokens = completionTokens
+		u.Reported = true
+	}
 }
@@ -0,0 +1,215 @@
+// Package fake 实现不访问网络的确定性 AIClient，用于离线运行和测试。
+// 回答来自配置的脚本或模板，可以模拟延迟、失败和流式输出
+package fake
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"fitgo/internal/service/ai/client"
+	"fitgo/internal/service/ai/tokens"
+	"fitgo/pkg/config"
+)
+
+// defaultChunkSize 流式输出每段的字符数
+const defaultChunkSize = 8
+
+// defaultTemplate 没有配置回答时使用的模板
+const defaultTemplate = "[fake] 第 {{.Call}} 次调用，{{len .Messages}} 条消息，提示词摘要 {{.Digest}}"
+
+// ErrSimulated 按配置模拟的失败
+var ErrSimulated = errors.New("fake 提供者模拟失败")
+
+// Client 实现 AIClient 和 ToolCaller 接口
+type Client struct {
+	cfg   config.FakeConfig
+	model string
+	tmpl  *template.Template
+
+	mu    sync.Mutex
+	calls int
+}
+
+// Input 回答模板的输入
+type Input struct {
+	Call     int                  // 第几次调用，从 1 开始
+	Model    string               // 配置的模型名称
+	Messages []client.ChatMessage // 完整的消息
+	System   string               // 第一条 system 消息
+	Prompt   string               // 最后一条 user 消息
+	Digest   string               // 所有消息的 SHA-256 前 12 位
+}
+
+// NewClient 创建 fake 客户端，fake 配置为空时使用默认模板
+func NewClient(cfg *config.AIProviderConfig) (*Client, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("AI 配置不能为空")
+	}
+	c := &Client{model: cfg.Model}
+	if cfg.Fake != nil {
+		c.cfg = *cfg.Fake
+	}
+
+	text := c.cfg.Template
+	if text == "" {
+		text = defaultTemplate
+	}
+	tmpl, err := template.New("fake").Funcs(template.FuncMap{
+		"contains": strings.Contains,
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析 fake 回答模板失败: %v", err)
+	}
+	c.tmpl = tmpl
+	return c, nil
+}
+
+// Chat 实现 AIClient 接口
+func (c *Client) Chat(ctx context.Context, messages []client.ChatMessage) (string, error) {
+	reply, fail, err := c.next(messages)
+	if err != nil {
+		return "", err
+	}
+	if err := sleep(ctx, c.cfg.LatencyMs); err != nil {
+		return "", err
+	}
+	if fail {
+		return "", c.failure()
+	}
+	c.recordUsage(ctx, messages, reply)
+	return reply, nil
+}
+
+// ChatWithTools 实现 ToolCaller 接口，不请求工具，直接回答
+func (c *Client) ChatWithTools(ctx context.Context, messages []client.ChatMessage, tools []client.Tool) (*client.ChatMessage, error) {
+	reply, err := c.Chat(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	return &client.ChatMessage{Role: "assistant", Content: reply}, nil
+}
+
+// ChatStream 实现 AIClient 接口，按 chunk_size 个字符分段输出。
+// 模拟失败时先输出 fail_after 段再返回错误
+func (c *Client) ChatStream(ctx context.Context, messages []client.ChatMessage, onDelta client.StreamHandler) (string, error) {
+	reply, fail, err := c.next(messages)
+	if err != nil {
+		return "", err
+	}
+	if err := sleep(ctx, c.cfg.LatencyMs); err != nil {
+		return "", err
+	}
+
+	size := c.cfg.ChunkSize
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	runes := []rune(reply)
+	var full strings.Builder
+	for i, chunk := 0, 0; i < len(runes); i, chunk = i+size, chunk+1 {
+		if fail && chunk >= c.cfg.FailAfter {
+			return full.String(), c.failure()
+		}
+		if chunk > 0 {
+			if err := sleep(ctx, c.cfg.ChunkDelayMs); err != nil {
+				return full.String(), err
+			}
+		}
+		delta := string(runes[i:min(i+size, len(runes))])
+		full.WriteString(delta)
+		if err := onDelta(delta); err != nil {
+			return full.String(), err
+		}
+	}
+	if fail {
+		return full.String(), c.failure()
+	}
+	c.recordUsage(ctx, messages, reply)
+	return full.String(), nil
+}
+
+// next 生成本次调用的回答，并判断本次是否应该失败
+func (c *Client) next(messages []client.ChatMessage) (string, bool, error) {
+	c.mu.Lock()
+	c.calls++
+	call := c.calls
+	c.mu.Unlock()
+
+	fail := c.cfg.FailEvery > 0 && call%c.cfg.FailEvery == 0
+	input := Input{Call: call, Model: c.model, Messages: messages, Digest: Digest(messages)}
+	for _, m := range messages {
+		if m.Role == "system" && input.System == "" {
+			input.System = m.Content
+		}
+		if m.Role == "user" {
+			input.Prompt = m.Content
+		}
+	}
+
+	var reply string
+	if len(c.cfg.Replies) > 0 {
+		reply = c.cfg.Replies[(call-1)%len(c.cfg.Replies)]
+	} else {
+		var b strings.Builder
+		if err := c.tmpl.Execute(&b, input); err != nil {
+			return "", false, fmt.Errorf("渲染 fake 回答失败: %v", err)
+		}
+		reply = b.String()
+	}
+	if c.cfg.EchoDigest {
+		reply += "\n\n<!-- prompt-digest: " + input.Digest + " -->"
+	}
+	return reply, fail, nil
+}
+
+func (c *Client) failure() error {
+	if c.cfg.Error != "" {
+		return fmt.Errorf("%w: %s", ErrSimulated, c.cfg.Error)
+	}
+	return ErrSimulated
+}
+
+// recordUsage 按默认估算器报告 token 用量，使用量统计在离线时也有数据
+func (c *Client) recordUsage(ctx context.Context, messages []client.ChatMessage, reply string) {
+	client.RecordUsage(ctx, tokens.Default.Messages(messages), tokens.Default.Count(reply))
+}
+
+// Digest 所有消息的角色和内容的 SHA-256 前 12 位
+func Digest(messages []client.ChatMessage) string {
+	h := sha256.New()
+	for _, m := range messages {
+		h.Write([]byte(m.Role))
+		h.Write([]byte{0})
+		h.Write([]byte(m.Content))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// sleep 等待 ms 毫秒，ctx 取消时提前返回
+func sleep(ctx context.Context, ms int) error {
+	if ms <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(time.Duration(ms) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 确保 Client 实现了 AIClient 和 ToolCaller 接口
+var (
+	_ client.AIClient   = (*Client)(nil)
+	_ client.ToolCaller = (*Client)(nil)
+)
@@ -0,0 +1,198 @@
+// Package ollama 通过 Ollama 原生 /api/chat 接口调用本地模型
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"fitgo/internal/service/ai/client"
+	"fitgo/pkg/config"
+)
+
+// defaultBaseURL Ollama 默认监听地址
+const defaultBaseURL = "http://localhost:11434"
+
+// Client Ollama 客户端
+type Client struct {
+	httpClient *http.Client
+	config     *config.AIProviderConfig
+	baseURL    string
+}
+
+// NewClient 创建 Ollama 客户端，base_url 未设置时使用本机默认地址
+func NewClient(cfg *config.AIConfig) (*Client, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("AI 配置不能为空")
+	}
+	if cfg.Config.Model == "" {
+		return nil, fmt.Errorf("Ollama 需要配置 model")
+	}
+
+	baseURL := strings.TrimRight(cfg.Config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	return &Client{
+		httpClient: &http.Client{},
+		config:     &cfg.Config,
+		baseURL:    baseURL,
+	}, nil
+}
+
+// chatRequest /api/chat 请求体
+type chatRequest struct {
+	Model    string               `json:"model"`
+	Messages []client.ChatMessage `json:"messages"`
+	Stream   bool                 `json:"stream"`
+	Options  *options             `json:"options,omitempty"`
+}
+
+// options 模型采样参数，num_predict 即最大生成 token 数
+type options struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+}
+
+// chatResponse 非流式响应，流式时每行一个同结构的 JSON 对象
+type chatResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done  bool   `json:"done"`
+	Error string `json:"error"`
+
+	PromptEvalCount int `json:"prompt_eval_count"` // 输入 token 数，只在 done 时返回
+	EvalCount       int `json:"eval_count"`        // 输出 token 数
+}
+
+// Chat 实现 AIClient 接口
+func (c *Client) Chat(ctx context.Context, messages []client.ChatMessage) (string, error) {
+	if c.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.config.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	resp, err := c.post(ctx, messages, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("解析 Ollama 响应失败: %v", err)
+	}
+	if result.Error != "" {
+		return "", fmt.Errorf("Ollama 返回错误: %s", result.Error)
+	}
+	if result.Done {
+		client.RecordUsage(ctx, result.PromptEvalCount, result.EvalCount)
+	}
+	if result.Message.Content == "" {
+		return "", fmt.Errorf("Ollama 返回空响应")
+	}
+
+	return result.Message.Content, nil
+}
+
+// ChatStream 实现 AIClient 接口，Ollama 流式响应为逐行 JSON
+func (c *Client) ChatStream(ctx context.Context, messages []client.ChatMessage, onDelta client.StreamHandler) (string, error) {
+	// 流式请求不设置整体超时，由 ctx 控制取消
+	resp, err := c.post(ctx, messages, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var full strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var chunk chatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return full.String(), fmt.Errorf("解析流式响应失败: %v", err)
+		}
+		if chunk.Error != "" {
+			return full.String(), fmt.Errorf("Ollama 返回错误: %s", chunk.Error)
+		}
+		if delta := chunk.Message.Content; delta != "" {
+			full.WriteString(delta)
+			if err := onDelta(delta); err != nil {
+				return full.String(), err
+			}
+		}
+		if chunk.Done {
+			client.RecordUsage(ctx, chunk.PromptEvalCount, chunk.EvalCount)
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() != nil {
+			return full.String(), ctx.Err()
+		}
+		return full.String(), fmt.Errorf("读取流式响应失败: %v", err)
+	}
+
+	return full.String(), nil
+}
+
+func (c *Client) post(ctx context.Context, messages []client.ChatMessage, stream bool) (*http.Response, error) {
+	reqBody := chatRequest{
+		Model:    c.config.Model,
+		Messages: messages,
+		Stream:   stream,
+	}
+	if c.config.Temperature != nil || c.config.TopP != nil || c.config.MaxTokens > 0 {
+		reqBody.Options = &options{
+			Temperature: c.config.Temperature,
+			TopP:        c.config.TopP,
+			NumPredict:  c.config.MaxTokens,
+		}
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求体失败: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("创建HTTP请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+	for key, value := range c.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("调用 Ollama API 失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Ollama API 返回错误: 状态码 %d, 响应: %s", resp.StatusCode, string(body))
+	}
+	return resp, nil
+}
+
+// 确保 Client 实现了 AIClient 接口
+var _ client.AIClient = (*Client)(nil)
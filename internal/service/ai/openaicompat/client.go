@@ -0,0 +1,204 @@
+// Package openaicompat 实现 OpenAI Chat Completions 协议的通用客户端，
+// 可用于任意兼容该协议的服务(OpenAI、vLLM、LM Studio、各类网关等)。
+package openaicompat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"fitgo/internal/service/ai/client"
+	"fitgo/pkg/config"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Client 实现 AIClient 接口
+type Client struct {
+	client *openai.Client
+	config *config.AIProviderConfig
+	name   string // 用于错误信息的提供者名称
+}
+
+// NewClient 创建客户端，name 为错误信息中显示的提供者名称
+func NewClient(cfg *config.AIProviderConfig, name string) (*Client, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("AI 配置不能为空")
+	}
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("%s 需要配置 base_url", name)
+	}
+
+	openAIConfig := openai.DefaultConfig(cfg.APIKey)
+	openAIConfig.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	// 不设置 http.Client.Timeout：它包含读取响应体的时间，会截断耗时较长的流式响应。
+	// 非流式调用的超时由调用方的 ctx 控制，这里只限制等待响应头的时间
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Timeout > 0 {
+		transport.ResponseHeaderTimeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	openAIConfig.HTTPClient = &http.Client{Transport: headerTransport{headers: cfg.Headers, next: transport}}
+
+	return &Client{
+		client: openai.NewClientWithConfig(openAIConfig),
+		config: cfg,
+		name:   name,
+	}, nil
+}
+
+// Chat 实现 AIClient 接口
+func (c *Client) Chat(ctx context.Context, messages []client.ChatMessage) (string, error) {
+	resp, err := c.client.CreateChatCompletion(ctx, c.request(messages, false))
+	if err != nil {
+		return "", fmt.Errorf("调用 %s API 失败: %v", c.name, err)
+	}
+	recordUsage(ctx, resp.Usage)
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("%s 返回空响应", c.name)
+	}
+
+	return resp.Choices[0].Message.Content, nil
+}
+
+// ChatWithTools 实现 ToolCaller 接口
+func (c *Client) ChatWithTools(ctx context.Context, messages []client.ChatMessage, tools []client.Tool) (*client.ChatMessage, error) {
+	req := c.request(messages, false)
+	for _, tool := range tools {
+		req.Tools = append(req.Tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+
+	resp, err := c.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("调用 %s API 失败: %v", c.name, err)
+	}
+	recordUsage(ctx, resp.Usage)
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("%s 返回空响应", c.name)
+	}
+
+	msg := resp.Choices[0].Message
+	message := &client.ChatMessage{Role: msg.Role, Content: msg.Content}
+	for _, call := range msg.ToolCalls {
+		message.ToolCalls = append(message.ToolCalls, client.ToolCall{
+			ID:       call.ID,
+			Type:     string(call.Type),
+			Function: client.FunctionCall{Name: call.Function.Name, Arguments: call.Function.Arguments},
+		})
+	}
+	return message, nil
+}
+
+// ChatStream 实现 AIClient 接口
+func (c *Client) ChatStream(ctx context.Context, messages []client.ChatMessage, onDelta client.StreamHandler) (string, error) {
+	stream, err := c.client.CreateChatCompletionStream(ctx, c.request(messages, true))
+	if err != nil {
+		return "", fmt.Errorf("调用 %s API 失败: %v", c.name, err)
+	}
+	defer stream.Close()
+
+	var full strings.Builder
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return full.String(), nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return full.String(), ctx.Err()
+			}
+			return full.String(), fmt.Errorf("读取 %s 流式响应失败: %v", c.name, err)
+		}
+		if resp.Usage != nil {
+			recordUsage(ctx, *resp.Usage)
+		}
+		if len(resp.Choices) == 0 || resp.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		delta := resp.Choices[0].Delta.Content
+		full.WriteString(delta)
+		if err := onDelta(delta); err != nil {
+			return full.String(), err
+		}
+	}
+}
+
+func (c *Client) request(messages []client.ChatMessage, stream bool) openai.ChatCompletionRequest {
+	openAIMessages := make([]openai.ChatCompletionMessage, 0, len(messages))
+	for _, msg := range messages {
+		openAIMessage := openai.ChatCompletionMessage{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCallID: msg.ToolCallID,
+		}
+		for _, call := range msg.ToolCalls {
+			openAIMessage.ToolCalls = append(openAIMessage.ToolCalls, openai.ToolCall{
+				ID:       call.ID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: call.Function.Name, Arguments: call.Function.Arguments},
+			})
+		}
+		openAIMessages = append(openAIMessages, openAIMessage)
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model:     c.config.Model,
+		Messages:  openAIMessages,
+		Stream:    stream,
+		MaxTokens: c.config.MaxTokens,
+	}
+	if c.config.Temperature != nil {
+		req.Temperature = float32(*c.config.Temperature)
+	}
+	if c.config.TopP != nil {
+		req.TopP = float32(*c.config.TopP)
+	}
+	if stream {
+		// 要求在最后一个数据块中返回 token 用量
+		req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	}
+	return req
+}
+
+// recordUsage 记录响应中的 token 用量，服务端没有返回时忽略
+func recordUsage(ctx context.Context, u openai.Usage) {
+	if u.PromptTokens > 0 || u.CompletionTokens > 0 {
+		client.RecordUsage(ctx, u.PromptTokens, u.CompletionTokens)
+	}
+}
+
+// headerTransport 为每个请求加上配置的附加请求头
+type headerTransport struct {
+	headers map[string]string
+	next    http.RoundTripper
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.headers) == 0 {
+		return t.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// 确保 Client 实现了 AIClient 和 ToolCaller 接口
+var (
+	_ client.AIClient   = (*Client)(nil)
+	_ client.ToolCaller = (*Client)(nil)
+)
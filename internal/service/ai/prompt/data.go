@@ -0,0 +1,325 @@
+package prompt
+
+import "fitgo/pkg/sport"
+
+// 模板输入中的数值都是已经换算并带单位的文本(见 pkg/units)，空字符串表示没有数据，
+// 模板只负责排版，模型不需要做任何单位换算
+
+// RunningSummary 跑步单次分析模板(running/summary.*、running/structured.*)的输入
+type RunningSummary struct {
+	Sport     sport.Sport
+	SportName string // 运动类型的中文名称
+	Summary   ActivitySummary
+	Laps      []Lap      // 每公里分段，压缩后为合并的分段
+	Readiness *Readiness // 当日恢复数据，没有时为 nil
+	Fitness   *Fitness   // 截至当天的比赛成绩预测和训练配速，没有时为 nil
+
+	// LapCompaction 提示词超出 token 预算时对分段的压缩方式，没有压缩时为 nil。
+	// 压缩后的分段表只保留距离、用时、配速、平均心率、步频和爬升
+	LapCompaction *LapCompaction
+
+	OutputSchema string `json:"-"` // 结构化输出要求的 JSON Schema，只有 structured 模板使用
+}
+
+// ActivitySummary 整次运动的汇总
+type ActivitySummary struct {
+	Name            string
+	StartTime       string // 本地时间 YYYY-MM-DD HH:MM
+	Distance        string
+	TotalTime       string // 总时间，含暂停
+	MovingTime      string // 运动时间
+	PausedTime      string
+	AvgPace         string
+	AdjustedPace    string // 坡度调整配速
+	BestKmPace      string // 最快一公里配速
+	AvgSpeed        string
+	AvgHR           string
+	MaxHR           string
+	AvgCadence      string
+	MaxCadence      string
+	AvgStride       string
+	AvgPower        string
+	MaxPower        string
+	Ascent          string
+	Descent         string
+	Calories        string
+	TrainingLoad    string
+	AerobicEffect   string
+	AnaerobicEffect string
+}
+
+// Lap 单个分段
+type Lap struct {
+	Index        int
+	Distance     string
+	Time         string
+	Pace         string
+	AdjustedPace string
+	AvgHR        string
+	MaxHR        string
+	AvgCadence   string
+	AvgPower     string
+	Ascent       string
+	Descent      string
+}
+
+// LapCompaction 分段的压缩方式。Bucket 和 Phases 都为零值时只省略了次要的列
+type LapCompaction struct {
+	OriginalLaps int    // 压缩前的分段数
+	Bucket       string // 按固定距离合并时的距离，如 1.00 km
+	Phases       int    // 按阶段合并时的阶段数
+}
+
+// Readiness 运动当天的恢复数据
+type Readiness struct {
+	RestingHR   string
+	HRV         string // 夜间 HRV
+	HRVBaseline string
+	Sleep       string // 睡眠时长
+	DeepSleep   string
+	Fatigue     string
+	LoadRatio   string
+}
+
+// Fitness 由近期成绩推算的比赛成绩预测和训练配速
+type Fitness struct {
+	From, To      string
+	Performances  []FitnessPerformance
+	VDOT          string
+	CriticalSpeed string // 临界速度对应的配速，没有时为空
+	DPrime        string
+	Races         []FitnessRace
+	Paces         []FitnessPace
+}
+
+// FitnessPerformance 参与预测的一次成绩
+type FitnessPerformance struct {
+	Distance string
+	Time     string
+	Pace     string
+	Date     string
+	Race     bool // 比赛成绩，否则为训练中的最好成绩
+}
+
+// FitnessRace 一个比赛距离的预测
+type FitnessRace struct {
+	Race     string // 5k、10k、half_marathon、marathon
+	Name     string // 中文名称
+	Distance string
+	Time     string
+	Range    string // 置信区间，如 1:38:20-1:45:10
+	Pace     string
+}
+
+// FitnessPace 一种训练配速
+type FitnessPace struct {
+	Zone string // easy、marathon、threshold、interval、repetition
+	Name string // 中文名称
+	Pace string // 如 4:16-4:28 /km
+}
+
+// CyclingSummary 骑行单次分析模板(cycling/*)的输入
+type CyclingSummary struct {
+	Sport     sport.Sport
+	SportName string
+	Summary   ActivitySummary // 各运动共有的汇总，踏频见 Cycling
+	Cycling   CyclingMetrics
+	Laps      []CyclingLap
+	Readiness *Readiness
+
+	LapsOmitted  int    // 超出 token 预算时省略的分段数
+	OutputSchema string `json:"-"`
+}
+
+// CyclingMetrics 骑行特有的指标
+type CyclingMetrics struct {
+	MaxSpeed         string
+	NormalizedPower  string // 标准化功率
+	VariabilityIndex string // 功率变异指数(标准化功率/平均功率)
+	Work             string // 机械功
+	AvgCadence       string // 平均踏频
+	MaxCadence       string
+}
+
+// CyclingLap 骑行分段
+type CyclingLap struct {
+	Index      int
+	Distance   string
+	Time       string
+	Speed      string
+	AvgHR      string
+	AvgPower   string
+	MaxPower   string
+	AvgCadence string
+	Ascent     string
+}
+
+// SwimmingSummary 游泳单次分析模板(swimming/*)的输入
+type SwimmingSummary struct {
+	Sport     sport.Sport
+	SportName string
+	Summary   ActivitySummary
+	Swimming  SwimmingMetrics
+	Laps      []SwimLap
+	Readiness *Readiness
+
+	LapsOmitted  int
+	OutputSchema string `json:"-"`
+}
+
+// SwimmingMetrics 游泳特有的指标
+type SwimmingMetrics struct {
+	PoolLength    string // 泳池长度，公开水域为空
+	Pace          string // 平均每百米配速
+	BestPace      string // 最快分段的每百米配速
+	AvgSwolf      string
+	AvgStrokeRate string // 平均划频
+	TotalStrokes  string
+	StrokeLength  string // 平均每次划水距离
+}
+
+// SwimLap 游泳分段(泳池为每趟或每组，公开水域为自动分段)
+type SwimLap struct {
+	Index      int
+	Distance   string
+	Time       string
+	Pace       string // 每百米配速
+	Swolf      string
+	StrokeRate string
+	Strokes    string
+	AvgHR      string
+}
+
+// StrengthSummary 力量训练单次分析模板(strength/*)的输入
+type StrengthSummary struct {
+	Sport     sport.Sport
+	SportName string
+	Summary   ActivitySummary
+	Strength  StrengthMetrics
+	Sets      []StrengthSet
+	Readiness *Readiness
+
+	LapsOmitted  int    // 超出 token 预算时省略的组数
+	OutputSchema string `json:"-"`
+}
+
+// StrengthMetrics 力量训练的汇总
+type StrengthMetrics struct {
+	Sets      string // 组数
+	TotalReps string
+	Volume    string // 总负荷量(次数 × 负重)
+}
+
+// StrengthSet 一组训练
+type StrengthSet struct {
+	Index    int
+	Exercise string // 动作名称，设备未记录时为空
+	Reps     string
+	Weight   string
+	Time     string
+	AvgHR    string
+	MaxHR    string
+}
+
+// GenericSummary 没有专门分析器的运动(徒步、滑雪、划船等)的分析模板(generic/summary.*、generic/structured.*)的输入
+type GenericSummary struct {
+	Sport     sport.Sport
+	SportName string
+	Summary   ActivitySummary
+	Laps      []Lap // 只有距离、用时、配速、心率和爬升
+	Readiness *Readiness
+
+	LapsOmitted  int
+	OutputSchema string `json:"-"`
+}
+
+// BlockReview 周期训练回顾模板(generic/review.*)的输入
+type BlockReview struct {
+	Period     string // week 或 month
+	From       string // YYYY-MM-DD
+	To         string // YYYY-MM-DD，含当天
+	InProgress bool   // 周期尚未结束
+
+	Volume   ReviewVolume
+	Previous ReviewVolume // 上一周期
+	Changes  ReviewChanges
+	BySport  []ReviewSport
+
+	MaxHR string       // 划分心率区间使用的最大心率
+	Zones []ReviewZone // 没有心率数据时为空
+
+	LongestRun   *ReviewSession
+	Days         int // 统计的天数
+	ActiveDays   int
+	LongestBreak int // 最长连续休息天数
+
+	Weeks           []ReviewVolume // 月回顾中每周的训练量
+	Sessions        []ReviewSession
+	SessionsOmitted int // 超出 token 预算时省略的活动数
+}
+
+// ReviewVolume 一段时间内的训练量
+type ReviewVolume struct {
+	From       string
+	To         string
+	Sessions   int
+	Distance   string
+	MovingTime string
+	Ascent     string
+	Load       string
+}
+
+// ReviewChanges 相对上一周期的变化，上一周期为 0 时为空
+type ReviewChanges struct {
+	Distance   string
+	MovingTime string
+	Load       string
+}
+
+// ReviewSport 一个运动大类的训练量
+type ReviewSport struct {
+	Family     string // 运动大类，如 running
+	Name       string // 中文名称
+	Sessions   int
+	Distance   string
+	MovingTime string
+	Share      string // 占总运动时间的比例
+}
+
+// ReviewZone 一个心率区间的时间
+type ReviewZone struct {
+	Name  string // Z1-Z5
+	Range string // 心率范围
+	Time  string
+	Share string // 占有心率数据时间的比例
+}
+
+// ReviewSession 周期内的一次活动
+type ReviewSession struct {
+	Date       string
+	Sport      string // 运动类型，如 trail_run
+	SportName  string // 中文名称
+	Distance   string
+	MovingTime string
+	Pace       string // 只有跑步和徒步有配速
+	AvgHR      string
+	Load       string
+}
+
+// Tools 工具调用说明模板(generic/tools.*)的输入
+type Tools struct {
+	MaxIterations int // 最多的工具调用轮数
+}
+
+// Query 查询翻译模板(generic/query.*)的输入
+type Query struct {
+	Today string // 今天的日期 YYYY-MM-DD
+	Spec  string // 查询语言中可用的字段和运算符
+}
+
+// QueryAnswer 查询回答模板(generic/query_answer.*)的输入
+type QueryAnswer struct {
+	Question string
+	Query    string // 执行的查询 JSON
+	Table    string // Markdown 表格形式的结果
+}
@@ -0,0 +1,256 @@
+// Package prompt 管理 AI 分析使用的 text/template 提示词模板。
+//
+// 模板按 <运动类型或大类>/<任务>.<语言>.tmpl 命名，如 running/summary.zh.tmpl、
+// trail_run/summary.en.tmpl。内置模板随程序编译，配置的模板目录中的同名文件优先。
+// 每个模板第一行用注释声明版本：{{- /* version: 1 */ -}}，
+// 模板的版本ID由名称、声明的版本和内容(含引用的片段)哈希组成，随报告一起保存。
+// 文件名以下划线开头的是可被引用的片段。
+package prompt
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+
+	"fitgo/pkg/config"
+	"fitgo/pkg/sport"
+)
+
+// ErrTemplateNotFound 没有匹配的模板
+var ErrTemplateNotFound = errors.New("提示词模板不存在")
+
+// DefaultLanguage 未配置语言时使用的默认语言
+const DefaultLanguage = "zh"
+
+// 任务类型，与模板文件名中的任务部分对应
+const (
+	TaskSummary    = "summary"      // Markdown 报告
+	TaskStructured = "structured"   // 按 JSON Schema 输出的结构化报告
+	TaskTools      = "tools"        // 允许模型调用工具时追加的系统提示
+	TaskQuery      = "query"        // 把问题翻译成查询 DSL
+	TaskAnswer     = "query_answer" // 根据查询结果回答问题
+	TaskReview     = "review"       // 周期训练回顾
+)
+
+// 用通配符列出文件，直接嵌入目录会忽略下划线开头的片段
+//
+//go:embed templates/*/*.tmpl
+var embedded embed.FS
+
+var versionPattern = regexp.MustCompile(`^\{\{-?\s*/\*\s*version:\s*(\S+)\s*\*/\s*-?\}\}`)
+
+// Template 一个已解析的提示词模板
+type Template struct {
+	Name    string // 如 running/summary.zh
+	Version string // 模板声明的版本
+	ID      string // 版本ID: <名称>@<版本>+<内容哈希前8位>
+	tmpl    *template.Template
+}
+
+// Render 用输入数据渲染模板
+func (t *Template) Render(data interface{}) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("渲染提示词模板 %s 失败: %v", t.Name, err)
+	}
+	return b.String(), nil
+}
+
+// Store 模板集合，开启热加载时模板目录变化后自动重新加载
+type Store struct {
+	cfg config.PromptConfig
+
+	mu        sync.Mutex
+	templates map[string]*Template
+	snapshot  string // 模板目录的文件名、大小和修改时间，用于判断是否变化
+}
+
+// NewStore 加载内置模板和模板目录中的模板，任一模板解析失败都返回错误
+func NewStore(cfg config.PromptConfig) (*Store, error) {
+	s := &Store{cfg: cfg}
+	templates, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.templates = templates
+	s.snapshot = s.dirSnapshot()
+	return s, nil
+}
+
+// Lookup 按运动类型和语言选择模板：依次尝试具体运动类型、运动大类、generic，
+// 指定语言没有模板时回退到默认语言；lang 为空时使用配置的默认语言
+func (s *Store) Lookup(sp sport.Sport, task, lang string) (*Template, error) {
+	s.reloadIfChanged()
+
+	languages := []string{s.language()}
+	if lang != "" && lang != languages[0] {
+		languages = []string{lang, languages[0]}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, l := range languages {
+		for _, group := range []string{string(sp), string(sp.Family()), "generic"} {
+			if t, ok := s.templates[group+"/"+task+"."+l]; ok {
+				return t, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %s/%s.%s", ErrTemplateNotFound, sp, task, languages[0])
+}
+
+func (s *Store) language() string {
+	if s.cfg.Language != "" {
+		return s.cfg.Language
+	}
+	return DefaultLanguage
+}
+
+// reloadIfChanged 热加载：模板目录有变化时重新加载，解析失败时保留原有模板
+func (s *Store) reloadIfChanged() {
+	if !s.cfg.HotReload || s.cfg.Dir == "" {
+		return
+	}
+	snapshot := s.dirSnapshot()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if snapshot == s.snapshot {
+		return
+	}
+	templates, err := s.load()
+	if err != nil {
+		log.Printf("prompt: 重新加载模板失败，继续使用原模板: %v", err)
+		return
+	}
+	s.templates = templates
+	s.snapshot = snapshot
+	log.Printf("prompt: 已重新加载模板目录 %s", s.cfg.Dir)
+}
+
+// load 读取内置模板，再用模板目录中的同名文件覆盖
+func (s *Store) load() (map[string]*Template, error) {
+	files := map[string]string{}
+
+	root, _ := fs.Sub(embedded, "templates")
+	if err := readFS(root, files); err != nil {
+		return nil, err
+	}
+	if s.cfg.Dir != "" {
+		if _, err := os.Stat(s.cfg.Dir); err != nil {
+			return nil, fmt.Errorf("提示词模板目录不可用: %v", err)
+		}
+		if err := readFS(os.DirFS(s.cfg.Dir), files); err != nil {
+			return nil, err
+		}
+	}
+
+	partials := map[string]string{}
+	for name, content := range files {
+		if isPartial(name) {
+			partials[name] = content
+		}
+	}
+	templates := map[string]*Template{}
+	for name, content := range files {
+		if isPartial(name) {
+			continue
+		}
+		t, err := parse(name, content, partials)
+		if err != nil {
+			return nil, err
+		}
+		templates[name] = t
+	}
+	return templates, nil
+}
+
+func readFS(fsys fs.FS, files map[string]string) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != ".tmpl" {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return fmt.Errorf("读取提示词模板 %s 失败: %v", p, err)
+		}
+		files[strings.TrimSuffix(p, ".tmpl")] = string(content)
+		return nil
+	})
+}
+
+// isPartial 文件名以下划线开头的是片段，如 running/_data.zh，
+// 供其它模板用 {{template "running/_data.zh" .}} 引用，本身不能直接使用
+func isPartial(name string) bool {
+	return strings.HasPrefix(path.Base(name), "_")
+}
+
+var includePattern = regexp.MustCompile(`\{\{-?\s*template\s+"([^"]+)"`)
+
+func parse(name, content string, partials map[string]string) (*Template, error) {
+	m := versionPattern.FindStringSubmatch(content)
+	if m == nil {
+		return nil, fmt.Errorf("提示词模板 %s 缺少版本声明 {{/* version: ... */}}", name)
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("解析提示词模板 %s 失败: %v", name, err)
+	}
+
+	// 引用的片段一起解析，并计入内容哈希，片段变化时版本ID随之变化
+	hash := sha256.New()
+	hash.Write([]byte(content))
+	included := map[string]bool{}
+	queue := []string{content}
+	for len(queue) > 0 {
+		for _, ref := range includePattern.FindAllStringSubmatch(queue[0], -1) {
+			partial, ok := partials[ref[1]]
+			if !ok || included[ref[1]] {
+				continue
+			}
+			included[ref[1]] = true
+			if _, err := tmpl.New(ref[1]).Parse(partial); err != nil {
+				return nil, fmt.Errorf("解析提示词片段 %s 失败: %v", ref[1], err)
+			}
+			hash.Write([]byte(partial))
+			queue = append(queue, partial)
+		}
+		queue = queue[1:]
+	}
+
+	sum := hash.Sum(nil)
+	return &Template{
+		Name:    name,
+		Version: m[1],
+		ID:      fmt.Sprintf("%s@%s+%s", name, m[1], hex.EncodeToString(sum[:4])),
+		tmpl:    tmpl,
+	}, nil
+}
+
+func (s *Store) dirSnapshot() string {
+	if s.cfg.Dir == "" {
+		return ""
+	}
+	var b strings.Builder
+	filepath.WalkDir(s.cfg.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			fmt.Fprintf(&b, "%s|%d|%d\n", p, info.Size(), info.ModTime().UnixNano())
+		}
+		return nil
+	})
+	return b.String()
+}
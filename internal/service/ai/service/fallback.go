@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fitgo/internal/service/ai/client"
+	"fitgo/internal/service/ai/tokens"
+	"fitgo/internal/service/usage"
+	"fitgo/pkg/breaker"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// ErrNoProvider 没有可用的提供者(未配置或全部熔断)
+var ErrNoProvider = errors.New("没有可用的 AI 提供者")
+
+// 熔断默认值
+const (
+	defaultBreakerThreshold = 3
+	defaultBreakerCooldown  = 60 * time.Second
+)
+
+// Complete 按任务路由选择提供者并依次尝试，直到某个提供者成功。
+// 单个提供者出错或超时后回退到下一个，ctx 被取消时立即返回
+func (s *AIService) Complete(ctx context.Context, task string, messages []client.ChatMessage) (*Response, error) {
+	if err := s.allow(ctx); err != nil {
+		return nil, err
+	}
+	var errs []string
+	for _, p := range s.candidates(task, messages) {
+		if !p.breaker.Allow() {
+			errs = append(errs, p.name+": 已熔断")
+			continue
+		}
+
+		m := s.meter(ctx, task, p, messages)
+		content, err := p.chat(m.ctx, messages)
+		m.done(content, err)
+		if err == nil {
+			p.breaker.Record(true)
+			return &Response{Content: content, Provider: p.name, Model: p.model}, nil
+		}
+		if ctx.Err() != nil {
+			p.breaker.Release()
+			return nil, ctx.Err()
+		}
+		p.fail(err)
+		errs = append(errs, fmt.Sprintf("%s: %v", p.name, err))
+	}
+	return nil, noProviderError(errs)
+}
+
+// CompleteWithTools 与 Complete 相同，但把工具定义交给模型，只尝试支持工具调用的提供者。
+// 返回的 Response.ToolCalls 不为空时由调用方执行工具后继续对话(见 ai/tools)
+func (s *AIService) CompleteWithTools(ctx context.Context, task string, messages []client.ChatMessage, tools []client.Tool) (*Response, error) {
+	if err := s.allow(ctx); err != nil {
+		return nil, err
+	}
+	var errs []string
+	for _, p := range s.candidates(task, messages) {
+		caller, ok := p.client.(client.ToolCaller)
+		if !ok {
+			errs = append(errs, p.name+": 不支持工具调用")
+			continue
+		}
+		if !p.breaker.Allow() {
+			errs = append(errs, p.name+": 已熔断")
+			continue
+		}
+
+		m := s.meter(ctx, task, p, messages)
+		message, err := p.chatWithTools(m.ctx, caller, messages, tools)
+		if err == nil {
+			m.done(message.Content, nil)
+			p.breaker.Record(true)
+			return &Response{Content: message.Content, Provider: p.name, Model: p.model, ToolCalls: message.ToolCalls}, nil
+		}
+		m.done("", err)
+		if ctx.Err() != nil {
+			p.breaker.Release()
+			return nil, ctx.Err()
+		}
+		p.fail(err)
+		errs = append(errs, fmt.Sprintf("%s: %v", p.name, err))
+	}
+	return nil, noProviderError(errs)
+}
+
+// CompleteStream 与 Complete 相同，但以流式方式返回。
+// 已经输出过增量文本的提供者失败时不再回退，避免拼接两个模型的输出
+func (s *AIService) CompleteStream(ctx context.Context, task string, messages []client.ChatMessage, onDelta client.StreamHandler) (*Response, error) {
+	if err := s.allow(ctx); err != nil {
+		return &Response{}, err
+	}
+	var errs []string
+	for _, p := range s.candidates(task, messages) {
+		if !p.breaker.Allow() {
+			errs = append(errs, p.name+": 已熔断")
+			continue
+		}
+
+		started := false
+		m := s.meter(ctx, task, p, messages)
+		content, err := p.chatStream(m.ctx, messages, func(delta string) error {
+			started = true
+			return onDelta(delta)
+		})
+		m.done(content, err)
+		resp := &Response{Content: content, Provider: p.name, Model: p.model}
+		if err == nil {
+			p.breaker.Record(true)
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			p.breaker.Release()
+			return resp, ctx.Err()
+		}
+		p.fail(err)
+		if started {
+			return resp, fmt.Errorf("%s: %w", p.name, err)
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", p.name, err))
+	}
+	return &Response{}, noProviderError(errs)
+}
+
+// candidates 返回本次调用依次尝试的提供者：匹配的路由规则中的提供者在前，其余按优先级在后
+func (s *AIService) candidates(task string, messages []client.ChatMessage) []*provider {
+	inputChars := 0
+	for _, msg := range messages {
+		inputChars += len([]rune(msg.Content))
+	}
+
+	var preferred []string
+	for _, route := range s.routes {
+		if route.Task != task && route.Task != "*" {
+			continue
+		}
+		if route.MaxInputChars > 0 && inputChars > route.MaxInputChars {
+			continue
+		}
+		preferred = route.Providers
+		break
+	}
+
+	ordered := make([]*provider, 0, len(s.providers))
+	used := make(map[string]bool, len(s.providers))
+	for _, name := range preferred {
+		for _, p := range s.providers {
+			if p.name == name && !used[name] {
+				ordered = append(ordered, p)
+				used[name] = true
+			}
+		}
+	}
+	for _, p := range s.providers {
+		if !used[p.name] {
+			ordered = append(ordered, p)
+		}
+	}
+	return ordered
+}
+
+// chat 非流式调用，配置了超时的提供者超时后视为失败
+func (p *provider) chat(ctx context.Context, messages []client.ChatMessage) (string, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	return p.client.Chat(ctx, messages)
+}
+
+// chatWithTools 带工具定义的非流式调用，超时处理与 chat 相同
+func (p *provider) chatWithTools(ctx context.Context, caller client.ToolCaller, messages []client.ChatMessage, tools []client.Tool) (*client.ChatMessage, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	return caller.ChatWithTools(ctx, messages, tools)
+}
+
+// chatStream 流式调用，配置了超时的提供者超过 timeout 没有新的增量文本时视为失败。
+// 超时只限制两段输出之间的间隔，不限制总时长，长报告不会在输出中途被截断
+func (p *provider) chatStream(ctx context.Context, messages []client.ChatMessage, onDelta client.StreamHandler) (string, error) {
+	if p.timeout <= 0 {
+		return p.client.ChatStream(ctx, messages, onDelta)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	idle := time.AfterFunc(p.timeout, func() { cancel(errStreamIdle) })
+	defer idle.Stop()
+
+	content, err := p.client.ChatStream(ctx, messages, func(delta string) error {
+		idle.Reset(p.timeout)
+		return onDelta(delta)
+	})
+	if err != nil && errors.Is(context.Cause(ctx), errStreamIdle) {
+		return content, fmt.Errorf("%w: %v", errStreamIdle, p.timeout)
+	}
+	return content, err
+}
+
+// errStreamIdle 流式响应超过提供者的超时时间没有新的输出
+var errStreamIdle = errors.New("流式响应超时没有新的输出")
+
+// allow 配置了用量记录时检查花费上限
+func (s *AIService) allow(ctx context.Context) error {
+	if s.usage == nil {
+		return nil
+	}
+	return s.usage.Allow(ctx)
+}
+
+// metering 对一个提供者的一次尝试计量，ctx 用于接收客户端解析到的用量
+type metering struct {
+	ctx      context.Context
+	service  *AIService
+	task     string
+	provider *provider
+	messages []client.ChatMessage
+	start    time.Time
+	usage    client.Usage
+}
+
+func (s *AIService) meter(ctx context.Context, task string, p *provider, messages []client.ChatMessage) *metering {
+	m := &metering{service: s, task: task, provider: p, messages: messages, start: time.Now()}
+	m.ctx = client.WithUsage(ctx, &m.usage)
+	return m
+}
+
+// done 记录本次尝试的用量、耗时和错误，失败的调用同样记录(可能已经计费)。
+// 提供者没有返回用量时按该类提供者的估算器估算
+func (m *metering) done(output string, err error) {
+	if m.service.usage == nil {
+		return
+	}
+	r := &usage.Record{
+		Task:             m.task,
+		Provider:         m.provider.name,
+		Model:            m.provider.model,
+		PromptTokens:     m.usage.PromptTokens,
+		CompletionTokens: m.usage.CompletionTokens,
+		LatencyMs:        time.Since(m.start).Milliseconds(),
+	}
+	if !m.usage.Reported {
+		estimator := tokens.For(m.provider.kind)
+		r.PromptTokens = estimator.Messages(m.messages)
+		r.CompletionTokens = estimator.Count(output)
+		r.Estimated = true
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	m.service.usage.Record(m.ctx, r)
+}
+
+func (p *provider) fail(err error) {
+	log.Printf("ai: 提供者 %s 调用失败: %v", p.name, err)
+	if p.breaker.Record(false) {
+		log.Printf("ai: 提供者 %s 连续失败 %d 次，熔断 %v", p.name, p.breaker.Threshold(), p.breaker.Cooldown())
+	}
+}
+
+func noProviderError(errs []string) error {
+	if len(errs) == 0 {
+		return ErrNoProvider
+	}
+	return fmt.Errorf("%w: %s", ErrNoProvider, strings.Join(errs, "; "))
+}
+
+// newBreaker 按配置创建提供者的熔断器，未配置时使用默认值。
+// 熔断器属于 AIService 实例，服务在启动时只创建一次，健康状态在请求之间保留
+func newBreaker(threshold, cooldownSeconds int) *breaker.Breaker {
+	if threshold == 0 {
+		threshold = defaultBreakerThreshold
+	}
+	cooldown := defaultBreakerCooldown
+	if cooldownSeconds > 0 {
+		cooldown = time.Duration(cooldownSeconds) * time.Second
+	}
+	return breaker.New(threshold, cooldown)
+}
@@ -3,15 +3,57 @@ package service
 import (
 	"context"
 	"fitgo/internal/service/ai/client"
+	"fitgo/internal/service/ai/fake"
 	"fitgo/internal/service/ai/gemini"
+	"fitgo/internal/service/ai/ollama"
+	"fitgo/internal/service/ai/openaicompat"
 	qwenservice "fitgo/internal/service/ai/qwen"
+	"fitgo/internal/service/ai/tokens"
+	"fitgo/internal/service/usage"
+	"fitgo/pkg/breaker"
 	"fitgo/pkg/config"
 	"fmt"
+	"sort"
+	"time"
 )
 
-// AIService AI 服务
+// 任务类型，用于路由规则
+const (
+	TaskSummary = "summary" // 单次运动分析
+	TaskReview  = "review"  // 周期回顾
+	TaskChat    = "chat"    // 对话
+	TaskQuery   = "query"   // 自然语言查询
+)
+
+// DefaultMaxInputTokens 未配置 max_input_tokens 时分析提示词的 token 预算
+const DefaultMaxInputTokens = 6000
+
+// AIService AI 服务，按优先级和路由规则在多个提供者之间回退
 type AIService struct {
-	client client.AIClient
+	providers      []*provider // 按优先级排序
+	routes         []config.AIRouteConfig
+	maxInputTokens int
+	usage          usage.UsageService // 为 nil 时不记录用量
+}
+
+// Response 一次模型调用的结果
+type Response struct {
+	Content  string `json:"content"`
+	Provider string `json:"provider"` // 实际应答的提供者名称
+	Model    string `json:"model"`
+
+	ToolCalls []client.ToolCall `json:"tool_calls,omitempty"` // 只有 CompleteWithTools 返回
+}
+
+// provider 提供者链中的一项
+type provider struct {
+	name    string
+	kind    string // 提供者类型，如 qwen
+	model   string
+	timeout time.Duration
+	client  client.AIClient
+	breaker *breaker.Breaker
+	budget  int // 提示词的 token 预算，0 表示使用全局配置
 }
 
 // NewAIService 创建 AI 服务
@@ -20,31 +62,133 @@ func NewAIService(cfg *config.AIConfig) (*AIService, error) {
 		return nil, fmt.Errorf("AI 配置不能为空")
 	}
 
-	var aiClient client.AIClient
-	var err error
+	entries := cfg.Providers
+	if len(entries) == 0 {
+		entries = []config.AIProviderEntry{{Name: cfg.Provider, Provider: cfg.Provider, Config: cfg.Config}}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Priority < entries[j].Priority })
+
+	s := &AIService{routes: cfg.Routes, maxInputTokens: cfg.MaxInputTokens}
+	if s.maxInputTokens <= 0 {
+		s.maxInputTokens = DefaultMaxInputTokens
+	}
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		name := entry.Name
+		if name == "" {
+			name = entry.Provider
+		}
+		if names[name] {
+			return nil, fmt.Errorf("AI 提供者名称重复: %s", name)
+		}
+		names[name] = true
+
+		aiClient, err := newClient(entry.Provider, entry.Config)
+		if err != nil {
+			return nil, err
+		}
+		s.providers = append(s.providers, &provider{
+			name:    name,
+			kind:    entry.Provider,
+			model:   entry.Config.Model,
+			timeout: time.Duration(entry.Config.Timeout) * time.Second,
+			client:  aiClient,
+			breaker: newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+			budget:  entry.Config.MaxInputTokens,
+		})
+	}
+
+	for _, route := range cfg.Routes {
+		for _, name := range route.Providers {
+			if !names[name] {
+				return nil, fmt.Errorf("路由规则 %s 引用了未知的 AI 提供者: %s", route.Task, name)
+			}
+		}
+	}
 
-	switch cfg.Provider {
+	return s, nil
+}
+
+// newClient 根据提供者类型创建客户端
+func newClient(providerType string, providerConfig config.AIProviderConfig) (client.AIClient, error) {
+	cfg := &config.AIConfig{Provider: providerType, Config: providerConfig}
+
+	switch providerType {
 	case "qwen":
-		aiClient, err = qwenservice.NewClient(cfg)
+		aiClient, err := qwenservice.NewClient(cfg)
 		if err != nil {
 			return nil, fmt.Errorf("创建千问客户端失败: %v", err)
 		}
+		return aiClient, nil
 	case "gemini":
-		aiClient, err = gemini.NewClient(cfg)
+		aiClient, err := gemini.NewClient(cfg)
 		if err != nil {
 			return nil, fmt.Errorf("创建 Gemini 客户端失败: %v", err)
 		}
+		return aiClient, nil
+	case "openai-compatible", "openai":
+		aiClient, err := openaicompat.NewClient(&cfg.Config, "OpenAI 兼容接口")
+		if err != nil {
+			return nil, fmt.Errorf("创建 OpenAI 兼容客户端失败: %v", err)
+		}
+		return aiClient, nil
+	case "ollama":
+		aiClient, err := ollama.NewClient(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("创建 Ollama 客户端失败: %v", err)
+		}
+		return aiClient, nil
+	case "fake":
+		aiClient, err := fake.NewClient(&cfg.Config)
+		if err != nil {
+			return nil, fmt.Errorf("创建 fake 客户端失败: %v", err)
+		}
+		return aiClient, nil
 	default:
-		return nil, fmt.Errorf("不支持的AI提供者: %s", cfg.Provider)
+		return nil, fmt.Errorf("不支持的AI提供者: %s", providerType)
 	}
+}
 
-	return &AIService{client: aiClient}, nil
+// WithUsage 设置用量记录：每次调用前检查花费上限，每个提供者的每次尝试都记录 token、耗时和费用
+func (s *AIService) WithUsage(u usage.UsageService) *AIService {
+	s.usage = u
+	return s
 }
 
 // Chat 发送聊天消息
 func (s *AIService) Chat(ctx context.Context, messages []client.ChatMessage) (string, error) {
-	if s.client == nil {
-		return "", fmt.Errorf("AI 客户端未初始化")
+	resp, err := s.Complete(ctx, TaskChat, messages)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+// ChatStream 以流式方式发送聊天消息，增量文本通过 onDelta 返回
+func (s *AIService) ChatStream(ctx context.Context, messages []client.ChatMessage, onDelta client.StreamHandler) (string, error) {
+	resp, err := s.CompleteStream(ctx, TaskChat, messages, onDelta)
+	return resp.Content, err
+}
+
+// Models 返回所有提供者配置的模型名称，用于判断缓存的报告是否由当前配置的模型生成
+func (s *AIService) Models() []string {
+	models := make([]string, 0, len(s.providers))
+	for _, p := range s.providers {
+		models = append(models, p.model)
+	}
+	return models
+}
+
+// InputBudget 返回 task 首选提供者的 token 估算器和提示词预算。
+// 只按首选提供者估算，回退到上下文更小的提供者时由它自行截断或报错
+func (s *AIService) InputBudget(task string) (tokens.Estimator, int) {
+	candidates := s.candidates(task, nil)
+	if len(candidates) == 0 {
+		return tokens.Default, s.maxInputTokens
+	}
+	p := candidates[0]
+	if p.budget > 0 {
+		return tokens.For(p.kind), p.budget
 	}
-	return s.client.Chat(ctx, messages)
+	return tokens.For(p.kind), s.maxInputTokens
 }
@@ -0,0 +1,93 @@
+// Package structured 定义 AI 分析的结构化输出：Go 结构体即 Schema，
+// 模型必须返回符合 Schema 的 JSON，服务端校验、修复或重试后再渲染为 Markdown/HTML。
+package structured
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidOutput 模型输出不符合 Schema
+var ErrInvalidOutput = errors.New("AI 输出不符合结构化格式")
+
+// Analysis 单次运动分析的结构化结果
+type Analysis struct {
+	Summary         string          `json:"summary" desc:"运动表现总结，1-3 句核心洞察"`
+	Metrics         []Metric        `json:"metrics" desc:"关键指标表，数值原样引用输入数据"`
+	PaceConsistency PaceConsistency `json:"pace_consistency" desc:"配速稳定性分析"`
+	Advice          []Advice        `json:"advice" desc:"按优先级排列的改进建议，至少 3 条"`
+}
+
+// Metric 指标表中的一行
+type Metric struct {
+	Name    string `json:"name" desc:"指标名称，如 平均配速"`
+	Value   string `json:"value" desc:"带单位的数值，原样引用输入数据"`
+	Comment string `json:"comment,omitempty" desc:"简短点评"`
+}
+
+// PaceConsistency 前、中、末段的配速与心率变化
+type PaceConsistency struct {
+	Segments []Segment `json:"segments" desc:"分段对比，通常为前段、中段、末段"`
+	Findings []string  `json:"findings" desc:"节奏控制与疲劳出现的关键发现"`
+}
+
+// Segment 一个区段的表现
+type Segment struct {
+	Name      string `json:"name" desc:"区段名称，如 前段(1-3 km)"`
+	Pace      string `json:"pace" desc:"该区段的配速"`
+	HeartRate string `json:"heart_rate" desc:"该区段的心率"`
+	Note      string `json:"note,omitempty" desc:"说明"`
+}
+
+// Advice 一条改进建议
+type Advice struct {
+	Priority int    `json:"priority" desc:"优先级，1 最高"`
+	Title    string `json:"title" desc:"建议标题"`
+	Detail   string `json:"detail" desc:"具体做法"`
+}
+
+// minAdvice 建议的最少条数
+const minAdvice = 3
+
+// Validate 检查必填字段，并把建议按优先级排序
+func (a *Analysis) Validate() error {
+	var problems []string
+	if strings.TrimSpace(a.Summary) == "" {
+		problems = append(problems, "summary 不能为空")
+	}
+	if len(a.Metrics) == 0 {
+		problems = append(problems, "metrics 至少需要 1 项")
+	}
+	for i, m := range a.Metrics {
+		if strings.TrimSpace(m.Name) == "" || strings.TrimSpace(m.Value) == "" {
+			problems = append(problems, fmt.Sprintf("metrics[%d] 缺少 name 或 value", i))
+		}
+	}
+	for i, s := range a.PaceConsistency.Segments {
+		if strings.TrimSpace(s.Name) == "" {
+			problems = append(problems, fmt.Sprintf("pace_consistency.segments[%d] 缺少 name", i))
+		}
+	}
+	if len(a.PaceConsistency.Findings) == 0 {
+		problems = append(problems, "pace_consistency.findings 至少需要 1 条")
+	}
+	if len(a.Advice) < minAdvice {
+		problems = append(problems, fmt.Sprintf("advice 至少需要 %d 条，实际 %d 条", minAdvice, len(a.Advice)))
+	}
+	for i, adv := range a.Advice {
+		if adv.Priority < 1 {
+			problems = append(problems, fmt.Sprintf("advice[%d].priority 必须从 1 开始", i))
+		}
+		if strings.TrimSpace(adv.Title) == "" || strings.TrimSpace(adv.Detail) == "" {
+			problems = append(problems, fmt.Sprintf("advice[%d] 缺少 title 或 detail", i))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidOutput, strings.Join(problems, "; "))
+	}
+
+	sort.SliceStable(a.Advice, func(i, j int) bool { return a.Advice[i].Priority < a.Advice[j].Priority })
+	return nil
+}
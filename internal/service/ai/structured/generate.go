@@ -0,0 +1,41 @@
+package structured
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"fitgo/internal/service/ai/client"
+	aiservice "fitgo/internal/service/ai/service"
+)
+
+// MaxRetries 输出不合格时最多重试的次数
+const MaxRetries = 2
+
+// Generate 调用模型生成结构化分析。输出无法修复时把错误反馈给模型重试，最多 MaxRetries 次。
+// 返回的 Response.Content 为规范化后的 JSON
+func Generate(ctx context.Context, ai *aiservice.AIService, task string, messages []client.ChatMessage) (*Analysis, *aiservice.Response, error) {
+	conversation := append([]client.ChatMessage(nil), messages...)
+
+	var lastErr error
+	for attempt := 0; attempt <= MaxRetries; attempt++ {
+		resp, err := ai.Complete(ctx, task, conversation)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		a, err := Parse(resp.Content)
+		if err == nil {
+			normalized, _ := json.Marshal(a)
+			resp.Content = string(normalized)
+			return a, resp, nil
+		}
+		lastErr = err
+
+		conversation = append(conversation,
+			client.ChatMessage{Role: "assistant", Content: resp.Content},
+			client.ChatMessage{Role: "user", Content: fmt.Sprintf("上面的输出没有通过校验：%v。请只输出修正后的 JSON 对象，不要包含任何其它文字。", err)},
+		)
+	}
+	return nil, nil, fmt.Errorf("重试 %d 次后仍然失败: %w", MaxRetries, lastErr)
+}
@@ -0,0 +1,41 @@
+package structured
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var trailingComma = regexp.MustCompile(`,\s*([}\]])`)
+
+// Parse 从模型输出中解析并校验 Analysis。
+// 先做常见的修复：去掉 ```json 代码块和前后的说明文字、删除多余的尾逗号
+func Parse(output string) (*Analysis, error) {
+	text := extractObject(output)
+	if text == "" {
+		return nil, fmt.Errorf("%w: 没有找到 JSON 对象", ErrInvalidOutput)
+	}
+
+	var a Analysis
+	if err := json.Unmarshal([]byte(text), &a); err != nil {
+		repaired := trailingComma.ReplaceAllString(text, "$1")
+		if err2 := json.Unmarshal([]byte(repaired), &a); err2 != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidOutput, err)
+		}
+	}
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// extractObject 取出第一个 { 到最后一个 } 之间的内容
+func extractObject(output string) string {
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return ""
+	}
+	return output[start : end+1]
+}
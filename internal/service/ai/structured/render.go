@@ -0,0 +1,114 @@
+package structured
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// labels 渲染时使用的标题，按语言
+var labels = map[string]map[string]string{
+	"zh": {
+		"summary": "一、 🎯 运动表现总结", "metrics": "二、 📊 关键生理与技术指标",
+		"pace": "三、 📈 每公里配速稳定性分析", "advice": "四、 💡 针对性改进建议",
+		"name": "指标", "value": "数值", "comment": "点评",
+		"segment": "区段", "segPace": "配速", "segHR": "心率", "note": "说明",
+	},
+	"en": {
+		"summary": "1. 🎯 Performance Summary", "metrics": "2. 📊 Physiological and Technical Metrics",
+		"pace": "3. 📈 Pace Consistency per Kilometer", "advice": "4. 💡 Actionable Advice",
+		"name": "Metric", "value": "Value", "comment": "Comment",
+		"segment": "Segment", "segPace": "Pace", "segHR": "Heart rate", "note": "Note",
+	},
+}
+
+func labelsFor(lang string) map[string]string {
+	if l, ok := labels[lang]; ok {
+		return l
+	}
+	return labels["zh"]
+}
+
+// Markdown 渲染为与 Markdown 报告相同结构的文本
+func (a *Analysis) Markdown(lang string) string {
+	l := labelsFor(lang)
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "### **%s**\n\n%s\n\n", l["summary"], a.Summary)
+
+	fmt.Fprintf(&b, "### **%s**\n\n| %s | %s | %s |\n|---|---|---|\n", l["metrics"], l["name"], l["value"], l["comment"])
+	for _, m := range a.Metrics {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", cell(m.Name), cell(m.Value), cell(m.Comment))
+	}
+
+	fmt.Fprintf(&b, "\n### **%s**\n\n", l["pace"])
+	if len(a.PaceConsistency.Segments) > 0 {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n|---|---|---|---|\n", l["segment"], l["segPace"], l["segHR"], l["note"])
+		for _, s := range a.PaceConsistency.Segments {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", cell(s.Name), cell(s.Pace), cell(s.HeartRate), cell(s.Note))
+		}
+		b.WriteString("\n")
+	}
+	for _, f := range a.PaceConsistency.Findings {
+		fmt.Fprintf(&b, "- %s\n", f)
+	}
+
+	fmt.Fprintf(&b, "\n### **%s**\n\n", l["advice"])
+	for i, adv := range a.Advice {
+		fmt.Fprintf(&b, "%d. **%s**：%s\n", i+1, adv.Title, adv.Detail)
+	}
+	return b.String()
+}
+
+// cell 表格单元格中的竖线和换行需要转义
+func cell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+var htmlTemplate = template.Must(template.New("analysis").Parse(`<section class="ai-analysis">
+<h3>{{index .L "summary"}}</h3>
+<p>{{.A.Summary}}</p>
+<h3>{{index .L "metrics"}}</h3>
+<table>
+<thead><tr><th>{{index .L "name"}}</th><th>{{index .L "value"}}</th><th>{{index .L "comment"}}</th></tr></thead>
+<tbody>
+{{- range .A.Metrics}}
+<tr><td>{{.Name}}</td><td>{{.Value}}</td><td>{{.Comment}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+<h3>{{index .L "pace"}}</h3>
+{{- with .A.PaceConsistency.Segments}}
+<table>
+<thead><tr><th>{{index $.L "segment"}}</th><th>{{index $.L "segPace"}}</th><th>{{index $.L "segHR"}}</th><th>{{index $.L "note"}}</th></tr></thead>
+<tbody>
+{{- range .}}
+<tr><td>{{.Name}}</td><td>{{.Pace}}</td><td>{{.HeartRate}}</td><td>{{.Note}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+<ul>
+{{- range .A.PaceConsistency.Findings}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+<h3>{{index .L "advice"}}</h3>
+<ol>
+{{- range .A.Advice}}
+<li><strong>{{.Title}}</strong>：{{.Detail}}</li>
+{{- end}}
+</ol>
+</section>
+`))
+
+// HTML 渲染为 HTML 片段，所有文本都经过转义，前端可以直接插入页面
+func (a *Analysis) HTML(lang string) string {
+	var b bytes.Buffer
+	htmlTemplate.Execute(&b, struct {
+		A *Analysis
+		L map[string]string
+	}{a, labelsFor(lang)})
+	return b.String()
+}
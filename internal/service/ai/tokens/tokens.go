@@ -0,0 +1,66 @@
+// Package tokens 粗略估算文本的 token 数，用于控制提示词长度。
+// 各家分词器不同，这里按字符类别估算并略微高估，不用于计费
+package tokens
+
+import (
+	"math"
+	"unicode"
+
+	"fitgo/internal/service/ai/client"
+)
+
+// messageOverhead 每条消息的角色和分隔符大约占用的 token 数
+const messageOverhead = 4
+
+// Estimator 按字符类别估算 token 数
+type Estimator struct {
+	CJKPerToken   float64 // 每个 token 平均对应的中日韩字符数
+	CharsPerToken float64 // 每个 token 平均对应的其他字符数(英文、数字、标点、空白)
+}
+
+// Default 未知提供者使用的估算器，比各家的分词器都保守
+var Default = Estimator{CJKPerToken: 1, CharsPerToken: 3}
+
+// estimators 各类提供者的估算器，key 与 config.AIConfig.Provider 相同
+var estimators = map[string]Estimator{
+	"qwen":              {CJKPerToken: 1.3, CharsPerToken: 3.5},
+	"gemini":            {CJKPerToken: 1.2, CharsPerToken: 4},
+	"openai-compatible": {CJKPerToken: 1, CharsPerToken: 4},
+	"openai":            {CJKPerToken: 1, CharsPerToken: 4},
+	"ollama":            Default, // 本地模型的分词器差异最大
+}
+
+// For 返回提供者类型对应的估算器，未知类型返回 Default
+func For(provider string) Estimator {
+	if e, ok := estimators[provider]; ok {
+		return e
+	}
+	return Default
+}
+
+// Count 估算一段文本的 token 数
+func (e Estimator) Count(text string) int {
+	var cjk, other int
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) || isFullWidth(r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return int(math.Ceil(float64(cjk)/e.CJKPerToken + float64(other)/e.CharsPerToken))
+}
+
+// Messages 估算一组消息的 token 数，包括每条消息的固定开销
+func (e Estimator) Messages(messages []client.ChatMessage) int {
+	total := 0
+	for _, m := range messages {
+		total += e.Count(m.Content) + messageOverhead
+	}
+	return total
+}
+
+// isFullWidth 全角标点和符号，如 ，。：（）
+func isFullWidth(r rune) bool {
+	return (r >= 0x3000 && r <= 0x303f) || (r >= 0xff00 && r <= 0xffef)
+}
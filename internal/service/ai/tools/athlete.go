@@ -0,0 +1,356 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/ai/client"
+	"fitgo/internal/service/coros"
+	"fitgo/pkg/sport"
+	"fitgo/pkg/units"
+)
+
+// 工具参数的默认值和上限，天数和条数
+const (
+	defaultActivityDays = 28
+	maxActivityDays     = 365
+	defaultActivityList = 20
+	maxActivityList     = 100
+	defaultLoadDays     = 14
+	maxLoadDays         = 90
+)
+
+// athlete 查询运动员训练历史的工具，数据来自本地活动和高驰每日数据。
+// 只能查询 accountID 名下的活动，所有查询都以 asOf 为截止时间，分析历史活动时不会看到之后的训练
+type athlete struct {
+	activities activity.ActivityService
+	coros      coros.CorosService
+	accountID  string
+	asOf       time.Time
+}
+
+// NewAthleteTools 创建查询训练历史的工具集：最近活动、训练负荷、最好成绩和活动分段。
+// 活动只包括来源账号为 accountID 的活动，accountID 为空时只包括手动上传的活动；
+// corosService 为 nil 或 accountID 为空时训练负荷工具返回错误；asOf 为零值时使用当前时间
+func NewAthleteTools(activities activity.ActivityService, corosService coros.CorosService, accountID string, asOf time.Time) *Registry {
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	a := &athlete{activities: activities, coros: corosService, accountID: accountID, asOf: asOf}
+
+	r := NewRegistry()
+	r.Register(client.Tool{
+		Name:        "list_recent_activities",
+		Description: "列出截止到本次活动为止最近若干天的活动摘要(日期、类型、距离、用时、配速、心率)，按时间倒序",
+		Parameters: object(map[string]interface{}{
+			"days":  integer("查询最近多少天，默认 28，最多 365"),
+			"sport": family("只列出某一大类的活动，不填列出全部"),
+			"limit": integer("最多返回多少条，默认 20，最多 100"),
+		}),
+	}, a.listRecentActivities)
+	r.Register(client.Tool{
+		Name:        "get_training_load",
+		Description: "获取最近若干天的每日训练负荷、急性/慢性负荷(ATI/CTI)、负荷比、疲劳度、静息心率和 HRV",
+		Parameters: object(map[string]interface{}{
+			"days": integer("查询最近多少天，默认 14，最多 90"),
+		}),
+	}, a.trainingLoad)
+	r.Register(client.Tool{
+		Name:        "get_personal_bests",
+		Description: "获取 1 公里、5 公里、10 公里、半程和全程马拉松的最好成绩及对应活动",
+		Parameters: object(map[string]interface{}{
+			"sport": family("运动大类，默认 running"),
+		}),
+	}, a.personalBests)
+	r.Register(client.Tool{
+		Name:        "get_activity_splits",
+		Description: "获取某次活动的分段数据(距离、用时、配速、心率、步频、功率)",
+		Parameters: object(map[string]interface{}{
+			"activity_id": map[string]interface{}{"type": "string", "description": "活动ID，来自 list_recent_activities 或 get_personal_bests"},
+		}, "activity_id"),
+	}, a.activitySplits)
+	return r
+}
+
+// activitySummary 活动摘要，数值已格式化
+type activitySummary struct {
+	ID        string `json:"id"`
+	Date      string `json:"date"`
+	Sport     string `json:"sport"`
+	Distance  string `json:"distance"`
+	Time      string `json:"time"`
+	Pace      string `json:"pace,omitempty"`
+	AvgHR     string `json:"avg_hr,omitempty"`
+	MaxHR     string `json:"max_hr,omitempty"`
+	Ascent    string `json:"ascent,omitempty"`
+	LapsCount int    `json:"laps"`
+}
+
+func (a *athlete) listRecentActivities(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Days  int          `json:"days"`
+		Sport sport.Family `json:"sport"`
+		Limit int          `json:"limit"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("参数格式错误: %v", err)
+	}
+	days := clamp(params.Days, defaultActivityDays, maxActivityDays)
+	limit := clamp(params.Limit, defaultActivityList, maxActivityList)
+
+	activities, err := a.history(days)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]activitySummary, 0, limit)
+	for _, act := range activities {
+		if params.Sport != "" && act.Sport.Family() != params.Sport {
+			continue
+		}
+		if len(summaries) == limit {
+			break
+		}
+		summary := activitySummary{
+			ID:        act.ID,
+			Date:      localDate(act.StartTime),
+			Sport:     act.Sport.Name(),
+			Distance:  units.Distance(act.Distance),
+			Time:      units.Duration(act.MovingTime),
+			AvgHR:     units.HeartRate(float64(act.AvgHR)),
+			MaxHR:     units.HeartRate(float64(act.MaxHR)),
+			LapsCount: len(act.Laps),
+		}
+		if act.Distance > 0 && act.MovingTime > 0 {
+			summary.Pace = units.Pace(act.MovingTime / (act.Distance / 1000))
+		}
+		if act.Ascent > 0 {
+			summary.Ascent = units.Elevation(act.Ascent)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// dailyLoad 一天的负荷与恢复数据
+type dailyLoad struct {
+	Date         string `json:"date"`
+	TrainingLoad int    `json:"training_load"`
+	ATI          int    `json:"ati"`
+	CTI          int    `json:"cti"`
+	LoadRatio    string `json:"load_ratio,omitempty"`
+	Fatigue      string `json:"fatigue,omitempty"`
+	RestingHR    string `json:"resting_hr,omitempty"`
+	HRV          string `json:"hrv,omitempty"`
+}
+
+func (a *athlete) trainingLoad(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Days int `json:"days"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("参数格式错误: %v", err)
+	}
+	if a.coros == nil || a.accountID == "" {
+		return nil, fmt.Errorf("没有关联的高驰账号，无法获取训练负荷")
+	}
+	days := clamp(params.Days, defaultLoadDays, maxLoadDays)
+
+	to := a.asOf.Format(coros.DateLayout)
+	from := a.asOf.AddDate(0, 0, -(days - 1)).Format(coros.DateLayout)
+	metrics, err := a.coros.DailyMetrics(ctx, a.accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	loads := make([]dailyLoad, 0, len(metrics))
+	for _, m := range metrics {
+		loads = append(loads, dailyLoad{
+			Date:         m.Date,
+			TrainingLoad: m.TrainingLoad,
+			ATI:          m.ATI,
+			CTI:          m.CTI,
+			LoadRatio:    units.Decimal(m.LoadRatio),
+			Fatigue:      units.Decimal(m.Fatigue),
+			RestingHR:    units.HeartRate(float64(m.RestingHR)),
+			HRV:          units.Milliseconds(float64(m.HRV)),
+		})
+	}
+	return loads, nil
+}
+
+// personalBest 一个距离的最好成绩
+type personalBest struct {
+	Distance   string `json:"distance"`
+	Time       string `json:"time"`
+	Pace       string `json:"pace"`
+	Date       string `json:"date"`
+	ActivityID string `json:"activity_id"`
+}
+
+func (a *athlete) personalBests(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Sport sport.Family `json:"sport"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("参数格式错误: %v", err)
+	}
+	if params.Sport == "" {
+		params.Sport = sport.FamilyRunning
+	}
+
+	activities, err := a.history(0)
+	if err != nil {
+		return nil, err
+	}
+	best := make(map[float64]activity.BestEffort)
+	for _, summary := range activities {
+		if summary.Sport.Family() != params.Sport {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		act, err := a.activities.Get(summary.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, effort := range activity.BestEfforts(act, activity.StandardDistances) {
+			if current, ok := best[effort.Distance]; !ok || effort.Duration < current.Duration {
+				best[effort.Distance] = effort
+			}
+		}
+	}
+
+	bests := make([]personalBest, 0, len(best))
+	for _, distance := range activity.StandardDistances {
+		effort, ok := best[distance]
+		if !ok {
+			continue
+		}
+		bests = append(bests, personalBest{
+			Distance:   units.Distance(distance),
+			Time:       units.Duration(effort.Duration),
+			Pace:       units.Pace(effort.Duration / (distance / 1000)),
+			Date:       localDate(effort.StartTime),
+			ActivityID: effort.ActivityID,
+		})
+	}
+	return bests, nil
+}
+
+// split 一个分段
+type split struct {
+	Index      int    `json:"index"`
+	Distance   string `json:"distance"`
+	Time       string `json:"time"`
+	Pace       string `json:"pace,omitempty"`
+	AvgHR      string `json:"avg_hr,omitempty"`
+	MaxHR      string `json:"max_hr,omitempty"`
+	AvgCadence string `json:"avg_cadence,omitempty"`
+	AvgPower   string `json:"avg_power,omitempty"`
+}
+
+func (a *athlete) activitySplits(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		ActivityID string `json:"activity_id"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("参数格式错误: %v", err)
+	}
+	if params.ActivityID == "" {
+		return nil, fmt.Errorf("缺少 activity_id")
+	}
+
+	act, err := a.activities.Get(params.ActivityID)
+	if err != nil {
+		return nil, err
+	}
+	// 其他账号的活动按不存在处理，不透露活动是否存在
+	if act.Source.AccountID != a.accountID {
+		return nil, fmt.Errorf("%w: %s", activity.ErrActivityNotFound, params.ActivityID)
+	}
+	splits := make([]split, 0, len(act.Laps))
+	for i, lap := range act.Laps {
+		splits = append(splits, split{
+			Index:      i + 1,
+			Distance:   units.Distance(lap.Distance),
+			Time:       units.Duration(lap.Duration),
+			Pace:       units.PaceFromSpeed(lap.AvgSpeed),
+			AvgHR:      units.HeartRate(float64(lap.AvgHR)),
+			MaxHR:      units.HeartRate(float64(lap.MaxHR)),
+			AvgCadence: units.Cadence(float64(lap.AvgCadence)),
+			AvgPower:   units.Power(float64(lap.AvgPower)),
+		})
+	}
+	return splits, nil
+}
+
+// history 返回账号名下截止到 asOf、最近 days 天内的活动摘要(按时间倒序)，days 为 0 时不限制起点
+func (a *athlete) history(days int) ([]*activity.Activity, error) {
+	activities, err := a.activities.List()
+	if err != nil {
+		return nil, err
+	}
+	var from time.Time
+	if days > 0 {
+		from = a.asOf.AddDate(0, 0, -days)
+	}
+
+	filtered := make([]*activity.Activity, 0, len(activities))
+	for _, act := range activities {
+		if act.Source.AccountID != a.accountID {
+			continue
+		}
+		start, err := time.Parse(time.RFC3339, act.StartTime)
+		if err != nil || start.After(a.asOf) || start.Before(from) {
+			continue
+		}
+		filtered = append(filtered, act)
+	}
+	return filtered, nil
+}
+
+// localDate 把 RFC3339 时间转换为本地日期，无法解析时原样返回
+func localDate(value string) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return t.Local().Format(coros.DateLayout)
+}
+
+// clamp 参数未填时使用默认值，超过上限时取上限
+func clamp(value, def, max int) int {
+	if value <= 0 {
+		return def
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+func object(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func integer(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "description": description}
+}
+
+func family(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": description,
+		"enum": []string{
+			string(sport.FamilyRunning), string(sport.FamilyCycling), string(sport.FamilySwimming),
+			string(sport.FamilyStrength), string(sport.FamilyHiking), string(sport.FamilyOther),
+		},
+	}
+}
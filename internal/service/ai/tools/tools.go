@@ -0,0 +1,133 @@
+// Package tools 实现模型的工具调用循环：把工具定义交给模型，执行模型请求的工具并把结果
+// 追加到对话中，直到模型给出最终回答或达到轮数上限。每次工具调用都记录下来。
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"fitgo/internal/service/ai/client"
+	aiservice "fitgo/internal/service/ai/service"
+)
+
+// DefaultMaxIterations 未指定上限时最多的工具调用轮数
+const DefaultMaxIterations = 5
+
+// Handler 执行一次工具调用，args 为模型给出的 JSON 参数，返回值序列化为 JSON 交给模型
+type Handler func(ctx context.Context, args json.RawMessage) (interface{}, error)
+
+// Registry 一组可供模型调用的工具
+type Registry struct {
+	definitions []client.Tool
+	handlers    map[string]Handler
+}
+
+// NewRegistry 创建空的工具集
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]Handler)}
+}
+
+// Register 注册工具，同名工具后注册的覆盖先注册的处理函数
+func (r *Registry) Register(tool client.Tool, handler Handler) {
+	if _, ok := r.handlers[tool.Name]; !ok {
+		r.definitions = append(r.definitions, tool)
+	}
+	r.handlers[tool.Name] = handler
+}
+
+// Definitions 返回交给模型的工具定义
+func (r *Registry) Definitions() []client.Tool {
+	return r.definitions
+}
+
+// Call 一次工具调用的记录
+type Call struct {
+	Iteration int    `json:"iteration"` // 第几轮，从 1 开始
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Result    string `json:"result,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Duration  int64  `json:"duration_ms"`
+}
+
+// Result 工具调用循环的结果
+type Result struct {
+	*aiservice.Response
+	Calls []Call
+}
+
+// Run 执行工具调用循环。每一轮模型可以请求多个工具，工具出错时把错误作为结果交给模型而不是中止；
+// 达到 maxIterations 轮后不再提供工具，要求模型根据已有信息直接回答。maxIterations 不大于 0 时使用默认值
+func Run(ctx context.Context, ai *aiservice.AIService, task string, messages []client.ChatMessage, registry *Registry, maxIterations int) (*Result, error) {
+	if maxIterations <= 0 {
+		maxIterations = DefaultMaxIterations
+	}
+	conversation := append([]client.ChatMessage(nil), messages...)
+	result := &Result{}
+
+	for iteration := 1; iteration <= maxIterations; iteration++ {
+		resp, err := ai.CompleteWithTools(ctx, task, conversation, registry.Definitions())
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.ToolCalls) == 0 {
+			result.Response = resp
+			return result, nil
+		}
+
+		conversation = append(conversation, client.ChatMessage{Role: "assistant", Content: resp.Content, ToolCalls: resp.ToolCalls})
+		for _, toolCall := range resp.ToolCalls {
+			call := registry.execute(ctx, iteration, toolCall)
+			log.Printf("ai: 工具调用 #%d %s(%s) 耗时 %dms %s", iteration, call.Name, call.Arguments, call.Duration, call.Error)
+			result.Calls = append(result.Calls, call)
+
+			content := call.Result
+			if call.Error != "" {
+				content = fmt.Sprintf(`{"error":%q}`, call.Error)
+			}
+			conversation = append(conversation, client.ChatMessage{Role: "tool", Content: content, ToolCallID: toolCall.ID})
+		}
+	}
+
+	conversation = append(conversation, client.ChatMessage{Role: "user", Content: "工具调用次数已达上限，请根据已经获得的信息直接给出回答。"})
+	resp, err := ai.CompleteWithTools(ctx, task, conversation, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.ToolCalls = nil
+	result.Response = resp
+	return result, nil
+}
+
+// execute 执行一次工具调用并记录结果
+func (r *Registry) execute(ctx context.Context, iteration int, toolCall client.ToolCall) (call Call) {
+	call = Call{Iteration: iteration, Name: toolCall.Function.Name, Arguments: toolCall.Function.Arguments}
+	start := time.Now()
+	defer func() { call.Duration = time.Since(start).Milliseconds() }()
+
+	handler, ok := r.handlers[call.Name]
+	if !ok {
+		call.Error = "未知工具: " + call.Name
+		return call
+	}
+
+	args := json.RawMessage(call.Arguments)
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	value, err := handler(ctx, args)
+	if err != nil {
+		call.Error = err.Error()
+		return call
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		call.Error = fmt.Sprintf("序列化结果失败: %v", err)
+		return call
+	}
+	call.Result = string(data)
+	return call
+}
@@ -0,0 +1,143 @@
+package verify
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	distanceRe  = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(km|公里|千米|米|m)`)
+	durationRe  = regexp.MustCompile(`(\d+):(\d{2})(?::(\d{2}))?`)
+	paceRe      = regexp.MustCompile(`(?i)(\d+)(?::|'|′)(\d{2})(?:"|″)?\s*(?:/\s*(?:km|公里)|min/km|每公里)`)
+	quotePaceRe = regexp.MustCompile(`(\d+)(?:'|′)(\d{2})(?:"|″)`) // 5'43" 形式的配速可以不带单位
+	heartRateRe = regexp.MustCompile(`(?i)(\d+)\s*(?:bpm|次/分)`)
+	powerRe     = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(?:W|瓦)`)
+	colonLineRe = regexp.MustCompile(`^([^:：\d|]{1,40}?)\s*[:：]\s*(.+)$`)
+	listPrefix  = regexp.MustCompile(`^(?:[-*+]|\d+\.)\s+`)
+)
+
+// Markdown 提取 Markdown 报告中带名称的数值：
+// 表格中首列为指标名称的行、表头全部是指标名称的横向表格，以及“名称：数值”形式的行
+func Markdown(content string) []Claim {
+	var claims []Claim
+	var header []string // 横向表格的表头
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "|") {
+			cells := tableCells(line)
+			if separator(cells) {
+				continue
+			}
+			if header != nil && len(cells) == len(header) {
+				for i, cell := range cells {
+					claims = append(claims, Claim{Label: header[i], Value: cell})
+				}
+				continue
+			}
+			header = nil
+			if len(cells) >= 2 && allLabels(cells) {
+				header = cells
+				continue
+			}
+			if len(cells) >= 2 {
+				claims = append(claims, Claim{Label: cells[0], Value: strings.Join(cells[1:], " | ")})
+			}
+			continue
+		}
+		header = nil
+
+		line = listPrefix.ReplaceAllString(line, "")
+		if m := colonLineRe.FindStringSubmatch(strings.ReplaceAll(line, "**", "")); m != nil {
+			claims = append(claims, Claim{Label: m[1], Value: m[2]})
+		}
+	}
+	return claims
+}
+
+func tableCells(line string) []string {
+	parts := strings.Split(strings.Trim(line, "|"), "|")
+	cells := make([]string, 0, len(parts))
+	for _, p := range parts {
+		cells = append(cells, strings.TrimSpace(p))
+	}
+	return cells
+}
+
+func separator(cells []string) bool {
+	for _, c := range cells {
+		if strings.Trim(c, ":- ") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func allLabels(cells []string) bool {
+	for _, c := range cells {
+		if _, ok := lookup(c); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// parse 解析文本中第一个该类型的数值，返回统一单位的值和匹配的原文
+func parse(k kind, text string) (float64, string, bool) {
+	switch k {
+	case kindDistance:
+		for _, m := range distanceRe.FindAllStringSubmatchIndex(text, -1) {
+			// 排除 km/h、min、mph 等单位
+			if r, _ := utf8.DecodeRuneInString(text[m[1]:]); r == '/' || unicode.IsLetter(r) && r < utf8.RuneSelf {
+				continue
+			}
+			value, _ := strconv.ParseFloat(text[m[2]:m[3]], 64)
+			if unit := strings.ToLower(text[m[4]:m[5]]); unit == "km" || unit == "公里" || unit == "千米" {
+				value *= 1000
+			}
+			return value, text[m[0]:m[1]], true
+		}
+	case kindDuration:
+		for _, m := range durationRe.FindAllStringSubmatchIndex(text, -1) {
+			// 排除配速
+			if rest := strings.TrimSpace(text[m[1]:]); strings.HasPrefix(rest, "/") || strings.HasPrefix(strings.ToLower(rest), "min/km") {
+				continue
+			}
+			a, _ := strconv.Atoi(text[m[2]:m[3]])
+			b, _ := strconv.Atoi(text[m[4]:m[5]])
+			value := float64(a*60 + b)
+			if m[6] >= 0 {
+				c, _ := strconv.Atoi(text[m[6]:m[7]])
+				value = float64(a*3600 + b*60 + c)
+			}
+			return value, text[m[0]:m[1]], true
+		}
+	case kindPace:
+		m := paceRe.FindStringSubmatch(text)
+		if m == nil {
+			m = quotePaceRe.FindStringSubmatch(text)
+		}
+		if m != nil {
+			minutes, _ := strconv.Atoi(m[1])
+			seconds, _ := strconv.Atoi(m[2])
+			return float64(minutes*60 + seconds), m[0], true
+		}
+	case kindHeartRate:
+		if m := heartRateRe.FindStringSubmatch(text); m != nil {
+			value, _ := strconv.ParseFloat(m[1], 64)
+			return value, m[0], true
+		}
+	case kindPower:
+		for _, m := range powerRe.FindAllStringSubmatchIndex(text, -1) {
+			// 排除 W/kg、Wh 等单位
+			if r, _ := utf8.DecodeRuneInString(text[m[1]:]); r == '/' || unicode.IsLetter(r) && r < utf8.RuneSelf {
+				continue
+			}
+			value, _ := strconv.ParseFloat(text[m[2]:m[3]], 64)
+			return value, text[m[0]:m[1]], true
+		}
+	}
+	return 0, "", false
+}
@@ -0,0 +1,196 @@
+// Package verify 核对 AI 报告中引用的数值。
+//
+// 提示词中的数值都已在 Go 中计算并格式化，模型只需原样引用，但仍会出现换算错误(如把 52:30 写成 1:02:30)。
+// 本包从报告中提取带指标名称的距离、时间、配速、心率和功率，与提示词中的真实值在容差内比较，
+// 不一致的记为 Mismatch；距离、运动时间、平均配速、平均心率等关键指标不一致时由调用方重新生成
+package verify
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// 可核对的指标
+const (
+	Distance     = "distance"
+	TotalTime    = "total_time"
+	MovingTime   = "moving_time"
+	PausedTime   = "paused_time"
+	AvgPace      = "avg_pace"
+	AdjustedPace = "adjusted_pace"
+	BestKmPace   = "best_km_pace"
+	AvgHR        = "avg_hr"
+	MaxHR        = "max_hr"
+	AvgPower     = "avg_power"
+	MaxPower     = "max_power"
+)
+
+// kind 数值的类型，决定解析方式和容差
+type kind int
+
+const (
+	kindDistance  kind = iota // 米
+	kindDuration              // 秒
+	kindPace                  // 秒/公里
+	kindHeartRate             // bpm
+	kindPower                 // W
+)
+
+// metric 一项可核对的指标，labels 为报告中可能使用的名称(中英文，比较时忽略大小写和空白)
+type metric struct {
+	name     string
+	kind     kind
+	critical bool
+	labels   []string
+}
+
+var metrics = []metric{
+	{Distance, kindDistance, true, []string{"总距离", "距离", "distance", "total distance"}},
+	{TotalTime, kindDuration, true, []string{"总时间", "总用时", "total time", "elapsed time"}},
+	{MovingTime, kindDuration, true, []string{"运动时间", "moving time"}},
+	{PausedTime, kindDuration, false, []string{"暂停时间", "总暂停时间", "paused time", "total paused time"}},
+	{AvgPace, kindPace, true, []string{"平均配速", "average pace", "avg pace"}},
+	{AdjustedPace, kindPace, false, []string{"坡度调整配速", "grade-adjusted pace", "gap"}},
+	{BestKmPace, kindPace, false, []string{"最快一公里配速", "最快配速", "fastest km pace", "fastest pace"}},
+	{AvgHR, kindHeartRate, true, []string{"平均心率", "average heart rate", "avg hr", "average hr"}},
+	{MaxHR, kindHeartRate, false, []string{"最大心率", "max heart rate", "max hr"}},
+	{AvgPower, kindPower, false, []string{"平均功率", "average power", "avg power"}},
+	{MaxPower, kindPower, false, []string{"最大功率", "max power"}},
+}
+
+// Fact 一项指标的真实值，Value 为提示词中格式化后的文本，如 10.00 km、3:44 /km、146 bpm
+type Fact struct {
+	Metric string
+	Value  string
+}
+
+// Claim 报告中引用的一项指标，Label 为报告中的名称，Value 为名称后的文本
+type Claim struct {
+	Label string
+	Value string
+}
+
+// Mismatch 与真实值不一致的数值
+type Mismatch struct {
+	Metric   string `json:"metric"`
+	Label    string `json:"label"`    // 报告中的指标名称
+	Reported string `json:"reported"` // 报告中的数值
+	Expected string `json:"expected"` // 提示词中的真实值
+	Critical bool   `json:"critical"`
+}
+
+// Result 核对结果
+type Result struct {
+	Checked     int        `json:"checked"` // 核对的数值个数
+	Mismatches  []Mismatch `json:"mismatches,omitempty"`
+	Regenerated bool       `json:"regenerated,omitempty"` // 报告是关键数值不符后重新生成的版本
+}
+
+// Critical 是否有关键指标不一致
+func (r *Result) Critical() bool {
+	return r.CriticalCount() > 0
+}
+
+// CriticalCount 不一致的关键指标个数
+func (r *Result) CriticalCount() int {
+	n := 0
+	for _, m := range r.Mismatches {
+		if m.Critical {
+			n++
+		}
+	}
+	return n
+}
+
+// Feedback 反馈给模型的修正说明
+func (r *Result) Feedback() string {
+	var b strings.Builder
+	b.WriteString("上面的报告中有数值与提供的数据不一致：\n")
+	for _, m := range r.Mismatches {
+		fmt.Fprintf(&b, "- %s：报告中为 %s，数据中为 %s\n", m.Label, m.Reported, m.Expected)
+	}
+	b.WriteString("请原样引用提供的数值，不要重新换算，按原来的格式重新输出完整的报告。")
+	return b.String()
+}
+
+// Compare 把报告中的数值与真实值比较。没有真实值、名称无法识别或数值无法解析的项不参与核对
+func Compare(claims []Claim, facts []Fact) *Result {
+	truth := map[string]Fact{}
+	for _, f := range facts {
+		if f.Value != "" {
+			truth[f.Metric] = f
+		}
+	}
+
+	result := &Result{}
+	for _, c := range claims {
+		m, ok := lookup(c.Label)
+		if !ok {
+			continue
+		}
+		fact, ok := truth[m.name]
+		if !ok {
+			continue
+		}
+		expected, _, ok := parse(m.kind, fact.Value)
+		if !ok {
+			continue
+		}
+		reported, text, ok := parse(m.kind, c.Value)
+		if !ok {
+			continue
+		}
+		result.Checked++
+		if math.Abs(reported-expected) > tolerance(m.kind, expected) {
+			result.Mismatches = append(result.Mismatches, Mismatch{
+				Metric:   m.name,
+				Label:    strings.TrimSpace(c.Label),
+				Reported: text,
+				Expected: fact.Value,
+				Critical: m.critical,
+			})
+		}
+	}
+	return result
+}
+
+// tolerance 允许的误差：距离 1% 或 10 米，时间 1% 或 1 秒，配速 2 秒，心率 1 bpm，功率 1% 或 1 W
+func tolerance(k kind, expected float64) float64 {
+	switch k {
+	case kindDistance:
+		return math.Max(expected*0.01, 10)
+	case kindDuration:
+		return math.Max(expected*0.01, 1)
+	case kindPace:
+		return 2
+	case kindHeartRate:
+		return 1
+	default:
+		return math.Max(expected*0.01, 1)
+	}
+}
+
+// lookup 按报告中的名称查找指标，忽略 Markdown 强调、括号中的注释、大小写和空白
+func lookup(label string) (metric, bool) {
+	normalized := normalize(label)
+	for _, m := range metrics {
+		for _, l := range m.labels {
+			if normalized == l {
+				return m, true
+			}
+		}
+	}
+	return metric{}, false
+}
+
+func normalize(label string) string {
+	label = strings.NewReplacer("*", "", "_", "", "`", "").Replace(label)
+	for _, open := range []string{"(", "（"} {
+		if i := strings.Index(label, open); i > 0 {
+			label = label[:i]
+		}
+	}
+	label = strings.TrimRight(strings.TrimSpace(label), ":：")
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
@@ -0,0 +1,120 @@
+// Package analyzer 单次运动的 AI 分析。
+//
+// 每类运动有自己的分析器(见子包 running、cycling、swimming、strength、generic)，
+// 负责从高驰运动详情中提取该类运动的指标并渲染对应的提示词模板；
+// 选择模板、token 预算、缓存、工具调用和保存报告等流程由本包统一完成。
+// Registry 按统一运动类型选择分析器，没有注册的运动类型使用回退分析器。
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+
+	"fitgo/internal/service/ai/prompt"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/coros"
+	"fitgo/internal/service/prediction"
+	"fitgo/pkg/sport"
+)
+
+// ErrUnsupportedSport 没有处理该运动类型的分析器
+var ErrUnsupportedSport = errors.New("没有支持该运动类型的分析器")
+
+// Analyzer 一类运动的分析器
+type Analyzer interface {
+	// Name 分析器名称，与内置提示词模板的目录名相同，如 running、cycling
+	Name() string
+
+	// Sports 由该分析器处理的运动类型
+	Sports() []sport.Sport
+
+	// Render 从运动详情中提取指标并渲染提示词模板。
+	// fits 判断提示词是否在 token 预算内，超出时由分析器决定如何压缩
+	Render(tmpl *prompt.Template, in *Input, fits func(string) bool) (*Prompt, error)
+}
+
+// Input 各分析器共用的输入
+type Input struct {
+	Detail       *coros.SportsSummaryResult
+	Readiness    *prompt.Readiness // 当日恢复数据，没有时为 nil
+	Fitness      *prompt.Fitness   // 运动前的比赛成绩预测和训练配速，只有跑步提供，没有时为 nil
+	OutputSchema string            // 结构化输出要求的 JSON Schema，只有 structured 模板使用
+}
+
+// Prompt 渲染好的提示词
+type Prompt struct {
+	Content    string
+	Data       interface{} // 模板输入，序列化后作为输入哈希，数据变化时缓存失效
+	Compaction string      // 超出 token 预算时的压缩说明，没有压缩时为空
+}
+
+// Registry 按运动类型选择分析器
+type Registry struct {
+	analyzers   map[sport.Sport]Analyzer
+	fallback    Analyzer
+	predictions prediction.PredictionService // 为 nil 时跑步分析不包含成绩预测
+
+	ai                *aiservice.AIService // 为 nil 时分析返回 ErrNoProvider
+	prompts           *prompt.Store
+	maxToolIterations int
+}
+
+// NewRegistry 创建分析器集合，fallback 处理没有注册的运动类型，为 nil 时这些运动类型不支持分析
+func NewRegistry(fallback Analyzer) *Registry {
+	return &Registry{analyzers: map[sport.Sport]Analyzer{}, fallback: fallback}
+}
+
+// Register 注册分析器处理的所有运动类型，已注册的运动类型被覆盖
+func (r *Registry) Register(a Analyzer) *Registry {
+	for _, sp := range a.Sports() {
+		r.analyzers[sp] = a
+	}
+	return r
+}
+
+// WithPredictions 跑步分析时附带截至当天的比赛成绩预测和训练配速
+func (r *Registry) WithPredictions(p prediction.PredictionService) *Registry {
+	r.predictions = p
+	return r
+}
+
+// WithAI 设置分析使用的 AI 服务和提示词模板，两者由调用方创建一次后在所有请求间共用。
+// maxToolIterations 为允许调用工具时的最大轮数，不大于 0 时使用 tools.DefaultMaxIterations
+func (r *Registry) WithAI(ai *aiservice.AIService, prompts *prompt.Store, maxToolIterations int) *Registry {
+	r.ai = ai
+	r.prompts = prompts
+	r.maxToolIterations = maxToolIterations
+	return r
+}
+
+// Lookup 返回处理该运动类型的分析器
+func (r *Registry) Lookup(sp sport.Sport) (Analyzer, error) {
+	if a, ok := r.analyzers[sp]; ok {
+		return a, nil
+	}
+	if r.fallback != nil {
+		return r.fallback, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedSport, sp)
+}
+
+// RenderOmittingLaps 渲染提示词，超出 token 预算时调用 omit 省略分段数据后再渲染一次。
+// data 为模板输入的指针，omit 返回省略的分段数，为 0 时不再重新渲染
+func RenderOmittingLaps(tmpl *prompt.Template, data interface{}, omit func() int, fits func(string) bool) (*Prompt, error) {
+	content, err := tmpl.Render(data)
+	if err != nil {
+		return nil, err
+	}
+	if fits(content) {
+		return &Prompt{Content: content, Data: data}, nil
+	}
+	omitted := omit()
+	if omitted == 0 {
+		return &Prompt{Content: content, Data: data}, nil
+	}
+	content, err = tmpl.Render(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Prompt{Content: content, Data: data, Compaction: fmt.Sprintf("提示词超出 token 预算，省略了 %d 个分段", omitted)}, nil
+}
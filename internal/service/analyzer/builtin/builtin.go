@@ -0,0 +1,21 @@
+// Package builtin 内置的分析器集合
+package builtin
+
+import (
+	"fitgo/internal/service/analyzer"
+	"fitgo/internal/service/analyzer/cycling"
+	"fitgo/internal/service/analyzer/generic"
+	"fitgo/internal/service/analyzer/running"
+	"fitgo/internal/service/analyzer/strength"
+	"fitgo/internal/service/analyzer/swimming"
+)
+
+// Registry 返回注册了所有内置分析器的集合：跑步、骑行、游泳、力量训练，
+// 其它运动类型使用通用分析器
+func Registry() *analyzer.Registry {
+	return analyzer.NewRegistry(generic.Analyzer{}).
+		Register(running.Analyzer{}).
+		Register(cycling.Analyzer{}).
+		Register(swimming.Analyzer{}).
+		Register(strength.Analyzer{})
+}
@@ -0,0 +1,86 @@
+// Package cycling 骑行大类(公路骑行、室内骑行)的分析器
+package cycling
+
+import (
+	"fitgo/internal/service/ai/prompt"
+	"fitgo/internal/service/analyzer"
+	"fitgo/pkg/sport"
+	"fitgo/pkg/units"
+)
+
+// 高驰骑行详情中骑行特有的字段：maxSpeed 为公里/小时，np 为标准化功率(W)，
+// avgCadence/maxCadence 为踏频(转/分钟)；分段没有 avgSpeed 时按距离和用时计算
+
+// Analyzer 骑行分析器：功率、踏频和速度
+type Analyzer struct{}
+
+var _ analyzer.Analyzer = Analyzer{}
+
+// Name 实现 analyzer.Analyzer 接口
+func (Analyzer) Name() string {
+	return string(sport.FamilyCycling)
+}
+
+// Sports 实现 analyzer.Analyzer 接口
+func (Analyzer) Sports() []sport.Sport {
+	return []sport.Sport{sport.Bike, sport.IndoorBike}
+}
+
+// Render 实现 analyzer.Analyzer 接口，超出 token 预算时省略分段
+func (Analyzer) Render(tmpl *prompt.Template, in *analyzer.Input, fits func(string) bool) (*analyzer.Prompt, error) {
+	input := &prompt.CyclingSummary{
+		Sport:        in.Detail.Sport,
+		SportName:    in.Detail.Sport.Name(),
+		Summary:      analyzer.FormatSummary(in.Detail.Summary),
+		Cycling:      metrics(in.Detail.Summary),
+		Laps:         laps(in.Detail.LapList),
+		Readiness:    in.Readiness,
+		OutputSchema: in.OutputSchema,
+	}
+	return analyzer.RenderOmittingLaps(tmpl, input, func() int {
+		input.LapsOmitted, input.Laps = len(input.Laps), nil
+		return input.LapsOmitted
+	}, fits)
+}
+
+// metrics 骑行特有的指标，机械功和变异指数由平均功率和标准化功率计算
+func metrics(summary map[string]interface{}) prompt.CyclingMetrics {
+	avgPower, np := number(summary, "avgPower"), number(summary, "np")
+	m := prompt.CyclingMetrics{
+		MaxSpeed:        units.Speed(number(summary, "maxSpeed")),
+		NormalizedPower: units.Power(np),
+		Work:            units.Work(avgPower * number(summary, "workoutTime") / 100 / 1000),
+		AvgCadence:      units.RPM(number(summary, "avgCadence")),
+		MaxCadence:      units.RPM(number(summary, "maxCadence")),
+	}
+	if avgPower > 0 {
+		m.VariabilityIndex = units.Ratio(np / avgPower)
+	}
+	return m
+}
+
+// laps 把高驰分段换算为模板输入
+func laps(list []map[string]interface{}) []prompt.CyclingLap {
+	formatted := make([]prompt.CyclingLap, 0, len(list))
+	for i, lap := range list {
+		distance, duration := number(lap, "distance")/100, number(lap, "time")/100
+		speed := number(lap, "avgSpeed")
+		if speed == 0 && duration > 0 {
+			speed = distance / duration * 3.6
+		}
+		formatted = append(formatted, prompt.CyclingLap{
+			Index:      analyzer.LapIndex(lap, i),
+			Distance:   units.Distance(distance),
+			Time:       units.Duration(duration),
+			Speed:      units.Speed(speed),
+			AvgHR:      units.HeartRate(number(lap, "avgHr")),
+			AvgPower:   units.Power(number(lap, "avgPower")),
+			MaxPower:   units.Power(number(lap, "maxPower")),
+			AvgCadence: units.RPM(number(lap, "avgCadence")),
+			Ascent:     units.Elevation(number(lap, "elevGain")),
+		})
+	}
+	return formatted
+}
+
+var number = analyzer.Number
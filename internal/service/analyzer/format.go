@@ -0,0 +1,156 @@
+package analyzer
+
+import (
+	"fmt"
+	"time"
+
+	"fitgo/internal/service/ai/prompt"
+	"fitgo/internal/service/ai/verify"
+	"fitgo/internal/service/coros"
+	"fitgo/internal/service/prediction"
+	"fitgo/pkg/units"
+)
+
+// 高驰运动详情的原始单位：距离为厘米，时间为 1/100 秒，配速为秒/公里，
+// 热量为卡(÷1000 为千卡)，速度为公里/小时，步幅为厘米，爬升为米
+
+// FormatSummary 把高驰 summary 中各运动共有的字段换算为模板输入，
+// 不适用于某类运动的字段(如骑行的步幅)由对应的模板忽略
+func FormatSummary(summary map[string]interface{}) prompt.ActivitySummary {
+	s := prompt.ActivitySummary{
+		Distance:        units.Distance(Number(summary, "distance") / 100),
+		TotalTime:       units.Duration(Number(summary, "totalTime") / 100),
+		MovingTime:      units.Duration(Number(summary, "workoutTime") / 100),
+		PausedTime:      units.Duration(Number(summary, "pauseTime") / 100),
+		AvgPace:         units.Pace(Number(summary, "avgPace")),
+		AdjustedPace:    units.Pace(Number(summary, "adjustedPace")),
+		BestKmPace:      units.Pace(Number(summary, "bestKm")),
+		AvgSpeed:        units.Speed(Number(summary, "avgSpeed")),
+		AvgHR:           units.HeartRate(Number(summary, "avgHr")),
+		MaxHR:           units.HeartRate(Number(summary, "maxHr")),
+		AvgCadence:      units.Cadence(Number(summary, "avgCadence")),
+		MaxCadence:      units.Cadence(Number(summary, "maxCadence")),
+		AvgStride:       units.StrideLength(Number(summary, "avgStepLen")),
+		AvgPower:        units.Power(Number(summary, "avgPower")),
+		MaxPower:        units.Power(Number(summary, "maxPower")),
+		Ascent:          units.Elevation(Number(summary, "elevGain")),
+		Descent:         units.Elevation(Number(summary, "elevLoss")),
+		Calories:        units.Calories(Number(summary, "calories") / 1000),
+		AerobicEffect:   units.Decimal(Number(summary, "aerobicEffect")),
+		AnaerobicEffect: units.Decimal(Number(summary, "anaerobicEffect")),
+	}
+	if name, ok := summary["name"].(string); ok {
+		s.Name = name
+	}
+	if ts := Number(summary, "startTimestamp"); ts > 0 {
+		s.StartTime = time.Unix(int64(ts/100), 0).Format("2006-01-02 15:04")
+	}
+	if load := Number(summary, "trainingLoad"); load > 0 {
+		s.TrainingLoad = fmt.Sprintf("%.0f", load)
+	}
+	return s
+}
+
+// summaryFacts 汇总数值作为核对报告的真实值，与提示词中的文本相同
+func summaryFacts(s prompt.ActivitySummary) []verify.Fact {
+	return []verify.Fact{
+		{Metric: verify.Distance, Value: s.Distance},
+		{Metric: verify.TotalTime, Value: s.TotalTime},
+		{Metric: verify.MovingTime, Value: s.MovingTime},
+		{Metric: verify.PausedTime, Value: s.PausedTime},
+		{Metric: verify.AvgPace, Value: s.AvgPace},
+		{Metric: verify.AdjustedPace, Value: s.AdjustedPace},
+		{Metric: verify.BestKmPace, Value: s.BestKmPace},
+		{Metric: verify.AvgHR, Value: s.AvgHR},
+		{Metric: verify.MaxHR, Value: s.MaxHR},
+		{Metric: verify.AvgPower, Value: s.AvgPower},
+		{Metric: verify.MaxPower, Value: s.MaxPower},
+	}
+}
+
+// LapIndex 分段序号，高驰没有给出时按顺序编号
+func LapIndex(lap map[string]interface{}, i int) int {
+	if index := int(Number(lap, "lapIndex")); index > 0 {
+		return index
+	}
+	return i + 1
+}
+
+// formatReadiness 把当日恢复数据换算为模板输入
+func formatReadiness(d *coros.DailyMetrics) *prompt.Readiness {
+	r := &prompt.Readiness{
+		RestingHR:   units.HeartRate(float64(d.RestingHR)),
+		HRV:         units.Milliseconds(float64(d.HRV)),
+		HRVBaseline: units.Milliseconds(float64(d.HRVBaseline)),
+		Sleep:       units.Minutes(float64(d.Sleep.TotalMinutes)),
+		DeepSleep:   units.Minutes(float64(d.Sleep.DeepMinutes)),
+		Fatigue:     units.Decimal(d.Fatigue),
+	}
+	if d.LoadRatio > 0 {
+		r.LoadRatio = fmt.Sprintf("%.2f", d.LoadRatio)
+	}
+	return r
+}
+
+// Number 读取 JSON 解析出的数值字段，缺失或类型不符时为 0
+func Number(m map[string]interface{}, key string) float64 {
+	v, _ := m[key].(float64)
+	return v
+}
+
+// raceNames 比赛距离的中文名称
+var raceNames = map[string]string{
+	"5k":            "5 公里",
+	"10k":           "10 公里",
+	"half_marathon": "半程马拉松",
+	"marathon":      "全程马拉松",
+}
+
+// paceNames 训练配速的中文名称
+var paceNames = map[string]string{
+	"easy":       "轻松跑",
+	"marathon":   "马拉松配速",
+	"threshold":  "乳酸阈值跑",
+	"interval":   "间歇跑",
+	"repetition": "重复跑",
+}
+
+// formatFitness 把比赛成绩预测换算为模板输入
+func formatFitness(p *prediction.Prediction) *prompt.Fitness {
+	f := &prompt.Fitness{
+		From:          p.From,
+		To:            p.To,
+		VDOT:          fmt.Sprintf("%.1f", p.VDOT),
+		CriticalSpeed: units.PaceFromSpeed(p.CriticalSpeed),
+	}
+	if p.CriticalSpeed > 0 {
+		f.DPrime = fmt.Sprintf("%.0f m", p.DPrime)
+	}
+	for _, perf := range p.Performances {
+		f.Performances = append(f.Performances, prompt.FitnessPerformance{
+			Distance: units.Distance(perf.Distance),
+			Time:     units.Duration(perf.Duration),
+			Pace:     units.Pace(perf.Duration / (perf.Distance / 1000)),
+			Date:     perf.Date,
+			Race:     perf.Source == prediction.SourceRace,
+		})
+	}
+	for _, r := range p.Predictions {
+		f.Races = append(f.Races, prompt.FitnessRace{
+			Race:     r.Name,
+			Name:     raceNames[r.Name],
+			Distance: units.Distance(r.Distance),
+			Time:     units.Duration(r.Time),
+			Range:    units.Duration(r.Low) + "-" + units.Duration(r.High),
+			Pace:     units.Pace(r.Time / (r.Distance / 1000)),
+		})
+	}
+	for _, pace := range p.Paces {
+		f.Paces = append(f.Paces, prompt.FitnessPace{
+			Zone: pace.Name,
+			Name: paceNames[pace.Name],
+			Pace: units.Duration(pace.Fast) + "-" + units.Pace(pace.Slow),
+		})
+	}
+	return f
+}
@@ -0,0 +1,56 @@
+// Package generic 没有专门分析器的运动类型(徒步、滑雪、划船、铁人三项等)使用的回退分析器
+package generic
+
+import (
+	"fitgo/internal/service/ai/prompt"
+	"fitgo/internal/service/analyzer"
+	"fitgo/pkg/sport"
+	"fitgo/pkg/units"
+)
+
+// Analyzer 通用分析器，只使用各运动共有的指标：距离、时间、心率、爬升和热量
+type Analyzer struct{}
+
+var _ analyzer.Analyzer = Analyzer{}
+
+// Name 实现 analyzer.Analyzer 接口
+func (Analyzer) Name() string {
+	return "generic"
+}
+
+// Sports 实现 analyzer.Analyzer 接口。通用分析器作为回退使用，不注册具体的运动类型
+func (Analyzer) Sports() []sport.Sport {
+	return nil
+}
+
+// Render 实现 analyzer.Analyzer 接口，超出 token 预算时省略分段
+func (Analyzer) Render(tmpl *prompt.Template, in *analyzer.Input, fits func(string) bool) (*analyzer.Prompt, error) {
+	input := &prompt.GenericSummary{
+		Sport:        in.Detail.Sport,
+		SportName:    in.Detail.Sport.Name(),
+		Summary:      analyzer.FormatSummary(in.Detail.Summary),
+		Laps:         laps(in.Detail.LapList),
+		Readiness:    in.Readiness,
+		OutputSchema: in.OutputSchema,
+	}
+	return analyzer.RenderOmittingLaps(tmpl, input, func() int {
+		input.LapsOmitted, input.Laps = len(input.Laps), nil
+		return input.LapsOmitted
+	}, fits)
+}
+
+// laps 把高驰分段换算为模板输入，只保留各运动共有的列
+func laps(list []map[string]interface{}) []prompt.Lap {
+	formatted := make([]prompt.Lap, 0, len(list))
+	for i, lap := range list {
+		formatted = append(formatted, prompt.Lap{
+			Index:    analyzer.LapIndex(lap, i),
+			Distance: units.Distance(analyzer.Number(lap, "distance") / 100),
+			Time:     units.Duration(analyzer.Number(lap, "time") / 100),
+			Pace:     units.Pace(analyzer.Number(lap, "avgPace")),
+			AvgHR:    units.HeartRate(analyzer.Number(lap, "avgHr")),
+			Ascent:   units.Elevation(analyzer.Number(lap, "elevGain")),
+		})
+	}
+	return formatted
+}
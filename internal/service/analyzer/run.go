@@ -0,0 +1,388 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/ai/client"
+	"fitgo/internal/service/ai/prompt"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/ai/structured"
+	"fitgo/internal/service/ai/tools"
+	"fitgo/internal/service/ai/verify"
+	"fitgo/internal/service/coros"
+	"fitgo/internal/service/prediction"
+	"fitgo/internal/service/report"
+	"fitgo/internal/service/usage"
+	"fitgo/pkg/sport"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Options 分析选项
+type Options struct {
+	Refresh  bool   // 忽略缓存重新生成
+	Language string // 报告语言，选择对应语言的提示词模板，为空时使用配置的默认语言
+
+	// Activities 不为 nil 时允许模型调用工具查询训练历史(最近活动、训练负荷、最好成绩、分段)，
+	// 只用于非流式的 Markdown 报告
+	Activities activity.ActivityService
+}
+
+// analysis 一次分析的输入
+type analysis struct {
+	ai            *aiservice.AIService
+	messages      []client.ChatMessage
+	accountID     string
+	labelID       string
+	inputHash     string
+	promptVersion string        // 提示词模板的版本ID
+	format        string        // 报告格式 markdown 或 json
+	compaction    string        // 提示词超出 token 预算时的压缩说明
+	facts         []verify.Fact // 提示词中的汇总数值，用于核对报告
+
+	tools         *tools.Registry // 允许调用的工具，为 nil 时不使用工具
+	maxIterations int             // 工具调用的最多轮数
+}
+
+// Analyze 分析指定账号下的运动数据并返回AI分析报告，按运动类型选择分析器。
+// 提示词版本和模型都未变化时直接返回缓存的报告，不请求高驰接口；opts.Refresh 为 true 时强制重新生成。
+// 报告中的关键数值与运动数据不符时重新生成一次，核对结果保存在报告中。
+// 用量记在 ctx 中的用户名下，ctx 取消(如客户端断开)时中止模型调用
+func (r *Registry) Analyze(ctx context.Context, corosService coros.CorosService, reports report.ReportService, accountID, labelID string, sp sport.Sport, opts Options) (*report.Report, error) {
+	if cached, ok := r.cached(reports, labelID, sp, prompt.TaskSummary, opts); ok {
+		return cached, nil
+	}
+	a, err := r.prepare(ctx, corosService, accountID, labelID, sp, prompt.TaskSummary, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = userContext(ctx, accountID)
+	var response *aiservice.Response
+	var calls []tools.Call
+	if a.tools != nil {
+		result, err := tools.Run(ctx, a.ai, aiservice.TaskSummary, a.messages, a.tools, a.maxIterations)
+		if err != nil {
+			return nil, fmt.Errorf("AI分析失败: %w", err)
+		}
+		response, calls = result.Response, result.Calls
+	} else {
+		response, err = a.ai.Complete(ctx, aiservice.TaskSummary, a.messages)
+		if err != nil {
+			return nil, fmt.Errorf("AI分析失败: %w", err)
+		}
+	}
+
+	response, verification := a.verifyMarkdown(ctx, response)
+	return a.save(reports, response, verification, calls...)
+}
+
+// AnalyzeStream 与 Analyze 相同，但以流式方式返回分析结果，每段增量文本调用一次 onDelta。
+// 命中缓存时整份报告作为一段增量返回。ctx 取消(如浏览器断开)时中止模型调用。
+// 报告已经发送给客户端，数值不符时只记录核对结果，不重新生成
+func (r *Registry) AnalyzeStream(ctx context.Context, corosService coros.CorosService, reports report.ReportService, accountID, labelID string, sp sport.Sport, opts Options, onDelta client.StreamHandler) (*report.Report, error) {
+	opts.Activities = nil // 流式报告不使用工具
+	if cached, ok := r.cached(reports, labelID, sp, prompt.TaskSummary, opts); ok {
+		return cached, onDelta(cached.Content)
+	}
+	a, err := r.prepare(ctx, corosService, accountID, labelID, sp, prompt.TaskSummary, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := a.ai.CompleteStream(userContext(ctx, accountID), aiservice.TaskSummary, a.messages, onDelta)
+	if err != nil {
+		return nil, fmt.Errorf("AI分析失败: %w", err)
+	}
+
+	return a.save(reports, response, verify.Compare(verify.Markdown(response.Content), a.facts))
+}
+
+// AnalyzeStructured 与 Analyze 相同，但要求模型按 structured.Analysis 的 Schema 输出 JSON，
+// 校验失败时修复或重试。报告内容为规范化后的 JSON，指标表中的关键数值不符时重新生成一次
+func (r *Registry) AnalyzeStructured(ctx context.Context, corosService coros.CorosService, reports report.ReportService, accountID, labelID string, sp sport.Sport, opts Options) (*report.Report, *structured.Analysis, error) {
+	opts.Activities = nil // 结构化报告不使用工具
+	if cached, ok := r.cached(reports, labelID, sp, prompt.TaskStructured, opts); ok {
+		if result, err := structured.Parse(cached.Content); err == nil {
+			return cached, result, nil
+		}
+	}
+	a, err := r.prepare(ctx, corosService, accountID, labelID, sp, prompt.TaskStructured, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx = userContext(ctx, accountID)
+	result, response, err := structured.Generate(ctx, a.ai, aiservice.TaskSummary, a.messages)
+	if err != nil {
+		return nil, nil, fmt.Errorf("AI分析失败: %w", err)
+	}
+
+	verification := verify.Compare(metricClaims(result), a.facts)
+	if verification.Critical() {
+		retried, retriedResponse, err := structured.Generate(ctx, a.ai, aiservice.TaskSummary, a.correction(response.Content, verification))
+		if err != nil {
+			log.Printf("重新生成AI报告失败: %v", err)
+		} else if v, ok := better(verification, verify.Compare(metricClaims(retried), a.facts)); ok {
+			result, response, verification = retried, retriedResponse, v
+		}
+	}
+
+	saved, err := a.save(reports, response, verification)
+	return saved, result, err
+}
+
+// verifyMarkdown 核对 Markdown 报告中的数值，关键数值不符时把不符的数值反馈给模型重新生成一次。
+// 重新生成失败或没有减少关键数值的错误时返回原来的报告和核对结果
+func (a *analysis) verifyMarkdown(ctx context.Context, response *aiservice.Response) (*aiservice.Response, *verify.Result) {
+	verification := verify.Compare(verify.Markdown(response.Content), a.facts)
+	if !verification.Critical() {
+		return response, verification
+	}
+	retried, err := a.ai.Complete(ctx, aiservice.TaskSummary, a.correction(response.Content, verification))
+	if err != nil {
+		log.Printf("重新生成AI报告失败: %v", err)
+		return response, verification
+	}
+	if v, ok := better(verification, verify.Compare(verify.Markdown(retried.Content), a.facts)); ok {
+		return retried, v
+	}
+	return response, verification
+}
+
+// better 判断重新生成的报告是否比原来的报告关键数值错误更少，是时返回标记为已重新生成的核对结果
+func better(original, retried *verify.Result) (*verify.Result, bool) {
+	if retried.CriticalCount() >= original.CriticalCount() {
+		log.Printf("重新生成的AI报告仍有 %d 个关键数值不符，保留原来的报告", retried.CriticalCount())
+		return original, false
+	}
+	retried.Regenerated = true
+	return retried, true
+}
+
+// correction 在原对话后追加模型的报告和数值不符的说明
+func (a *analysis) correction(content string, verification *verify.Result) []client.ChatMessage {
+	return append(append([]client.ChatMessage(nil), a.messages...),
+		client.ChatMessage{Role: "assistant", Content: content},
+		client.ChatMessage{Role: "user", Content: verification.Feedback()},
+	)
+}
+
+// metricClaims 结构化分析指标表中的数值
+func metricClaims(analysis *structured.Analysis) []verify.Claim {
+	claims := make([]verify.Claim, 0, len(analysis.Metrics))
+	for _, m := range analysis.Metrics {
+		claims = append(claims, verify.Claim{Label: m.Name, Value: m.Value})
+	}
+	return claims
+}
+
+// cached 在请求高驰数据之前查找同一活动、相同提示词版本且由当前配置的模型生成的报告。
+// 提示词版本按请求的运动类型确定，命中缓存时不请求高驰接口，高驰不可用时仍可返回已生成的报告
+func (r *Registry) cached(reports report.ReportService, labelID string, sp sport.Sport, task string, opts Options) (*report.Report, bool) {
+	if opts.Refresh || r.ai == nil {
+		return nil, false
+	}
+	if _, err := r.Lookup(sp); err != nil {
+		return nil, false
+	}
+	p, err := r.selectPrompts(sp, task, opts)
+	if err != nil {
+		return nil, false
+	}
+	found, err := reports.Find(activityID(labelID), "", p.version, r.ai.Models())
+	if err != nil {
+		return nil, false
+	}
+	found.Cached = true
+	return found, true
+}
+
+// save 保存新生成的报告、数值核对结果及生成过程中的工具调用，保存失败不影响本次返回
+func (a *analysis) save(reports report.ReportService, response *aiservice.Response, verification *verify.Result, calls ...tools.Call) (*report.Report, error) {
+	r := &report.Report{
+		ActivityID:    activityID(a.labelID),
+		AccountID:     a.accountID,
+		InputHash:     a.inputHash,
+		PromptVersion: a.promptVersion,
+		Provider:      response.Provider,
+		Model:         response.Model,
+		Content:       response.Content,
+		Format:        a.format,
+		Compaction:    a.compaction,
+		ToolCalls:     calls,
+		Verification:  verification,
+	}
+	saved, err := reports.Save(r)
+	if err != nil {
+		log.Printf("保存AI报告失败: %v", err)
+		return r, nil
+	}
+	return saved, nil
+}
+
+// activityID 报告使用与本地活动相同的ID
+func activityID(labelID string) string {
+	return activity.SourceCoros + "-" + labelID
+}
+
+// prepare 按运动类型选择分析器并渲染 task 对应的提示词模板
+func (r *Registry) prepare(ctx context.Context, corosService coros.CorosService, accountID, labelID string, sp sport.Sport, task string, opts Options) (*analysis, error) {
+	if _, err := r.Lookup(sp); err != nil {
+		return nil, err
+	}
+
+	// 1. 没有可用的 AI 服务时不请求高驰数据
+	if r.ai == nil {
+		return nil, aiservice.ErrNoProvider
+	}
+
+	// 2. 获取运动概要，以详情中的运动类型为准选择分析器
+	sportsSummary, err := corosService.SportsSummary(ctx, accountID, labelID, sp)
+	if err != nil {
+		return nil, fmt.Errorf("获取运动概要失败: %v", err)
+	}
+	analyzer, err := r.Lookup(sportsSummary.Sport)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. 当日恢复数据(可选)，用于把身体状态与表现联系起来
+	in := &Input{Detail: sportsSummary}
+	if day := activityDate(sportsSummary.Summary); day != "" {
+		if days, err := corosService.DailyMetrics(ctx, accountID, day, day); err == nil && len(days) > 0 {
+			in.Readiness = formatReadiness(days[0])
+		}
+	}
+
+	// 跑步附带截至运动当天的比赛成绩预测，供模型给出配速建议
+	if r.predictions != nil && sportsSummary.Sport.Family() == sport.FamilyRunning {
+		var until time.Time
+		if t := activityTime(sportsSummary.Summary); !t.IsZero() {
+			until = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		}
+		if p, err := r.predictions.Predict(prediction.Options{AccountID: accountID, Until: until}); err == nil {
+			in.Fitness = formatFitness(p)
+		}
+	}
+
+	// 4. 按运动类型和语言选择模板
+	format := report.FormatMarkdown
+	if task == prompt.TaskStructured {
+		in.OutputSchema = structured.Schema()
+		format = report.FormatJSON
+	}
+	p, err := r.selectPrompts(sportsSummary.Sport, task, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5. 按首选提供者估算 token，超出预算时由分析器压缩分段数据(如 400 米自动分段的马拉松或超马)
+	estimator, budget := r.ai.InputBudget(aiservice.TaskSummary)
+	rendered, err := analyzer.Render(p.tmpl, in, func(content string) bool {
+		messages := []client.ChatMessage{{Role: "user", Content: content}}
+		if p.instructions != "" {
+			messages = append(messages, client.ChatMessage{Role: "system", Content: p.instructions})
+		}
+		return estimator.Messages(messages) <= budget
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 6. 输入数据哈希，随报告保存，用于追溯生成报告时的运动数据和恢复数据
+	data, err := json.Marshal(rendered.Data)
+	if err != nil {
+		return nil, fmt.Errorf("序列化运动数据失败: %v", err)
+	}
+	hash := sha256.Sum256(data)
+
+	a := &analysis{
+		ai: r.ai,
+		messages: []client.ChatMessage{
+			{
+				Role:    "user",
+				Content: rendered.Content,
+			},
+		},
+		accountID:     accountID,
+		labelID:       labelID,
+		inputHash:     hex.EncodeToString(hash[:]),
+		promptVersion: p.version,
+		format:        format,
+		compaction:    rendered.Compaction,
+		facts:         summaryFacts(FormatSummary(sportsSummary.Summary)),
+		maxIterations: p.maxIterations,
+	}
+	if opts.Activities != nil {
+		a.messages = append([]client.ChatMessage{{Role: "system", Content: p.instructions}}, a.messages...)
+		a.tools = tools.NewAthleteTools(opts.Activities, corosService, accountID, activityTime(sportsSummary.Summary))
+	}
+	return a, nil
+}
+
+// promptSet task 对应的提示词模板
+type promptSet struct {
+	tmpl          *prompt.Template
+	instructions  string // 工具说明，不使用工具时为空
+	version       string // 提示词版本ID，使用工具时同时包含两个模板
+	maxIterations int    // 工具调用的最多轮数
+}
+
+// selectPrompts 按运动类型和语言选择模板，不请求高驰接口。
+// 允许调用工具时追加工具说明，提示词版本同时包含两个模板，与不使用工具的报告分开缓存
+func (r *Registry) selectPrompts(sp sport.Sport, task string, opts Options) (*promptSet, error) {
+	tmpl, err := r.prompts.Lookup(sp, task, opts.Language)
+	if err != nil {
+		return nil, err
+	}
+	p := &promptSet{tmpl: tmpl, version: tmpl.ID}
+	if opts.Activities == nil {
+		return p, nil
+	}
+
+	p.maxIterations = r.maxToolIterations
+	if p.maxIterations <= 0 {
+		p.maxIterations = tools.DefaultMaxIterations
+	}
+	toolsTmpl, err := r.prompts.Lookup(sp, prompt.TaskTools, opts.Language)
+	if err != nil {
+		return nil, err
+	}
+	if p.instructions, err = toolsTmpl.Render(prompt.Tools{MaxIterations: p.maxIterations}); err != nil {
+		return nil, err
+	}
+	p.version += "+" + toolsTmpl.ID
+	return p, nil
+}
+
+// userContext 用量和每用户限额记在高驰账号名下。请求头中的用户由调用方提供，
+// 不能用来绕过账号的限额
+func userContext(ctx context.Context, accountID string) context.Context {
+	if accountID == "" {
+		return ctx
+	}
+	return usage.WithUser(ctx, accountID)
+}
+
+// activityDate 从 summary 的 startTimestamp(单位 1/100 秒)得到运动日期 YYYY-MM-DD
+func activityDate(summary map[string]interface{}) string {
+	t := activityTime(summary)
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(coros.DateLayout)
+}
+
+// activityTime 运动开始时间，没有 startTimestamp 时为零值
+func activityTime(summary map[string]interface{}) time.Time {
+	ts, ok := summary["startTimestamp"].(float64)
+	if !ok || ts <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(ts/100), 0)
+}
@@ -0,0 +1,159 @@
+package running
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"fitgo/internal/service/ai/prompt"
+	"fitgo/pkg/units"
+)
+
+// 提示词超出 token 预算时依次尝试的压缩方式：先省略次要的列，再按越来越大的距离合并分段，
+// 最后按阶段合并。只保留比上一步分段更少的方式
+var (
+	bucketSizes = []float64{1000, 2000, 5000} // 合并距离(米)
+	phaseCounts = []int{12, 6}
+)
+
+// compaction 一种压缩方式及压缩后的高驰分段
+type compaction struct {
+	laps []map[string]interface{}
+	info *prompt.LapCompaction
+}
+
+// renderWithinBudget 渲染提示词，超出预算(fits 返回 false)时压缩分段后重新渲染。
+// 所有方式都超出预算时使用压缩最多的结果。返回的说明用于报告，没有压缩时为空
+func renderWithinBudget(tmpl *prompt.Template, input *prompt.RunningSummary, laps []map[string]interface{}, fits func(string) bool) (string, string, error) {
+	input.Laps = formatLaps(laps)
+	input.LapCompaction = nil
+	content, err := tmpl.Render(*input)
+	if err != nil || fits(content) {
+		return content, "", err
+	}
+
+	var last compaction
+	for _, c := range compactions(laps) {
+		last = c
+		input.Laps = formatLaps(c.laps)
+		input.LapCompaction = c.info
+		content, err = tmpl.Render(*input)
+		if err != nil {
+			return "", "", err
+		}
+		if fits(content) {
+			return content, describe(c), nil
+		}
+	}
+	log.Printf("分段压缩为 %d 段后提示词仍超出 token 预算", len(last.laps))
+	return content, describe(last), nil
+}
+
+// compactions 按压缩程度从低到高列出可用的压缩方式
+func compactions(laps []map[string]interface{}) []compaction {
+	original := len(laps)
+	result := []compaction{{laps: laps, info: &prompt.LapCompaction{OriginalLaps: original}}}
+
+	current := original
+	for _, size := range bucketSizes {
+		merged := mergeLaps(laps, size)
+		if len(merged) < current {
+			result = append(result, compaction{laps: merged, info: &prompt.LapCompaction{OriginalLaps: original, Bucket: units.Distance(size)}})
+			current = len(merged)
+		}
+	}
+
+	total := 0.0
+	for _, lap := range laps {
+		total += number(lap, "distance") / 100
+	}
+	for _, phases := range phaseCounts {
+		if phases >= current || total <= 0 {
+			continue
+		}
+		merged := mergeLaps(laps, total/float64(phases))
+		result = append(result, compaction{laps: merged, info: &prompt.LapCompaction{OriginalLaps: original, Phases: len(merged)}})
+		current = len(merged)
+	}
+	return result
+}
+
+// describe 压缩说明，随报告保存
+func describe(c compaction) string {
+	switch {
+	case c.info.Bucket != "":
+		return fmt.Sprintf("提示词超出 token 预算，%d 个分段按每 %s 合并为 %d 段，并省略了次要的列", c.info.OriginalLaps, c.info.Bucket, len(c.laps))
+	case c.info.Phases > 0:
+		return fmt.Sprintf("提示词超出 token 预算，%d 个分段按距离合并为 %d 个阶段，并省略了次要的列", c.info.OriginalLaps, c.info.Phases)
+	default:
+		return "提示词超出 token 预算，分段表省略了坡度调整配速、最大心率、功率和下降"
+	}
+}
+
+// mergeLaps 按 size 米的距离区间合并相邻的高驰分段，分段归入其中点所在的区间
+func mergeLaps(laps []map[string]interface{}, size float64) []map[string]interface{} {
+	var merged []map[string]interface{}
+	var group []map[string]interface{}
+	current, start := -1, 0.0
+	for _, lap := range laps {
+		distance := number(lap, "distance") / 100
+		bucket := int((start + distance/2) / size)
+		if bucket != current && len(group) > 0 {
+			merged = append(merged, mergeGroup(group, len(merged)+1))
+			group = nil
+		}
+		current = bucket
+		start += distance
+		group = append(group, lap)
+	}
+	if len(group) > 0 {
+		merged = append(merged, mergeGroup(group, len(merged)+1))
+	}
+	return merged
+}
+
+// mergeGroup 把一组分段合并为一个，单位与高驰分段相同。配速由总时间和总距离重新计算，
+// 心率、步频、功率按时间加权，坡度调整配速按距离加权，爬升和下降累加
+func mergeGroup(group []map[string]interface{}, index int) map[string]interface{} {
+	var distance, duration, ascent, descent, maxHR float64
+	var adjusted, adjustedDistance float64
+	weighted := map[string]float64{}
+	weights := map[string]float64{}
+	for _, lap := range group {
+		d, t := number(lap, "distance"), number(lap, "time")
+		distance += d
+		duration += t
+		ascent += number(lap, "elevGain")
+		descent += number(lap, "elevLoss")
+		maxHR = math.Max(maxHR, number(lap, "maxHr"))
+		if v := number(lap, "adjustedPace"); v > 0 {
+			adjusted += v * d
+			adjustedDistance += d
+		}
+		for _, key := range []string{"avgHr", "avgCadence", "avgPower"} {
+			if v := number(lap, key); v > 0 && t > 0 {
+				weighted[key] += v * t
+				weights[key] += t
+			}
+		}
+	}
+
+	m := map[string]interface{}{
+		"lapIndex": float64(index),
+		"distance": distance,
+		"time":     duration,
+		"elevGain": ascent,
+		"elevLoss": descent,
+		"maxHr":    maxHR,
+	}
+	if distance > 0 {
+		m["avgPace"] = duration * 1000 / distance // (time/100 秒) / (distance/100000 公里)
+	}
+	if adjustedDistance > 0 {
+		m["adjustedPace"] = adjusted / adjustedDistance
+	}
+	for key, sum := range weighted {
+		m[key] = sum / weights[key]
+	}
+	return m
+}
@@ -0,0 +1,31 @@
+package running
+
+import (
+	"fitgo/internal/service/ai/prompt"
+	"fitgo/internal/service/analyzer"
+	"fitgo/pkg/units"
+)
+
+// formatLaps 把高驰每公里分段换算为模板输入
+func formatLaps(laps []map[string]interface{}) []prompt.Lap {
+	formatted := make([]prompt.Lap, 0, len(laps))
+	for i, lap := range laps {
+		formatted = append(formatted, prompt.Lap{
+			Index:        analyzer.LapIndex(lap, i),
+			Distance:     units.Distance(number(lap, "distance") / 100),
+			Time:         units.Duration(number(lap, "time") / 100),
+			Pace:         units.Pace(number(lap, "avgPace")),
+			AdjustedPace: units.Pace(number(lap, "adjustedPace")),
+			AvgHR:        units.HeartRate(number(lap, "avgHr")),
+			MaxHR:        units.HeartRate(number(lap, "maxHr")),
+			AvgCadence:   units.Cadence(number(lap, "avgCadence")),
+			AvgPower:     units.Power(number(lap, "avgPower")),
+			Ascent:       units.Elevation(number(lap, "elevGain")),
+			Descent:      units.Elevation(number(lap, "elevLoss")),
+		})
+	}
+	return formatted
+}
+
+// number 读取高驰分段的数值字段
+var number = analyzer.Number
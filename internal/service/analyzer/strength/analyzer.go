@@ -0,0 +1,81 @@
+// Package strength 力量训练和有氧运动的分析器
+package strength
+
+import (
+	"fitgo/internal/service/ai/prompt"
+	"fitgo/internal/service/analyzer"
+	"fitgo/pkg/sport"
+	"fitgo/pkg/units"
+)
+
+// 高驰力量训练的分段为每一组：name 为动作名称，reps 为次数，weight 为负重(千克)，
+// time 为该组用时(1/100 秒)
+
+// Analyzer 力量训练分析器：组数、次数、负荷量和心率
+type Analyzer struct{}
+
+var _ analyzer.Analyzer = Analyzer{}
+
+// Name 实现 analyzer.Analyzer 接口
+func (Analyzer) Name() string {
+	return string(sport.FamilyStrength)
+}
+
+// Sports 实现 analyzer.Analyzer 接口
+func (Analyzer) Sports() []sport.Sport {
+	return []sport.Sport{sport.Strength, sport.Cardio}
+}
+
+// Render 实现 analyzer.Analyzer 接口，超出 token 预算时省略每组明细，只保留汇总
+func (Analyzer) Render(tmpl *prompt.Template, in *analyzer.Input, fits func(string) bool) (*analyzer.Prompt, error) {
+	input := &prompt.StrengthSummary{
+		Sport:        in.Detail.Sport,
+		SportName:    in.Detail.Sport.Name(),
+		Summary:      analyzer.FormatSummary(in.Detail.Summary),
+		Strength:     metrics(in.Detail.LapList),
+		Sets:         sets(in.Detail.LapList),
+		Readiness:    in.Readiness,
+		OutputSchema: in.OutputSchema,
+	}
+	return analyzer.RenderOmittingLaps(tmpl, input, func() int {
+		input.LapsOmitted, input.Sets = len(input.Sets), nil
+		return input.LapsOmitted
+	}, fits)
+}
+
+// metrics 组数、总次数和总负荷量
+func metrics(list []map[string]interface{}) prompt.StrengthMetrics {
+	var reps, volume float64
+	for _, set := range list {
+		reps += number(set, "reps")
+		volume += number(set, "reps") * number(set, "weight")
+	}
+	m := prompt.StrengthMetrics{
+		Sets:      units.Count(float64(len(list))),
+		TotalReps: units.Count(reps),
+	}
+	if volume > 0 {
+		m.Volume = units.Weight(volume)
+	}
+	return m
+}
+
+// sets 把高驰分段换算为每组明细
+func sets(list []map[string]interface{}) []prompt.StrengthSet {
+	formatted := make([]prompt.StrengthSet, 0, len(list))
+	for i, set := range list {
+		name, _ := set["name"].(string)
+		formatted = append(formatted, prompt.StrengthSet{
+			Index:    analyzer.LapIndex(set, i),
+			Exercise: name,
+			Reps:     units.Count(number(set, "reps")),
+			Weight:   units.Weight(number(set, "weight")),
+			Time:     units.Duration(number(set, "time") / 100),
+			AvgHR:    units.HeartRate(number(set, "avgHr")),
+			MaxHR:    units.HeartRate(number(set, "maxHr")),
+		})
+	}
+	return formatted
+}
+
+var number = analyzer.Number
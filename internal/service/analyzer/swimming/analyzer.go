@@ -0,0 +1,103 @@
+// Package swimming 游泳大类(泳池、公开水域)的分析器
+package swimming
+
+import (
+	"fitgo/internal/service/ai/prompt"
+	"fitgo/internal/service/analyzer"
+	"fitgo/pkg/sport"
+	"fitgo/pkg/units"
+)
+
+// 高驰游泳详情中游泳特有的字段：poolLength 为泳池长度(厘米)，avgSwolf、avgStrokeRate(次/分钟)、
+// totalStrokes；分段为 swolf、strokeRate、strokes。游泳的 avgPace 不是秒/公里，
+// 每百米配速统一按距离和用时计算
+
+// Analyzer 游泳分析器：SWOLF、划频和每百米配速
+type Analyzer struct{}
+
+var _ analyzer.Analyzer = Analyzer{}
+
+// Name 实现 analyzer.Analyzer 接口
+func (Analyzer) Name() string {
+	return string(sport.FamilySwimming)
+}
+
+// Sports 实现 analyzer.Analyzer 接口
+func (Analyzer) Sports() []sport.Sport {
+	return []sport.Sport{sport.PoolSwim, sport.OpenWaterSwim}
+}
+
+// Render 实现 analyzer.Analyzer 接口，超出 token 预算时省略分段
+func (Analyzer) Render(tmpl *prompt.Template, in *analyzer.Input, fits func(string) bool) (*analyzer.Prompt, error) {
+	summary := analyzer.FormatSummary(in.Detail.Summary)
+	summary.AvgPace, summary.AvgCadence, summary.MaxCadence = "", "", "" // 游泳使用每百米配速和划频
+	input := &prompt.SwimmingSummary{
+		Sport:        in.Detail.Sport,
+		SportName:    in.Detail.Sport.Name(),
+		Summary:      summary,
+		Swimming:     metrics(in.Detail.Summary, in.Detail.LapList),
+		Laps:         laps(in.Detail.LapList),
+		Readiness:    in.Readiness,
+		OutputSchema: in.OutputSchema,
+	}
+	return analyzer.RenderOmittingLaps(tmpl, input, func() int {
+		input.LapsOmitted, input.Laps = len(input.Laps), nil
+		return input.LapsOmitted
+	}, fits)
+}
+
+// metrics 游泳特有的指标
+func metrics(summary map[string]interface{}, list []map[string]interface{}) prompt.SwimmingMetrics {
+	distance := number(summary, "distance") / 100
+	strokes := number(summary, "totalStrokes")
+	m := prompt.SwimmingMetrics{
+		Pace:          units.SwimPace(pacePer100m(distance, number(summary, "workoutTime")/100)),
+		AvgSwolf:      units.Count(number(summary, "avgSwolf")),
+		AvgStrokeRate: units.StrokeRate(number(summary, "avgStrokeRate")),
+		TotalStrokes:  units.Count(strokes),
+	}
+	if length := number(summary, "poolLength"); length > 0 {
+		m.PoolLength = units.Distance(length / 100)
+	}
+	if strokes > 0 {
+		m.StrokeLength = units.StrokeLength(distance / strokes)
+	}
+
+	best := 0.0
+	for _, lap := range list {
+		if pace := pacePer100m(number(lap, "distance")/100, number(lap, "time")/100); pace > 0 && (best == 0 || pace < best) {
+			best = pace
+		}
+	}
+	m.BestPace = units.SwimPace(best)
+	return m
+}
+
+// laps 把高驰分段换算为模板输入
+func laps(list []map[string]interface{}) []prompt.SwimLap {
+	formatted := make([]prompt.SwimLap, 0, len(list))
+	for i, lap := range list {
+		distance, duration := number(lap, "distance")/100, number(lap, "time")/100
+		formatted = append(formatted, prompt.SwimLap{
+			Index:      analyzer.LapIndex(lap, i),
+			Distance:   units.Distance(distance),
+			Time:       units.Duration(duration),
+			Pace:       units.SwimPace(pacePer100m(distance, duration)),
+			Swolf:      units.Count(number(lap, "swolf")),
+			StrokeRate: units.StrokeRate(number(lap, "strokeRate")),
+			Strokes:    units.Count(number(lap, "strokes")),
+			AvgHR:      units.HeartRate(number(lap, "avgHr")),
+		})
+	}
+	return formatted
+}
+
+// pacePer100m 每百米用时(秒)，距离为 0 时返回 0
+func pacePer100m(meters, seconds float64) float64 {
+	if meters <= 0 {
+		return 0
+	}
+	return seconds / meters * 100
+}
+
+var number = analyzer.Number
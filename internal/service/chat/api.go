@@ -0,0 +1,53 @@
+package chat
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSessionNotFound 会话不存在
+var ErrSessionNotFound = errors.New("对话不存在")
+
+// ErrEmptyMessage 消息为空
+var ErrEmptyMessage = errors.New("消息不能为空")
+
+// ChatService 定义了围绕单个活动的多轮问答接口。
+// 会话创建时以活动数据和最近一份 AI 报告作为上下文，后续提问复用保存的历史
+type ChatService interface {
+	// Create 为活动创建会话
+	Create(activityID string) (*Session, error)
+
+	// Send 发送一条提问并返回模型的回答，问答都追加到会话历史中
+	Send(ctx context.Context, sessionID, message string) (*Message, error)
+
+	// Get 获取会话及完整历史
+	Get(id string) (*Session, error)
+
+	// List 列出活动的所有会话(不含消息)，按更新时间倒序
+	List(activityID string) ([]*Session, error)
+
+	// Delete 删除会话
+	Delete(id string) error
+}
+
+// Session 一次对话
+type Session struct {
+	ID         string    `json:"id"`
+	ActivityID string    `json:"activity_id"`
+	Title      string    `json:"title"`   // 第一个问题
+	Context    string    `json:"context"` // 创建时生成的活动上下文，作为系统提示
+	Summary    string    `json:"summary,omitempty"`
+	Summarized int       `json:"summarized,omitempty"` // 已并入 Summary 的历史消息条数
+	Messages   []Message `json:"messages,omitempty"`
+	CreatedAt  string    `json:"created_at"`
+	UpdatedAt  string    `json:"updated_at"`
+}
+
+// Message 一条消息
+type Message struct {
+	Role      string `json:"role"` // user 或 assistant
+	Content   string `json:"content"`
+	Provider  string `json:"provider,omitempty"`
+	Model     string `json:"model,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
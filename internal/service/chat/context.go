@@ -0,0 +1,57 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/ai/structured"
+	"fitgo/internal/service/report"
+	"fitgo/pkg/units"
+)
+
+// buildContext 生成会话的系统提示：活动汇总、分段和最近一份 AI 报告，数值都已格式化
+func buildContext(a *activity.Activity, latest *report.Report) string {
+	var b strings.Builder
+	b.WriteString("你是一个专业的运动数据分析助手，正在和运动员讨论下面这次运动。请基于这些数据回答问题，数值原样引用，不要重新换算；数据中没有的信息请直接说明，不要猜测。\n\n")
+
+	fmt.Fprintf(&b, "运动类型：%s\n", a.Sport.Name())
+	fmt.Fprintf(&b, "开始时间：%s\n", a.StartTime)
+	fmt.Fprintf(&b, "距离：%s\n", units.Distance(a.Distance))
+	fmt.Fprintf(&b, "总时间：%s\n", units.Duration(a.Duration))
+	fmt.Fprintf(&b, "运动时间：%s\n", units.Duration(a.MovingTime))
+	if a.Distance > 0 && a.MovingTime > 0 {
+		fmt.Fprintf(&b, "平均配速：%s\n", units.Pace(a.MovingTime/(a.Distance/1000)))
+	}
+	if hr := units.HeartRate(float64(a.AvgHR)); hr != "" {
+		fmt.Fprintf(&b, "平均心率：%s\n", hr)
+	}
+	if hr := units.HeartRate(float64(a.MaxHR)); hr != "" {
+		fmt.Fprintf(&b, "最大心率：%s\n", hr)
+	}
+	fmt.Fprintf(&b, "累计爬升：%s\n", units.Elevation(a.Ascent))
+	if kcal := units.Calories(float64(a.Calories)); kcal != "" {
+		fmt.Fprintf(&b, "热量：%s\n", kcal)
+	}
+
+	if len(a.Laps) > 0 {
+		b.WriteString("\n分段：\n| 圈 | 距离 | 用时 | 配速 | 平均心率 | 最大心率 | 步频 | 功率 |\n|---|---|---|---|---|---|---|---|\n")
+		for i, lap := range a.Laps {
+			fmt.Fprintf(&b, "| %d | %s | %s | %s | %s | %s | %s | %s |\n", i+1,
+				units.Distance(lap.Distance), units.Duration(lap.Duration), units.PaceFromSpeed(lap.AvgSpeed),
+				units.HeartRate(float64(lap.AvgHR)), units.HeartRate(float64(lap.MaxHR)),
+				units.Cadence(float64(lap.AvgCadence)), units.Power(float64(lap.AvgPower)))
+		}
+	}
+
+	if latest != nil {
+		content := latest.Content
+		if latest.Format == report.FormatJSON {
+			if a, err := structured.Parse(content); err == nil {
+				content = a.Markdown("zh")
+			}
+		}
+		fmt.Fprintf(&b, "\n之前生成的分析报告：\n%s\n", content)
+	}
+	return b.String()
+}
@@ -0,0 +1,294 @@
+package chat
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/ai/client"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/report"
+	"fitgo/pkg/config"
+)
+
+// 历史长度的默认限制
+const (
+	defaultMaxHistoryChars = 12000
+	defaultKeepRecent      = 6
+)
+
+// chatService 是ChatService接口的具体实现，会话保存为 chats/<会话ID>.json。
+// 同一会话的提问串行处理，不同会话互不阻塞
+type chatService struct {
+	dir        string
+	activities activity.ActivityService
+	reports    report.ReportService
+	ai         *aiservice.AIService
+
+	maxHistoryChars int
+	keepRecent      int
+
+	mu    sync.Mutex             // 保护 locks 和文件读写
+	locks map[string]*sync.Mutex // 会话ID -> 会话锁
+}
+
+// NewChatService 创建对话服务，数据保存在 dataDir/chats 下。ai 为 nil 时只能管理会话，提问返回 ErrNoProvider
+func NewChatService(dataDir string, cfg config.ChatConfig, activities activity.ActivityService, reports report.ReportService, ai *aiservice.AIService) ChatService {
+	s := &chatService{
+		dir:             filepath.Join(dataDir, "chats"),
+		activities:      activities,
+		reports:         reports,
+		ai:              ai,
+		maxHistoryChars: cfg.MaxHistoryChars,
+		keepRecent:      cfg.KeepRecent,
+		locks:           make(map[string]*sync.Mutex),
+	}
+	if s.maxHistoryChars <= 0 {
+		s.maxHistoryChars = defaultMaxHistoryChars
+	}
+	if s.keepRecent <= 0 {
+		s.keepRecent = defaultKeepRecent
+	}
+	return s
+}
+
+func (s *chatService) Create(activityID string) (*Session, error) {
+	a, err := s.activities.Get(activityID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 最近一份报告是可选的上下文，读取失败不影响创建会话
+	var latest *report.Report
+	if reports, err := s.reports.List(activityID); err == nil && len(reports) > 0 {
+		latest = reports[0]
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	session := &Session{
+		ID:         newSessionID(),
+		ActivityID: a.ID,
+		Context:    buildContext(a, latest),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.save(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *chatService) Send(ctx context.Context, sessionID, message string) (*Message, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil, ErrEmptyMessage
+	}
+
+	if s.ai == nil {
+		return nil, aiservice.ErrNoProvider
+	}
+
+	lock := s.sessionLock(sessionID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	session, err := s.Get(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.compact(ctx, session, len([]rune(message)))
+
+	question := Message{Role: "user", Content: message, CreatedAt: time.Now().Format(time.RFC3339)}
+	resp, err := s.ai.Complete(ctx, aiservice.TaskChat, s.conversation(session, question))
+	if err != nil {
+		return nil, err
+	}
+
+	answer := Message{
+		Role:      "assistant",
+		Content:   resp.Content,
+		Provider:  resp.Provider,
+		Model:     resp.Model,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if session.Title == "" {
+		session.Title = message
+	}
+	session.Messages = append(session.Messages, question, answer)
+	session.UpdatedAt = answer.CreatedAt
+	if err := s.save(session); err != nil {
+		return nil, err
+	}
+	return &answer, nil
+}
+
+func (s *chatService) Get(id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(s.path(id))
+}
+
+func (s *chatService) List(activityID string) ([]*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("列出对话失败: %v", err)
+	}
+
+	sessions := make([]*Session, 0)
+	for _, file := range files {
+		session, err := s.read(file)
+		if err != nil {
+			return nil, err
+		}
+		if session.ActivityID != activityID {
+			continue
+		}
+		session.Context = ""
+		session.Messages = nil
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].UpdatedAt > sessions[j].UpdatedAt })
+	return sessions, nil
+}
+
+func (s *chatService) Delete(id string) error {
+	lock := s.sessionLock(id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path(id)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: %s", ErrSessionNotFound, id)
+		}
+		return fmt.Errorf("删除对话失败: %v", err)
+	}
+	delete(s.locks, id)
+	return nil
+}
+
+// conversation 组装发送给模型的消息：系统提示(活动上下文和早期对话摘要) + 未压缩的历史 + 本次提问
+func (s *chatService) conversation(session *Session, question Message) []client.ChatMessage {
+	system := session.Context
+	if session.Summary != "" {
+		system += "\n之前对话的摘要：\n" + session.Summary + "\n"
+	}
+
+	messages := []client.ChatMessage{{Role: "system", Content: system}}
+	for _, m := range session.Messages[session.Summarized:] {
+		messages = append(messages, client.ChatMessage{Role: m.Role, Content: m.Content})
+	}
+	return append(messages, client.ChatMessage{Role: question.Role, Content: question.Content})
+}
+
+// compact 未压缩的历史加上本次提问超过字符上限时，把较早的消息交给模型归纳进 Summary，
+// 只保留最近 keepRecent 条原文。归纳失败时直接丢弃较早的消息，保证请求不会无限增长
+func (s *chatService) compact(ctx context.Context, session *Session, incoming int) {
+	recent := session.Messages[session.Summarized:]
+	size := incoming
+	for _, m := range recent {
+		size += len([]rune(m.Content))
+	}
+	if size <= s.maxHistoryChars || len(recent) <= s.keepRecent {
+		return
+	}
+
+	older := recent[:len(recent)-s.keepRecent]
+	var b strings.Builder
+	if session.Summary != "" {
+		fmt.Fprintf(&b, "已有摘要：\n%s\n\n", session.Summary)
+	}
+	b.WriteString("新的对话：\n")
+	for _, m := range older {
+		role := "运动员"
+		if m.Role == "assistant" {
+			role = "助手"
+		}
+		fmt.Fprintf(&b, "%s：%s\n", role, m.Content)
+	}
+
+	resp, err := s.ai.Complete(ctx, aiservice.TaskChat, []client.ChatMessage{
+		{Role: "system", Content: "请把下面关于一次运动的问答归纳为简短的摘要，保留运动员关心的问题、已经给出的结论和具体数值，不超过 300 字。只输出摘要。"},
+		{Role: "user", Content: b.String()},
+	})
+	if err != nil {
+		log.Printf("对话 %s 历史摘要失败，改为截断: %v", session.ID, err)
+		if session.Summary == "" {
+			session.Summary = "(更早的对话已省略)"
+		}
+	} else {
+		session.Summary = strings.TrimSpace(resp.Content)
+	}
+	session.Summarized += len(older)
+}
+
+func (s *chatService) sessionLock(id string) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lock, ok := s.locks[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.locks[id] = lock
+	}
+	return lock
+}
+
+func (s *chatService) save(session *Session) error {
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化对话失败: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("创建数据目录失败: %v", err)
+	}
+	if err := os.WriteFile(s.path(session.ID), data, 0o644); err != nil {
+		return fmt.Errorf("写入对话失败: %v", err)
+	}
+	return nil
+}
+
+func (s *chatService) read(file string) (*Session, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, strings.TrimSuffix(filepath.Base(file), ".json"))
+		}
+		return nil, fmt.Errorf("读取对话失败: %v", err)
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("解析对话失败: %v", err)
+	}
+	return &session, nil
+}
+
+func (s *chatService) path(id string) string {
+	return filepath.Join(s.dir, filepath.Base(id)+".json")
+}
+
+func newSessionID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,172 @@
+package coros
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultAccountID 配置文件中的账号注册时使用的ID
+const DefaultAccountID = "default"
+
+// ErrAccountNotFound 账号不存在
+var ErrAccountNotFound = errors.New("账号不存在")
+
+// ErrAccountExists 账号ID或高驰账号已注册
+var ErrAccountExists = errors.New("账号已存在")
+
+// ErrInvalidAccount 账号信息不完整
+var ErrInvalidAccount = errors.New("username 和 password 不能为空")
+
+// AccountStore 高驰账号的本地存储，数据保存在一个 JSON 文件中
+type AccountStore struct {
+	path     string
+	mu       sync.RWMutex
+	accounts map[string]*Account
+}
+
+// NewAccountStore 从数据目录加载账号存储，文件不存在时返回空存储
+func NewAccountStore(dataDir string) (*AccountStore, error) {
+	store := &AccountStore{
+		path:     filepath.Join(dataDir, "coros_accounts.json"),
+		accounts: make(map[string]*Account),
+	}
+
+	data, err := os.ReadFile(store.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取账号文件失败: %v", err)
+	}
+
+	var accounts []*Account
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, fmt.Errorf("解析账号文件失败: %v", err)
+	}
+	for _, account := range accounts {
+		store.accounts[account.ID] = account
+	}
+
+	return store, nil
+}
+
+// Add 注册新账号，ID 为空时自动生成
+func (s *AccountStore) Add(account *Account) (*Account, error) {
+	if account.Username == 0 || account.Password == "" {
+		return nil, ErrInvalidAccount
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.accounts {
+		if existing.Username == account.Username {
+			return nil, fmt.Errorf("%w: 账号 %d 已注册为 %s", ErrAccountExists, account.Username, existing.ID)
+		}
+	}
+
+	saved := *account
+	if saved.ID == "" {
+		saved.ID = newAccountID()
+	}
+	if _, ok := s.accounts[saved.ID]; ok {
+		return nil, fmt.Errorf("%w: 账号ID %s", ErrAccountExists, saved.ID)
+	}
+	saved.CreatedAt = time.Now().Format(time.RFC3339)
+
+	s.accounts[saved.ID] = &saved
+	if err := s.save(); err != nil {
+		delete(s.accounts, saved.ID)
+		return nil, err
+	}
+
+	return &saved, nil
+}
+
+// Get 根据ID获取账号
+func (s *AccountStore) Get(id string) (*Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	account, ok := s.accounts[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, id)
+	}
+	copied := *account
+	return &copied, nil
+}
+
+// List 按创建时间列出所有账号
+func (s *AccountStore) List() []*Account {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	accounts := make([]*Account, 0, len(s.accounts))
+	for _, account := range s.accounts {
+		copied := *account
+		accounts = append(accounts, &copied)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].CreatedAt == accounts[j].CreatedAt {
+			return accounts[i].ID < accounts[j].ID
+		}
+		return accounts[i].CreatedAt < accounts[j].CreatedAt
+	})
+	return accounts
+}
+
+// Remove 删除账号
+func (s *AccountStore) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, id)
+	}
+
+	delete(s.accounts, id)
+	if err := s.save(); err != nil {
+		s.accounts[id] = account
+		return err
+	}
+	return nil
+}
+
+// save 将账号写回文件，调用方需持有写锁
+func (s *AccountStore) save() error {
+	accounts := make([]*Account, 0, len(s.accounts))
+	for _, account := range s.accounts {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID < accounts[j].ID })
+
+	data, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化账号失败: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("创建数据目录失败: %v", err)
+	}
+	// 账号文件包含密码，仅允许当前用户读写
+	if err := os.WriteFile(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("写入账号文件失败: %v", err)
+	}
+	return nil
+}
+
+func newAccountID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -1,19 +1,58 @@
 package coros
 
+import (
+	"context"
+
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/workout"
+	"fitgo/pkg/sport"
+)
+
 // CorosSummary 表示高驰运动数据摘要
 type CorosSummary struct {
-	ID        string  `json:"id"`
-	Date      string  `json:"date"`
-	Duration  int     `json:"duration"`
-	Distance  float64 `json:"distance"`
-	Calories  float64 `json:"calories"`
-	SportType string  `json:"sport_type"`
+	ID       string      `json:"id"`
+	Date     string      `json:"date"`
+	Duration int         `json:"duration"`
+	Distance float64     `json:"distance"`
+	Calories float64     `json:"calories"`
+	Sport    sport.Sport `json:"sport"`
+}
+
+// Account 表示一个注册到 fitgo 的高驰账号
+type Account struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Username  int    `json:"username"`
+	Password  string `json:"password"` // 与配置文件一致，为高驰登录使用的 MD5 密码
+	CreatedAt string `json:"created_at"`
 }
 
-// 定义获取高驰API数据的服务接口
+// 定义获取高驰API数据的服务接口，所有数据接口均按账号ID区分，请求随 ctx 取消
 type CorosService interface {
-	Login() (string, error)
-	ListCorosSummaries() ([]*CorosSummary, error)
-	SportsSummary(labelId, sportType string) (*SportsSummaryResult, error)
-	ActivityList(size, pageNumber, modeList int) (map[string]interface{}, error)
+	AddAccount(account *Account) (*Account, error)
+	ListAccounts() ([]*Account, error)
+	RemoveAccount(accountID string) error
+
+	Login(ctx context.Context, accountID string) (string, error)
+	ListCorosSummaries(ctx context.Context, accountID string) ([]*CorosSummary, error)
+	SportsSummary(ctx context.Context, accountID, labelId string, sp sport.Sport) (*SportsSummaryResult, error)
+	// ActivityList 返回高驰的活动列表原始数据，dataList 中每项附加统一的 sport 字段
+	ActivityList(ctx context.Context, accountID string, size, pageNumber, modeList int) (map[string]interface{}, error)
+
+	// Dashboard 获取当前的恢复状态(静息心率、HRV、疲劳度、训练负荷)
+	Dashboard(ctx context.Context, accountID string) (*Dashboard, error)
+	// DailyMetrics 同步 [from, to] 区间(YYYY-MM-DD)的每日数据到本地并返回，
+	// 高驰请求失败时返回本地已同步的数据
+	DailyMetrics(ctx context.Context, accountID, from, to string) ([]*DailyMetrics, error)
+
+	// UploadWorkout 将训练课上传到账号的高驰训练计划，返回高驰的课程ID。
+	// 新增课程不是幂等操作，失败时不重试，避免在高驰账号中重复创建
+	UploadWorkout(ctx context.Context, accountID string, w *workout.Workout) (string, error)
+
+	// DownloadActivityFile 下载活动的原始文件，format 为 fit 或 tcx
+	DownloadActivityFile(ctx context.Context, accountID, labelID string, sp sport.Sport, format string) ([]byte, error)
+	// ImportActivity 下载活动的 FIT(失败时 TCX)文件并经由本地导入流程保存，已导入时直接返回
+	ImportActivity(ctx context.Context, accountID, labelID string, sp sport.Sport) (*activity.Activity, error)
+	// ImportActivities 导入最近 size 条活动，跳过已导入的
+	ImportActivities(ctx context.Context, accountID string, size int) (*ImportResult, error)
 }
@@ -0,0 +1,134 @@
+package coros
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// DateLayout 每日数据使用的日期格式
+const DateLayout = "2006-01-02"
+
+// SleepMetrics 一晚的睡眠时长与分期，单位为分钟
+type SleepMetrics struct {
+	TotalMinutes int `json:"total_minutes"`
+	DeepMinutes  int `json:"deep_minutes"`
+	LightMinutes int `json:"light_minutes"`
+	REMMinutes   int `json:"rem_minutes"`
+	AwakeMinutes int `json:"awake_minutes"`
+}
+
+// DailyMetrics 高驰记录的每日恢复与训练负荷数据
+type DailyMetrics struct {
+	Date           string       `json:"date"`            // YYYY-MM-DD
+	RestingHR      int          `json:"resting_hr"`      // 静息心率
+	HRV            int          `json:"hrv"`             // 夜间平均 HRV(ms)
+	HRVBaseline    int          `json:"hrv_baseline"`    // HRV 基线(ms)
+	Sleep          SleepMetrics `json:"sleep"`           // 睡眠
+	TrainingLoad   int          `json:"training_load"`   // 当日训练负荷
+	Fatigue        float64      `json:"fatigue"`         // 疲劳度(高驰 tiredRateNew)
+	ATI            int          `json:"ati"`             // 急性训练负荷
+	CTI            int          `json:"cti"`             // 慢性训练负荷
+	LoadRatio      float64      `json:"load_ratio"`      // 负荷比 ATI/CTI
+	RecoveryPct    int          `json:"recovery_pct"`    // 恢复百分比
+	TrainingStatus int          `json:"training_status"` // 高驰训练状态代码
+	SyncedAt       string       `json:"synced_at"`       // 最近一次同步时间
+}
+
+// Dashboard 高驰仪表盘上的当前恢复状态
+type Dashboard struct {
+	RestingHR      int     `json:"resting_hr"`
+	HRV            int     `json:"hrv"`
+	HRVBaseline    int     `json:"hrv_baseline"`
+	HRVRange       [2]int  `json:"hrv_range"` // HRV 正常区间
+	Fatigue        float64 `json:"fatigue"`
+	ATI            int     `json:"ati"`
+	CTI            int     `json:"cti"`
+	LoadRatio      float64 `json:"load_ratio"`
+	RecoveryPct    int     `json:"recovery_pct"`
+	Stamina        int     `json:"stamina"`
+	TrainingStatus int     `json:"training_status"`
+}
+
+// DailyStore 每日数据的本地存储，每个账号一个 JSON 文件，按日期去重
+type DailyStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewDailyStore 创建每日数据存储
+func NewDailyStore(dataDir string) *DailyStore {
+	return &DailyStore{dir: filepath.Join(dataDir, "daily")}
+}
+
+// Upsert 写入或覆盖指定日期的数据
+func (s *DailyStore) Upsert(accountID string, metrics []*DailyMetrics) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	days, err := s.load(accountID)
+	if err != nil {
+		return err
+	}
+	for _, m := range metrics {
+		days[m.Date] = m
+	}
+	return s.save(accountID, days)
+}
+
+// Range 返回 [from, to] 区间内按日期排序的数据
+func (s *DailyStore) Range(accountID, from, to string) ([]*DailyMetrics, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	days, err := s.load(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*DailyMetrics, 0)
+	for date, m := range days {
+		if date >= from && date <= to {
+			result = append(result, m)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result, nil
+}
+
+func (s *DailyStore) path(accountID string) string {
+	return filepath.Join(s.dir, filepath.Base(accountID)+".json")
+}
+
+func (s *DailyStore) load(accountID string) (map[string]*DailyMetrics, error) {
+	days := make(map[string]*DailyMetrics)
+	data, err := os.ReadFile(s.path(accountID))
+	if errors.Is(err, os.ErrNotExist) {
+		return days, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取每日数据失败: %v", err)
+	}
+	if err := json.Unmarshal(data, &days); err != nil {
+		return nil, fmt.Errorf("解析每日数据失败: %v", err)
+	}
+	return days, nil
+}
+
+func (s *DailyStore) save(accountID string, days map[string]*DailyMetrics) error {
+	data, err := json.MarshalIndent(days, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化每日数据失败: %v", err)
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("创建数据目录失败: %v", err)
+	}
+	if err := os.WriteFile(s.path(accountID), data, 0o644); err != nil {
+		return fmt.Errorf("写入每日数据失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package coros
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"fitgo/internal/service/activity"
+	"fitgo/pkg/sport"
+)
+
+// /activity/detail/download 的 fileType 取值
+var corosFileTypes = map[string]int{
+	activity.FormatTCX: 3,
+	activity.FormatFIT: 4,
+}
+
+// ImportResult 批量导入的结果
+type ImportResult struct {
+	Imported []*activity.Activity `json:"imported"`
+	Skipped  []string             `json:"skipped"` // 之前已导入的 labelId
+	Failed   map[string]string    `json:"failed"`  // labelId -> 错误信息
+}
+
+func (s *corosService) DownloadActivityFile(ctx context.Context, accountID, labelID string, sp sport.Sport, format string) ([]byte, error) {
+	fileType, ok := corosFileTypes[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", activity.ErrUnsupportedFormat, format)
+	}
+
+	urlStr := fmt.Sprintf("%s/activity/detail/download?labelId=%s&sportType=%d&fileType=%d",
+		s.config.Address, url.QueryEscape(labelID), sp.Coros(), fileType)
+	var data struct {
+		FileURL string `json:"fileUrl"`
+	}
+	if err := s.call(ctx, accountID, "POST", urlStr, nil, &data); err != nil {
+		return nil, err
+	}
+	if data.FileURL == "" {
+		return nil, fmt.Errorf("高驰未返回 %s 文件地址", format)
+	}
+
+	// 文件地址是带签名的临时链接，不需要 accesstoken
+	req, err := http.NewRequestWithContext(ctx, "GET", data.FileURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载文件失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载文件失败: HTTP %d", resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	return content, nil
+}
+
+func (s *corosService) ImportActivity(ctx context.Context, accountID, labelID string, sp sport.Sport) (*activity.Activity, error) {
+	source := activity.Source{Name: activity.SourceCoros, ID: labelID, AccountID: accountID, Sport: sp}
+
+	// 优先 FIT，下载或解析失败时回退到 TCX
+	var lastErr error
+	for _, format := range []string{activity.FormatFIT, activity.FormatTCX} {
+		content, err := s.DownloadActivityFile(ctx, accountID, labelID, sp, format)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		a, err := s.activities.Ingest(labelID+"."+format, content, source)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return a, nil
+	}
+	return nil, fmt.Errorf("导入活动 %s 失败: %w", labelID, lastErr)
+}
+
+func (s *corosService) ImportActivities(ctx context.Context, accountID string, size int) (*ImportResult, error) {
+	list, err := s.ActivityList(ctx, accountID, size, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Imported: []*activity.Activity{}, Skipped: []string{}, Failed: map[string]string{}}
+	data, _ := list["data"].(map[string]interface{})
+	items, _ := data["dataList"].([]interface{})
+	for _, item := range items {
+		a, _ := item.(map[string]interface{})
+		labelID, _ := a["labelId"].(string)
+		code, _ := a["sportType"].(float64)
+		if labelID == "" {
+			continue
+		}
+
+		if s.activities.Exists(activity.Source{Name: activity.SourceCoros, ID: labelID}) {
+			result.Skipped = append(result.Skipped, labelID)
+			continue
+		}
+		imported, err := s.ImportActivity(ctx, accountID, labelID, sport.FromCoros(int(code)))
+		if err != nil {
+			result.Failed[labelID] = err.Error()
+			continue
+		}
+		result.Imported = append(result.Imported, imported)
+	}
+	return result, nil
+}
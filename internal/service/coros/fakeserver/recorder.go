@@ -0,0 +1,200 @@
+package fakeserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// redactedKeys 录制时需要脱敏的字段，不区分大小写
+var redactedKeys = map[string]bool{
+	"accesstoken": true,
+	"token":       true,
+	"pwd":         true,
+	"password":    true,
+	"userid":      true,
+	"account":     true,
+	"email":       true,
+	"mobile":      true,
+	"phone":       true,
+	"nickname":    true,
+	"headpic":     true,
+}
+
+// Recorder 将请求代理到真实的高驰接口，并把成功的响应脱敏后写入夹具目录。
+// 客户端收到的仍是原始响应，因此录制期间可以正常登录与翻页
+type Recorder struct {
+	upstream string
+	dir      string
+	client   *http.Client
+}
+
+// NewRecorder 创建录制代理
+func NewRecorder(upstream, dir string) *Recorder {
+	return &Recorder{
+		upstream: strings.TrimRight(upstream, "/"),
+		dir:      dir,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (rec *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, rec.upstream+r.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, key := range []string{"accesstoken", "Content-Type", "User-Agent"} {
+		if value := r.Header.Get(key); value != "" {
+			req.Header.Set(key, value)
+		}
+	}
+
+	resp, err := rec.client.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if name := fixtureName(r); name != "" && resp.StatusCode == http.StatusOK {
+		if err := rec.save(name, respBody); err != nil {
+			log.Printf("fakeserver: 录制 %s 失败: %v", name, err)
+		} else {
+			log.Printf("fakeserver: 已录制 %s", name)
+		}
+	}
+
+	if r.URL.Path == "/activity/detail/download" && resp.StatusCode == http.StatusOK {
+		if name, err := rec.saveFile(r, respBody); err != nil {
+			log.Printf("fakeserver: 录制原始文件失败: %v", err)
+		} else {
+			log.Printf("fakeserver: 已录制 %s", name)
+		}
+	}
+
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+	w.Write(respBody)
+}
+
+// save 只录制 result 为 0000 的响应
+func (rec *Recorder) save(name string, body []byte) error {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return fmt.Errorf("响应不是 JSON: %v", err)
+	}
+	if payload["result"] != "0000" {
+		return fmt.Errorf("接口返回 %v: %v", payload["result"], payload["message"])
+	}
+
+	data, err := json.MarshalIndent(Redact(payload), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(rec.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0o644)
+}
+
+// saveFile 下载响应中 fileUrl 指向的原始活动文件并保存到 files/ 下
+func (rec *Recorder) saveFile(r *http.Request, body []byte) (string, error) {
+	labelID := r.URL.Query().Get("labelId")
+	ext, ok := fileExtensions[r.URL.Query().Get("fileType")]
+	if labelID == "" || path.Base(labelID) != labelID || !ok {
+		return "", fmt.Errorf("无效的下载参数")
+	}
+
+	var payload struct {
+		Result string `json:"result"`
+		Data   struct {
+			FileURL string `json:"fileUrl"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Result != "0000" || payload.Data.FileURL == "" {
+		return "", fmt.Errorf("响应中没有文件地址")
+	}
+
+	resp, err := rec.client.Get(payload.Data.FileURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("下载文件返回 HTTP %d", resp.StatusCode)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	name := path.Join(filesFixtureFS, labelID+ext)
+	target := filepath.Join(rec.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", err
+	}
+	return name, os.WriteFile(target, content, 0o644)
+}
+
+// fixtureName 返回请求对应的夹具文件，不需要录制的接口返回空
+func fixtureName(r *http.Request) string {
+	switch r.URL.Path {
+	case "/account/login":
+		return loginFixture
+	case "/activity/query":
+		return queryFixture
+	case "/dashboard/query":
+		return dashboardFixture
+	case "/analyse/dayDetail/query":
+		return dailyFixture
+	case "/activity/detail/query":
+		labelID := r.URL.Query().Get("labelId")
+		if labelID == "" || path.Base(labelID) != labelID {
+			return ""
+		}
+		return path.Join(detailFixtureFS, labelID+".json")
+	}
+	return ""
+}
+
+// Redact 递归替换凭据与个人信息字段
+func Redact(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if redactedKeys[strings.ToLower(key)] {
+				value[key] = "REDACTED"
+				continue
+			}
+			value[key] = Redact(child)
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = Redact(child)
+		}
+	}
+	return v
+}
@@ -0,0 +1,298 @@
+// Package fakeserver 提供一个离线的高驰 API 模拟服务，从录制的 JSON 夹具回放
+// 登录、活动列表、活动详情、原始文件下载、仪表盘和每日数据接口，并接收上传的训练课，也可以以录制模式代理真实接口并保存脱敏后的响应。
+package fakeserver
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+)
+
+//go:embed fixtures
+var embedded embed.FS
+
+// FakeToken 回放模式登录返回的 token
+const FakeToken = "fake-coros-token"
+
+// 夹具文件布局，录制与回放共用
+const (
+	loginFixture     = "login.json"
+	queryFixture     = "activity_query.json"
+	detailFixtureFS  = "activity_detail"
+	dashboardFixture = "dashboard.json"
+	dailyFixture     = "analyse_day_detail.json"
+	filesFixtureFS   = "files" // 原始活动文件 files/<labelId>.fit|.tcx
+)
+
+// fileExtensions /activity/detail/download 的 fileType 对应的文件扩展名
+var fileExtensions = map[string]string{
+	"3": ".tcx",
+	"4": ".fit",
+}
+
+// DefaultFixtures 返回内置的夹具
+func DefaultFixtures() fs.FS {
+	fixtures, _ := fs.Sub(embedded, "fixtures")
+	return fixtures
+}
+
+// Server 回放夹具的模拟服务
+type Server struct {
+	fixtures fs.FS
+	mux      *http.ServeMux
+
+	mu       sync.Mutex
+	programs []map[string]interface{}
+}
+
+// New 创建回放服务，fixtures 为 nil 时使用内置夹具
+func New(fixtures fs.FS) *Server {
+	if fixtures == nil {
+		fixtures = DefaultFixtures()
+	}
+
+	s := &Server{fixtures: fixtures, mux: http.NewServeMux()}
+	s.mux.HandleFunc("POST /account/login", s.login)
+	s.mux.HandleFunc("GET /activity/query", s.requireToken(s.activityQuery))
+	s.mux.HandleFunc("POST /activity/detail/query", s.requireToken(s.activityDetail))
+	s.mux.HandleFunc("GET /dashboard/query", s.requireToken(s.dashboard))
+	s.mux.HandleFunc("GET /analyse/dayDetail/query", s.requireToken(s.dayDetail))
+	s.mux.HandleFunc("POST /activity/detail/download", s.requireToken(s.activityDownload))
+	s.mux.HandleFunc("GET /files/{name}", s.file)
+	s.mux.HandleFunc("POST /training/program/add", s.requireToken(s.programAdd))
+	return s
+}
+
+// NewFromDir 从目录加载夹具，目录中缺少的文件回退到内置夹具
+func NewFromDir(dir string) *Server {
+	return New(overlayFS{primary: os.DirFS(dir), fallback: DefaultFixtures()})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	var resp map[string]interface{}
+	if err := s.readFixture(loginFixture, &resp); err != nil {
+		writeFailure(w, "5000", err.Error())
+		return
+	}
+	// 夹具中的 token 已脱敏，这里替换成固定的假 token
+	if data, ok := resp["data"].(map[string]interface{}); ok {
+		data["accessToken"] = FakeToken
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) activityQuery(w http.ResponseWriter, r *http.Request) {
+	var resp map[string]interface{}
+	if err := s.readFixture(queryFixture, &resp); err != nil {
+		writeFailure(w, "5000", err.Error())
+		return
+	}
+
+	// 按 size/pageNumber 对夹具中的列表分页
+	data, _ := resp["data"].(map[string]interface{})
+	list, _ := data["dataList"].([]interface{})
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+	page, _ := strconv.Atoi(r.URL.Query().Get("pageNumber"))
+	if size <= 0 {
+		size = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	start := (page - 1) * size
+	if start > len(list) {
+		start = len(list)
+	}
+	end := start + size
+	if end > len(list) {
+		end = len(list)
+	}
+
+	if data != nil {
+		data["dataList"] = list[start:end]
+		data["pageNumber"] = page
+		data["totalCount"] = len(list)
+		data["totalPage"] = (len(list) + size - 1) / size
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) activityDetail(w http.ResponseWriter, r *http.Request) {
+	labelID := r.URL.Query().Get("labelId")
+	if labelID == "" || path.Base(labelID) != labelID {
+		writeFailure(w, "1001", "labelId 无效")
+		return
+	}
+
+	var resp map[string]interface{}
+	err := s.readFixture(path.Join(detailFixtureFS, labelID+".json"), &resp)
+	if errors.Is(err, fs.ErrNotExist) {
+		writeFailure(w, "1019", "activity not found")
+		return
+	}
+	if err != nil {
+		writeFailure(w, "5000", err.Error())
+		return
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) dashboard(w http.ResponseWriter, r *http.Request) {
+	var resp map[string]interface{}
+	if err := s.readFixture(dashboardFixture, &resp); err != nil {
+		writeFailure(w, "5000", err.Error())
+		return
+	}
+	writeJSON(w, resp)
+}
+
+// dayDetail 按 startDay/endDay(YYYYMMDD) 过滤夹具中的每日数据
+func (s *Server) dayDetail(w http.ResponseWriter, r *http.Request) {
+	var resp map[string]interface{}
+	if err := s.readFixture(dailyFixture, &resp); err != nil {
+		writeFailure(w, "5000", err.Error())
+		return
+	}
+
+	startDay, _ := strconv.Atoi(r.URL.Query().Get("startDay"))
+	endDay, _ := strconv.Atoi(r.URL.Query().Get("endDay"))
+	data, _ := resp["data"].(map[string]interface{})
+	days, _ := data["dayList"].([]interface{})
+
+	filtered := make([]interface{}, 0, len(days))
+	for _, item := range days {
+		day, _ := item.(map[string]interface{})["happenDay"].(float64)
+		if (startDay == 0 || int(day) >= startDay) && (endDay == 0 || int(day) <= endDay) {
+			filtered = append(filtered, item)
+		}
+	}
+	if data != nil {
+		data["dayList"] = filtered
+	}
+	writeJSON(w, resp)
+}
+
+// activityDownload 返回夹具中原始文件的下载地址，没有对应格式的文件时返回错误码
+func (s *Server) activityDownload(w http.ResponseWriter, r *http.Request) {
+	labelID := r.URL.Query().Get("labelId")
+	ext, ok := fileExtensions[r.URL.Query().Get("fileType")]
+	if labelID == "" || path.Base(labelID) != labelID || !ok {
+		writeFailure(w, "1001", "invalid parameters")
+		return
+	}
+
+	name := labelID + ext
+	if _, err := fs.Stat(s.fixtures, path.Join(filesFixtureFS, name)); err != nil {
+		writeFailure(w, "1019", "file not found")
+		return
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	writeJSON(w, map[string]interface{}{
+		"result": "0000",
+		"data":   map[string]string{"fileUrl": scheme + "://" + r.Host + "/files/" + name},
+	})
+}
+
+// file 模拟文件下载地址，与真实接口一样不需要 accesstoken
+func (s *Server) file(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	data, err := fs.ReadFile(s.fixtures, path.Join(filesFixtureFS, path.Base(name)))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(data)
+}
+
+// programAdd 保存上传的训练课并返回递增的课程ID
+func (s *Server) programAdd(w http.ResponseWriter, r *http.Request) {
+	var program map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&program); err != nil {
+		writeFailure(w, "1001", "invalid request body")
+		return
+	}
+	if name, _ := program["name"].(string); name == "" {
+		writeFailure(w, "1001", "name is required")
+		return
+	}
+	if exercises, _ := program["exercises"].([]interface{}); len(exercises) == 0 {
+		writeFailure(w, "1001", "exercises is required")
+		return
+	}
+
+	s.mu.Lock()
+	s.programs = append(s.programs, program)
+	id := "fake-program-" + strconv.Itoa(len(s.programs))
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]interface{}{
+		"result": "0000",
+		"data":   map[string]string{"id": id},
+	})
+}
+
+// Programs 返回已上传的训练课请求体，按上传顺序排列
+func (s *Server) Programs() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]interface{}(nil), s.programs...)
+}
+
+// requireToken 模拟高驰的鉴权，缺少 accesstoken 头时返回错误码
+func (s *Server) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("accesstoken") == "" {
+			writeFailure(w, "1030", "access token invalid")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) readFixture(name string, v interface{}) error {
+	data, err := fs.ReadFile(s.fixtures, name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeFailure 高驰接口在业务错误时仍返回 200，通过 result 字段区分
+func writeFailure(w http.ResponseWriter, code, message string) {
+	writeJSON(w, map[string]string{"result": code, "message": message})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// overlayFS 优先读取 primary，不存在时回退到 fallback
+type overlayFS struct {
+	primary  fs.FS
+	fallback fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.primary.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.fallback.Open(name)
+	}
+	return f, err
+}
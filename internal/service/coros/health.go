@@ -0,0 +1,135 @@
+package coros
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// dailyItem /analyse/dayDetail/query 返回的每日数据
+type dailyItem struct {
+	HappenDay         int     `json:"happenDay"` // YYYYMMDD
+	RHR               int     `json:"rhr"`
+	AvgSleepHrv       int     `json:"avgSleepHrv"`
+	SleepHrvBase      int     `json:"sleepHrvBase"`
+	SleepTotalTime    int     `json:"sleepTotalTime"`
+	DeepSleepTime     int     `json:"deepSleepTime"`
+	LightSleepTime    int     `json:"lightSleepTime"`
+	RemSleepTime      int     `json:"remSleepTime"`
+	WakeTime          int     `json:"wakeTime"`
+	TrainingLoad      int     `json:"trainingLoad"`
+	TiredRateNew      float64 `json:"tiredRateNew"`
+	ATI               int     `json:"ati"`
+	CTI               int     `json:"cti"`
+	TrainingLoadRatio float64 `json:"trainingLoadRatio"`
+	RecoveryPct       int     `json:"recoveryPct"`
+	TrainingStatus    int     `json:"trainingStatus"`
+}
+
+// dashboardInfo /dashboard/query 返回的 summaryInfo
+type dashboardInfo struct {
+	RHR                  int     `json:"rhr"`
+	AvgSleepHrv          int     `json:"avgSleepHrv"`
+	SleepHrvBase         int     `json:"sleepHrvBase"`
+	SleepHrvIntervalList []int   `json:"sleepHrvIntervalList"`
+	TiredRateNew         float64 `json:"tiredRateNew"`
+	ATI                  int     `json:"ati"`
+	CTI                  int     `json:"cti"`
+	TrainingLoadRatio    float64 `json:"trainingLoadRatio"`
+	RecoveryPct          int     `json:"recoveryPct"`
+	StaminaLevel         int     `json:"staminaLevel"`
+	TrainingStatus       int     `json:"trainingStatus"`
+}
+
+func (s *corosService) Dashboard(ctx context.Context, accountID string) (*Dashboard, error) {
+	var data struct {
+		SummaryInfo dashboardInfo `json:"summaryInfo"`
+	}
+	if err := s.call(ctx, accountID, "GET", s.config.Address+"/dashboard/query", nil, &data); err != nil {
+		return nil, err
+	}
+
+	info := data.SummaryInfo
+	dashboard := &Dashboard{
+		RestingHR:      info.RHR,
+		HRV:            info.AvgSleepHrv,
+		HRVBaseline:    info.SleepHrvBase,
+		Fatigue:        info.TiredRateNew,
+		ATI:            info.ATI,
+		CTI:            info.CTI,
+		LoadRatio:      info.TrainingLoadRatio,
+		RecoveryPct:    info.RecoveryPct,
+		Stamina:        info.StaminaLevel,
+		TrainingStatus: info.TrainingStatus,
+	}
+	if len(info.SleepHrvIntervalList) == 2 {
+		dashboard.HRVRange = [2]int{info.SleepHrvIntervalList[0], info.SleepHrvIntervalList[1]}
+	}
+	return dashboard, nil
+}
+
+func (s *corosService) DailyMetrics(ctx context.Context, accountID, from, to string) ([]*DailyMetrics, error) {
+	start, err := time.Parse(DateLayout, from)
+	if err != nil {
+		return nil, fmt.Errorf("from 日期格式应为 YYYY-MM-DD: %v", err)
+	}
+	end, err := time.Parse(DateLayout, to)
+	if err != nil {
+		return nil, fmt.Errorf("to 日期格式应为 YYYY-MM-DD: %v", err)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("to 不能早于 from")
+	}
+
+	urlStr := fmt.Sprintf("%s/analyse/dayDetail/query?startDay=%s&endDay=%s",
+		s.config.Address, start.Format("20060102"), end.Format("20060102"))
+
+	var data struct {
+		DayList []dailyItem `json:"dayList"`
+	}
+	if err := s.call(ctx, accountID, "GET", urlStr, nil, &data); err != nil {
+		// 高驰不可用时返回本地已同步的数据，本地也没有时才返回错误
+		if local, localErr := s.daily.Range(accountID, from, to); localErr == nil && len(local) > 0 {
+			log.Printf("coros: 同步 %s 至 %s 的每日数据失败，返回本地数据: %v", from, to, err)
+			return local, nil
+		}
+		return nil, err
+	}
+
+	// 同步到本地，之后按日期从本地返回，包括之前同步过的数据
+	syncedAt := time.Now().Format(time.RFC3339)
+	metrics := make([]*DailyMetrics, 0, len(data.DayList))
+	for _, item := range data.DayList {
+		day, err := time.Parse("20060102", fmt.Sprint(item.HappenDay))
+		if err != nil {
+			continue
+		}
+		metrics = append(metrics, &DailyMetrics{
+			Date:        day.Format(DateLayout),
+			RestingHR:   item.RHR,
+			HRV:         item.AvgSleepHrv,
+			HRVBaseline: item.SleepHrvBase,
+			Sleep: SleepMetrics{
+				TotalMinutes: item.SleepTotalTime,
+				DeepMinutes:  item.DeepSleepTime,
+				LightMinutes: item.LightSleepTime,
+				REMMinutes:   item.RemSleepTime,
+				AwakeMinutes: item.WakeTime,
+			},
+			TrainingLoad:   item.TrainingLoad,
+			Fatigue:        item.TiredRateNew,
+			ATI:            item.ATI,
+			CTI:            item.CTI,
+			LoadRatio:      item.TrainingLoadRatio,
+			RecoveryPct:    item.RecoveryPct,
+			TrainingStatus: item.TrainingStatus,
+			SyncedAt:       syncedAt,
+		})
+	}
+	if err := s.daily.Upsert(accountID, metrics); err != nil {
+		return nil, err
+	}
+
+	return s.daily.Range(accountID, from, to)
+}
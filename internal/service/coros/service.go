@@ -2,8 +2,11 @@ package coros
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fitgo/internal/service/activity"
 	"fitgo/pkg/config"
+	"fitgo/pkg/sport"
 	"fmt"
 	"io"
 	"net/http"
@@ -30,6 +33,7 @@ type Lap struct {
 
 // 用于返回给调用方的数据结构
 type SportsSummaryResult struct {
+	Sport   sport.Sport              `json:"sport"`
 	LapList []map[string]interface{} `json:"lapList"`
 	Summary map[string]interface{}   `json:"summary"`
 }
@@ -40,29 +44,33 @@ type loginform struct {
 	Pwd         string `json:"pwd"`
 }
 
+// cachedToken 单个账号缓存的 token
+type cachedToken struct {
+	token  string    // 缓存的 token
+	expire time.Time // token 过期时间
+}
+
 type corosService struct {
-	token       string     // 缓存的 token
-	tokenMutex  sync.Mutex // 用于保护 token 的并发访问
-	tokenExpire time.Time  // token 过期时间
+	config     *config.CorosConfig
+	client     *httpClient // 所有高驰请求共用的客户端
+	accounts   *AccountStore
+	daily      *DailyStore
+	activities activity.ActivityService
+	tokens     map[string]cachedToken // 按账号ID缓存的 token
+	tokenMutex sync.Mutex             // 用于保护 tokens 的并发访问
 }
 
-func (s *corosService) ActivityList(size, pageNumber, modeList int) (map[string]interface{}, error) {
-	token, loginErr := s.Login()
+func (s *corosService) ActivityList(ctx context.Context, accountID string, size, pageNumber, modeList int) (map[string]interface{}, error) {
+	token, loginErr := s.Login(ctx, accountID)
 	if loginErr != nil {
 		return nil, loginErr
 	}
 
-	// 获取配置文件
-	cfg, err := config.LoadDefaultConfig()
-	if err != nil {
-		return nil, fmt.Errorf("加载配置失败: %v", err)
-	}
-
 	urlStr := fmt.Sprintf("%s/activity/query?size=%d&pageNumber=%d&modeList=",
-		cfg.Coros.Address, size, pageNumber)
+		s.config.Address, size, pageNumber)
 
 	// 3. 创建新的请求
-	req, err := http.NewRequest("GET", urlStr, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
 	if err != nil {
 		return nil, fmt.Errorf("创建请求失败: %v", err)
 	}
@@ -72,10 +80,9 @@ func (s *corosService) ActivityList(size, pageNumber, modeList int) (map[string]
 	req.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 15_0 like Mac OS X) AppleWebKit/605.1.15")
 
 	// 5. 发送请求
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("请求失败: %v", err)
+		return nil, fmt.Errorf("请求失败: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -91,77 +98,141 @@ func (s *corosService) ActivityList(size, pageNumber, modeList int) (map[string]
 		return nil, fmt.Errorf("JSON解析失败: %v", err)
 	}
 
+	// 为每条活动附加统一的运动类型
+	if data, ok := result["data"].(map[string]interface{}); ok {
+		if list, ok := data["dataList"].([]interface{}); ok {
+			for _, item := range list {
+				if entry, ok := item.(map[string]interface{}); ok {
+					code, _ := entry["sportType"].(float64)
+					entry["sport"] = sport.FromCoros(int(code))
+				}
+			}
+		}
+	}
+
 	return result, nil
 }
 
-func (s *corosService) ListCorosSummaries() ([]*CorosSummary, error) {
+func (s *corosService) ListCorosSummaries(ctx context.Context, accountID string) ([]*CorosSummary, error) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func NewCorosService() CorosService {
-	return &corosService{}
+// NewCorosService 创建高驰服务，配置文件中的账号以 DefaultAccountID 提供。
+// 下载的原始活动文件交给 activities 导入
+func NewCorosService(cfg *config.CorosConfig, accounts *AccountStore, daily *DailyStore, activities activity.ActivityService) CorosService {
+	return &corosService{
+		config:     cfg,
+		client:     newHTTPClient(cfg.HTTP),
+		accounts:   accounts,
+		daily:      daily,
+		activities: activities,
+		tokens:     make(map[string]cachedToken),
+	}
+}
+
+// configAccount 返回配置文件中的默认账号，未配置时返回 nil
+func (s *corosService) configAccount() *Account {
+	if s.config.Username == 0 {
+		return nil
+	}
+	return &Account{
+		ID:       DefaultAccountID,
+		Name:     "config",
+		Username: s.config.Username,
+		Password: s.config.Password,
+	}
+}
+
+// account 根据ID查找账号，优先匹配配置文件中的默认账号
+func (s *corosService) account(accountID string) (*Account, error) {
+	if accountID == DefaultAccountID {
+		if account := s.configAccount(); account != nil {
+			return account, nil
+		}
+	}
+	return s.accounts.Get(accountID)
+}
+
+func (s *corosService) AddAccount(account *Account) (*Account, error) {
+	if account.ID == DefaultAccountID && s.configAccount() != nil {
+		return nil, fmt.Errorf("%w: 账号ID %s 已被配置文件占用", ErrAccountExists, DefaultAccountID)
+	}
+	return s.accounts.Add(account)
+}
+
+func (s *corosService) ListAccounts() ([]*Account, error) {
+	accounts := s.accounts.List()
+	if account := s.configAccount(); account != nil {
+		accounts = append([]*Account{account}, accounts...)
+	}
+	return accounts, nil
+}
+
+func (s *corosService) RemoveAccount(accountID string) error {
+	if accountID == DefaultAccountID && s.configAccount() != nil {
+		return fmt.Errorf("配置文件中的账号无法删除")
+	}
+	if err := s.accounts.Remove(accountID); err != nil {
+		return err
+	}
+
+	s.tokenMutex.Lock()
+	delete(s.tokens, accountID)
+	s.tokenMutex.Unlock()
+	return nil
 }
 
-func (s *corosService) Login() (string, error) {
-	// 检查是否有未过期的 token
+func (s *corosService) Login(ctx context.Context, accountID string) (string, error) {
+	// 检查该账号是否有未过期的 token
 	s.tokenMutex.Lock()
-	if s.token != "" && time.Now().Before(s.tokenExpire) {
-		token := s.token
+	if cached, ok := s.tokens[accountID]; ok && time.Now().Before(cached.expire) {
 		s.tokenMutex.Unlock()
-		return token, nil
+		return cached.token, nil
 	}
 	s.tokenMutex.Unlock()
 
-	// 获取配置文件
-	cfg, err := config.LoadDefaultConfig()
+	account, err := s.account(accountID)
 	if err != nil {
-		return "", fmt.Errorf("加载配置失败: %v", err)
+		return "", err
 	}
 
-	username := cfg.Coros.Username
-	password := cfg.Coros.Password
 	loginUrl := fmt.Sprintf("%s/account/login",
-		cfg.Coros.Address)
+		s.config.Address)
 
-	loginForm := loginform{Account: username, AccountType: 2, Pwd: password}
+	loginForm := loginform{Account: account.Username, AccountType: 2, Pwd: account.Password}
 
 	// 将结构体序列化为 JSON
 	jsonData, err := json.Marshal(loginForm)
 	if err != nil {
-		fmt.Printf("JSON序列化失败: %v\n", err)
-
+		return "", fmt.Errorf("JSON序列化失败: %v", err)
 	}
 
 	// 创建 HTTP 请求
-	req, err := http.NewRequest("POST", loginUrl, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", loginUrl, bytes.NewBuffer(jsonData))
 	if err != nil {
-		fmt.Printf("创建请求失败: %v\n", err)
-
+		return "", fmt.Errorf("创建请求失败: %v", err)
 	}
 
 	// 设置 Content-Type 头
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
-		fmt.Printf("请求失败: %v\n", err)
-
+		return "", fmt.Errorf("请求失败: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// 处理响应
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Printf("读取响应失败: %v\n", err)
-
+		return "", fmt.Errorf("读取响应失败: %v", err)
 	}
 
 	// 解析响应JSON
 	var loginResp LoginResponse
 	if err := json.Unmarshal(body, &loginResp); err != nil {
-		fmt.Printf("解析响应失败: %v\n", err)
+		return "", fmt.Errorf("解析响应失败: %v", err)
 	}
 
 	// 检查响应码
@@ -171,36 +242,32 @@ func (s *corosService) Login() (string, error) {
 
 	// 更新缓存，token 有效期为 7 天
 	s.tokenMutex.Lock()
-	s.token = loginResp.Data.AccessToken
-	s.tokenExpire = time.Now().Add(7 * 24 * time.Hour)
+	s.tokens[accountID] = cachedToken{
+		token:  loginResp.Data.AccessToken,
+		expire: time.Now().Add(7 * 24 * time.Hour),
+	}
 	s.tokenMutex.Unlock()
 
 	return loginResp.Data.AccessToken, nil
 }
 
-func (s *corosService) SportsSummary(labelId, sportType string) (*SportsSummaryResult, error) {
+func (s *corosService) SportsSummary(ctx context.Context, accountID, labelId string, sp sport.Sport) (*SportsSummaryResult, error) {
 	// 1. 首先获取access token
-	token, err := s.Login()
+	token, err := s.Login(ctx, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("登录失败: %v", err)
 	}
 
-	// 获取配置文件
-	cfg, cfgErr := config.LoadDefaultConfig()
-	if cfgErr != nil {
-		return nil, fmt.Errorf("加载配置失败: %v", err)
-	}
-
 	// 2. 构建请求URL
 	urlStr := fmt.Sprintf(
-		"%s/activity/detail/query?screenW=781&screenH=1440&labelId=%s&sportType=%s",
-		cfg.Coros.Address,
+		"%s/activity/detail/query?screenW=781&screenH=1440&labelId=%s&sportType=%d",
+		s.config.Address,
 		url.QueryEscape(labelId),
-		url.QueryEscape(sportType),
+		sp.Coros(),
 	)
 
 	// 3. 创建新的请求
-	req, err := http.NewRequest("POST", urlStr, nil)
+	req, err := http.NewRequestWithContext(ctx, "POST", urlStr, nil)
 	if err != nil {
 		return nil, fmt.Errorf("创建请求失败: %v", err)
 	}
@@ -210,10 +277,9 @@ func (s *corosService) SportsSummary(labelId, sportType string) (*SportsSummaryR
 	req.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 15_0 like Mac OS X) AppleWebKit/605.1.15")
 
 	// 5. 发送请求
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("请求失败: %v", err)
+		return nil, fmt.Errorf("请求失败: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -261,8 +327,84 @@ func (s *corosService) SportsSummary(labelId, sportType string) (*SportsSummaryR
 	}
 
 	// 返回处理后的数据
+	// 以详情中的 sportType 为准，请求参数只用于查询
+	if code, ok := respData.Data.Summary["sportType"].(float64); ok {
+		if detail := sport.FromCoros(int(code)); detail != sport.Unknown {
+			sp = detail
+		}
+	}
+
 	return &SportsSummaryResult{
+		Sport:   sp,
 		LapList: filteredLapList,
 		Summary: respData.Data.Summary,
 	}, nil
 }
+
+// call 以账号身份请求高驰接口，body 不为 nil 时以 JSON 发送，检查 result 并把 data 解析到 out。
+// 失败时按 httpClient 的策略重试，非幂等的接口使用 callOnce
+func (s *corosService) call(ctx context.Context, accountID, method, urlStr string, body, out interface{}) error {
+	return s.request(ctx, accountID, method, urlStr, body, out, s.client.Do)
+}
+
+// callOnce 与 call 相同，但请求只发送一次，用于新增数据等非幂等的接口：
+// 超时或 5xx 时服务端可能已经处理了请求，重试会重复创建
+func (s *corosService) callOnce(ctx context.Context, accountID, method, urlStr string, body, out interface{}) error {
+	return s.request(ctx, accountID, method, urlStr, body, out, s.client.DoOnce)
+}
+
+func (s *corosService) request(ctx context.Context, accountID, method, urlStr string, body, out interface{}, do func(*http.Request) (*http.Response, error)) error {
+	token, err := s.Login(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("JSON序列化失败: %v", err)
+		}
+		reqBody = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, reqBody)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("accesstoken", token)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 15_0 like Mac OS X) AppleWebKit/605.1.15")
+
+	resp, err := do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %v", err)
+	}
+
+	var envelope struct {
+		Result string          `json:"result"`
+		Msg    string          `json:"message"`
+		Data   json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	if envelope.Result != "0000" {
+		return fmt.Errorf("获取失败: %s", envelope.Msg)
+	}
+	if out == nil || len(envelope.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("解析响应数据失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package coros
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"fitgo/pkg/breaker"
+	"fitgo/pkg/config"
+)
+
+// ErrCircuitOpen 熔断器打开时直接拒绝请求
+var ErrCircuitOpen = errors.New("高驰接口熔断中，请稍后重试")
+
+// metrics 通过 expvar 暴露在 /debug/vars 的 coros 字段下
+var metrics = expvar.NewMap("coros")
+
+// httpClient 所有高驰请求共用的 HTTP 客户端，负责超时、限流、重试与熔断
+type httpClient struct {
+	client      *http.Client
+	maxRetries  int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+	limiter     *tokenBucket
+	breaker     *breaker.Breaker
+}
+
+func newHTTPClient(cfg config.CorosHTTPConfig) *httpClient {
+	timeout := 15 * time.Second
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	maxRetries := 3
+	if cfg.MaxRetries > 0 {
+		maxRetries = cfg.MaxRetries
+	} else if cfg.MaxRetries < 0 {
+		maxRetries = 0
+	}
+	backoffBase := 200 * time.Millisecond
+	if cfg.BackoffBase > 0 {
+		backoffBase = time.Duration(cfg.BackoffBase) * time.Millisecond
+	}
+	backoffMax := 5 * time.Second
+	if cfg.BackoffMax > 0 {
+		backoffMax = time.Duration(cfg.BackoffMax) * time.Millisecond
+	}
+	rate := 5.0
+	if cfg.RateLimit > 0 {
+		rate = cfg.RateLimit
+	}
+	burst := 10
+	if cfg.Burst > 0 {
+		burst = cfg.Burst
+	}
+
+	c := &httpClient{
+		client:      &http.Client{Timeout: timeout},
+		maxRetries:  maxRetries,
+		backoffBase: backoffBase,
+		backoffMax:  backoffMax,
+		limiter:     newTokenBucket(rate, burst),
+	}
+	if cfg.BreakerThreshold > 0 {
+		cooldown := 30 * time.Second
+		if cfg.BreakerCooldown > 0 {
+			cooldown = time.Duration(cfg.BreakerCooldown) * time.Second
+		}
+		c.breaker = breaker.New(cfg.BreakerThreshold, cooldown)
+	}
+	return c
+}
+
+// Do 发送请求，网络错误、429 与 5xx 响应按指数退避加抖动重试。
+// 带请求体的请求需要能通过 GetBody 重放(http.NewRequest 对 bytes.Buffer 等会自动设置)
+func (c *httpClient) Do(req *http.Request) (*http.Response, error) {
+	return c.do(req, c.maxRetries)
+}
+
+// DoOnce 与 Do 相同(限流、熔断和指标)，但不重试，用于非幂等的请求
+func (c *httpClient) DoOnce(req *http.Request) (*http.Response, error) {
+	return c.do(req, 0)
+}
+
+func (c *httpClient) do(req *http.Request, maxRetries int) (*http.Response, error) {
+	ctx := req.Context()
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	if c.breaker != nil && !c.breaker.Allow() {
+		metrics.Add("rejected", 1)
+		return nil, ErrCircuitOpen
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.wait(ctx); err != nil {
+			c.release()
+			return nil, err
+		}
+
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("重放请求体失败: %v", err)
+			}
+			req.Body = body
+		}
+
+		start := time.Now()
+		resp, err := c.client.Do(req)
+		latency := time.Since(start)
+		metrics.Add("requests", 1)
+		metrics.AddFloat("latency_ms_total", float64(latency.Milliseconds()))
+
+		retryable := isRetryable(ctx, resp, err)
+		if !retryable {
+			if err != nil {
+				// 调用方取消或超时，高驰没有应答，不计入健康状态
+				c.release()
+			} else {
+				c.recordResult(true)
+			}
+			return resp, err
+		}
+
+		status := "network error: " + fmt.Sprint(err)
+		if err == nil {
+			status = resp.Status
+		}
+
+		if attempt >= maxRetries || !replayable {
+			metrics.Add("failures", 1)
+			c.recordResult(false)
+			log.Printf("coros: %s %s 失败(%s)，已重试 %d 次，耗时 %v", req.Method, req.URL.Path, status, attempt, latency)
+			return resp, err
+		}
+
+		// 丢弃本次响应体，复用连接
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		delay := c.backoff(attempt)
+		metrics.Add("retries", 1)
+		log.Printf("coros: %s %s 失败(%s)，耗时 %v，%v 后第 %d 次重试", req.Method, req.URL.Path, status, latency, delay, attempt+1)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			c.release()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff 返回第 attempt 次失败后的等待时间: base*2^attempt 上限 max，再取 [0.5, 1) 的随机抖动
+func (c *httpClient) backoff(attempt int) time.Duration {
+	delay := float64(c.backoffBase) * math.Pow(2, float64(attempt))
+	if delay > float64(c.backoffMax) {
+		delay = float64(c.backoffMax)
+	}
+	return time.Duration(delay * (0.5 + rand.Float64()/2))
+}
+
+func (c *httpClient) recordResult(success bool) {
+	if c.breaker == nil {
+		return
+	}
+	if c.breaker.Record(success) {
+		metrics.Add("breaker_opened", 1)
+		log.Printf("coros: 连续失败 %d 次，熔断 %v", c.breaker.Threshold(), c.breaker.Cooldown())
+	}
+}
+
+// release 请求被取消、结果不计入健康状态时释放熔断器的试探名额
+func (c *httpClient) release() {
+	if c.breaker != nil {
+		c.breaker.Release()
+	}
+}
+
+func isRetryable(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		// 调用方取消或超时不重试
+		return ctx.Err() == nil
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// tokenBucket 令牌桶限流器
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒补充的令牌数
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait 阻塞直到取得一个令牌或 ctx 结束
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		metrics.Add("throttled", 1)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package coros
+
+import (
+	"context"
+	"fmt"
+
+	"fitgo/internal/service/workout"
+)
+
+// 高驰训练计划中课程步骤的取值
+const (
+	exerciseWarmup   = 1
+	exerciseTraining = 2
+	exerciseCooldown = 3
+	exerciseRest     = 4
+
+	exerciseTargetOpen     = 0
+	exerciseTargetTime     = 2 // 秒
+	exerciseTargetDistance = 5 // 厘米
+
+	intensityNone      = 0
+	intensityHeartRate = 2
+	intensityPace      = 3 // 秒/公里
+	intensityPower     = 6
+	intensityCadence   = 7
+)
+
+// programExercise 高驰训练课的一个步骤，重复块是 IsGroup 为 true 的步骤，子步骤通过 GroupID 关联
+type programExercise struct {
+	ID                   string `json:"id"`
+	Name                 string `json:"name"`
+	SortNo               int    `json:"sortNo"`
+	ExerciseType         int    `json:"exerciseType"`
+	TargetType           int    `json:"targetType"`
+	TargetValue          int    `json:"targetValue"`
+	IntensityType        int    `json:"intensityType"`
+	IntensityValue       int    `json:"intensityValue"`
+	IntensityValueExtend int    `json:"intensityValueExtend"`
+	Sets                 int    `json:"sets"`
+	IsGroup              bool   `json:"isGroup"`
+	GroupID              string `json:"groupId"`
+}
+
+// program /training/program/add 的请求体
+type program struct {
+	Name      string            `json:"name"`
+	SportType int               `json:"sportType"`
+	Overview  string            `json:"overview"`
+	Exercises []programExercise `json:"exercises"`
+}
+
+func (s *corosService) UploadWorkout(ctx context.Context, accountID string, w *workout.Workout) (string, error) {
+	if err := w.Validate(); err != nil {
+		return "", err
+	}
+
+	p := program{
+		Name:      w.Name,
+		SportType: w.Sport.Coros(),
+		Overview:  w.Description,
+		Exercises: toExercises(w.Steps),
+	}
+
+	var data struct {
+		ID string `json:"id"`
+	}
+	if err := s.callOnce(ctx, accountID, "POST", s.config.Address+"/training/program/add", p, &data); err != nil {
+		return "", fmt.Errorf("上传训练课失败: %w", err)
+	}
+	return data.ID, nil
+}
+
+func toExercises(steps []workout.Step) []programExercise {
+	var exercises []programExercise
+	for _, step := range steps {
+		sortNo := len(exercises) + 1
+		if step.Type != workout.StepRepeat {
+			exercises = append(exercises, toExercise(step, sortNo, ""))
+			continue
+		}
+
+		groupID := fmt.Sprintf("group-%d", sortNo)
+		exercises = append(exercises, programExercise{
+			ID:           groupID,
+			Name:         step.Name,
+			SortNo:       sortNo,
+			ExerciseType: exerciseTraining,
+			Sets:         step.Repeat,
+			IsGroup:      true,
+		})
+		for _, child := range step.Steps {
+			exercises = append(exercises, toExercise(child, len(exercises)+1, groupID))
+		}
+	}
+	return exercises
+}
+
+func toExercise(step workout.Step, sortNo int, groupID string) programExercise {
+	e := programExercise{
+		ID:      fmt.Sprintf("step-%d", sortNo),
+		Name:    step.Name,
+		SortNo:  sortNo,
+		Sets:    1,
+		GroupID: groupID,
+	}
+
+	switch step.Type {
+	case workout.StepWarmup:
+		e.ExerciseType = exerciseWarmup
+	case workout.StepCooldown:
+		e.ExerciseType = exerciseCooldown
+	case workout.StepRest, workout.StepRecovery:
+		e.ExerciseType = exerciseRest
+	default:
+		e.ExerciseType = exerciseTraining
+	}
+
+	switch step.Duration.Type {
+	case workout.DurationTime:
+		e.TargetType = exerciseTargetTime
+		e.TargetValue = int(step.Duration.Value)
+	case workout.DurationDistance:
+		e.TargetType = exerciseTargetDistance
+		e.TargetValue = int(step.Duration.Value * 100)
+	default:
+		e.TargetType = exerciseTargetOpen
+	}
+
+	e.IntensityValue = int(step.Target.Low)
+	e.IntensityValueExtend = int(step.Target.High)
+	switch step.Target.Type {
+	case workout.TargetHeartRate:
+		e.IntensityType = intensityHeartRate
+	case workout.TargetPace:
+		e.IntensityType = intensityPace
+	case workout.TargetPower:
+		e.IntensityType = intensityPower
+	case workout.TargetCadence:
+		e.IntensityType = intensityCadence
+	default:
+		e.IntensityType = intensityNone
+		e.IntensityValue, e.IntensityValueExtend = 0, 0
+	}
+	return e
+}
@@ -0,0 +1,97 @@
+// Package prediction 根据近期成绩预测比赛成绩并推算训练配速。
+//
+// 成绩来自本地跑步活动的最好成绩(activity.BestEfforts)和请求中提供的比赛成绩，
+// 预测使用三个确定性的模型：Riegel 公式、Jack Daniels VDOT 和临界速度(CS/D')，
+// 训练配速由 VDOT 推算。模型不调用 AI，同样的成绩总是得到同样的预测
+package prediction
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotEnoughData 时间范围内没有可用的成绩
+var ErrNotEnoughData = errors.New("没有可用于预测的跑步成绩")
+
+// ErrInvalidPerformance 比赛成绩的距离或用时不合法
+var ErrInvalidPerformance = errors.New("比赛成绩的距离和用时必须大于 0")
+
+// DefaultDays 默认回看的天数
+const DefaultDays = 90
+
+// 成绩来源
+const (
+	SourceBestEffort = "best_effort" // 训练中的最好成绩
+	SourceRace       = "race"        // 请求中提供的比赛成绩
+)
+
+// Race 预测的比赛距离
+type Race struct {
+	Name     string  `json:"name"`
+	Distance float64 `json:"distance"` // 米
+}
+
+// Races 预测的比赛：5 公里、10 公里、半程和全程马拉松
+var Races = []Race{
+	{Name: "5k", Distance: 5000},
+	{Name: "10k", Distance: 10000},
+	{Name: "half_marathon", Distance: 21097.5},
+	{Name: "marathon", Distance: 42195},
+}
+
+// PredictionService 定义了比赛成绩预测的接口
+type PredictionService interface {
+	// Predict 根据 Options.AccountID 在 Options.Until 之前 Options.Days 天内的成绩预测比赛成绩
+	Predict(opts Options) (*Prediction, error)
+}
+
+// Options 预测选项
+type Options struct {
+	AccountID string        // 只使用该高驰账号导入的活动
+	Until     time.Time     // 只使用此前的活动，零值为现在
+	Days      int           // 回看的天数，0 为 DefaultDays
+	Races     []Performance // 本地活动之外的近期比赛成绩，与最好成绩一起参与预测
+}
+
+// Performance 一次成绩
+type Performance struct {
+	Distance   float64 `json:"distance"` // 米
+	Duration   float64 `json:"duration"` // 秒
+	Date       string  `json:"date,omitempty"`
+	ActivityID string  `json:"activity_id,omitempty"`
+	Source     string  `json:"source"`
+}
+
+// Prediction 预测结果，时间为秒、配速为秒/公里
+type Prediction struct {
+	From         string        `json:"from"`         // YYYY-MM-DD
+	To           string        `json:"to"`           // YYYY-MM-DD，含当天
+	Performances []Performance `json:"performances"` // 参与预测的成绩，每个距离只保留最快的一次
+
+	VDOT          float64     `json:"vdot"`
+	VDOTBasis     Performance `json:"vdot_basis"`               // 计算 VDOT 的成绩
+	CriticalSpeed float64     `json:"critical_speed,omitempty"` // 米/秒，2-30 分钟的成绩少于两个距离时为 0
+	DPrime        float64     `json:"d_prime,omitempty"`        // 米，超过临界速度时可用的无氧储备
+
+	Predictions []RacePrediction `json:"predictions"`
+	Paces       []TrainingPace   `json:"paces"`
+}
+
+// RacePrediction 一个距离的预测
+type RacePrediction struct {
+	Race
+	Riegel        float64 `json:"riegel"`                   // 由距离最接近的成绩按 Riegel 公式推算
+	VDOT          float64 `json:"vdot"`                     // VDOT 对应的成绩
+	CriticalSpeed float64 `json:"critical_speed,omitempty"` // 临界速度模型的成绩，只用于一小时以内的比赛
+	Time          float64 `json:"time"`                     // 各模型的平均值
+	Low           float64 `json:"low"`                      // 置信区间下限
+	High          float64 `json:"high"`                     // 置信区间上限
+	Basis         float64 `json:"basis"`                    // Riegel 推算使用的成绩距离
+}
+
+// TrainingPace 由 VDOT 推算的训练配速区间
+type TrainingPace struct {
+	Name string  `json:"name"` // easy、marathon、threshold、interval、repetition
+	Fast float64 `json:"fast"` // 区间内较快的配速
+	Slow float64 `json:"slow"` // 区间内较慢的配速
+}
@@ -0,0 +1,113 @@
+package prediction
+
+import (
+	"math"
+)
+
+// RiegelExponent Riegel 公式的疲劳指数
+const RiegelExponent = 1.06
+
+// 临界速度模型使用 2-30 分钟的成绩，只预测一小时以内的比赛
+const (
+	minCriticalDuration = 120
+	maxCriticalDuration = 1800
+	maxCriticalTarget   = 3600
+)
+
+// minBasisDistance 计算 VDOT 和 Riegel 时优先使用不短于 3 公里的成绩，更短的成绩无氧成分过多
+const minBasisDistance = 3000
+
+// paceZones 各训练配速对应的 VO2max 百分比(Daniels《跑步方程式》)
+var paceZones = []struct {
+	name      string
+	low, high float64
+}{
+	{"easy", 0.59, 0.74},
+	{"marathon", 0.75, 0.84},
+	{"threshold", 0.83, 0.88},
+	{"interval", 0.95, 1.00},
+	{"repetition", 1.05, 1.10},
+}
+
+// Riegel 按 Riegel 公式 T2 = T1 × (D2 / D1)^1.06 由一次成绩推算另一距离的用时
+func Riegel(distance, duration, target float64) float64 {
+	return duration * math.Pow(target/distance, RiegelExponent)
+}
+
+// VDOT 按 Daniels-Gilbert 公式计算一次成绩的 VDOT：
+// 以该速度跑步的摄氧量除以能维持该时间的 VO2max 百分比
+func VDOT(distance, duration float64) float64 {
+	minutes := duration / 60
+	percent := 0.8 + 0.1894393*math.Exp(-0.012778*minutes) + 0.2989558*math.Exp(-0.1932605*minutes)
+	return oxygenCost(distance/minutes) / percent
+}
+
+// TimeForVDOT 该 VDOT 跑完 distance 的用时(秒)，用二分法反解 VDOT 公式
+func TimeForVDOT(vdot, distance float64) float64 {
+	// 用时在 10 米/秒 到 0.5 米/秒之间，VDOT 随用时单调递减
+	low, high := distance/10, distance*2
+	for i := 0; i < 100; i++ {
+		mid := (low + high) / 2
+		if VDOT(distance, mid) > vdot {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2
+}
+
+// CriticalSpeed 用 2-30 分钟的成绩线性拟合 距离 = D' + CS × 时间，
+// 返回临界速度(米/秒)和 D'(米)。少于两个距离或拟合结果不合理时 ok 为 false
+func CriticalSpeed(performances []Performance) (cs, dPrime float64, ok bool) {
+	var n, sumT, sumD, sumTT, sumTD float64
+	distances := map[float64]bool{}
+	for _, p := range performances {
+		if p.Duration < minCriticalDuration || p.Duration > maxCriticalDuration {
+			continue
+		}
+		n++
+		sumT += p.Duration
+		sumD += p.Distance
+		sumTT += p.Duration * p.Duration
+		sumTD += p.Duration * p.Distance
+		distances[p.Distance] = true
+	}
+	if len(distances) < 2 {
+		return 0, 0, false
+	}
+	denominator := n*sumTT - sumT*sumT
+	if denominator == 0 {
+		return 0, 0, false
+	}
+	cs = (n*sumTD - sumT*sumD) / denominator
+	dPrime = (sumD - cs*sumT) / n
+	if cs <= 0 || dPrime < 0 {
+		return 0, 0, false
+	}
+	return cs, dPrime, true
+}
+
+// Paces 由 VDOT 推算的训练配速(秒/公里)
+func Paces(vdot float64) []TrainingPace {
+	paces := make([]TrainingPace, 0, len(paceZones))
+	for _, z := range paceZones {
+		paces = append(paces, TrainingPace{
+			Name: z.name,
+			Fast: 60000 / velocity(vdot*z.high),
+			Slow: 60000 / velocity(vdot*z.low),
+		})
+	}
+	return paces
+}
+
+// oxygenCost 以 velocity 米/分钟跑步的摄氧量(ml/kg/min)
+func oxygenCost(velocity float64) float64 {
+	return -4.60 + 0.182258*velocity + 0.000104*velocity*velocity
+}
+
+// velocity 摄氧量为 vo2 时的跑速(米/分钟)，oxygenCost 的反函数
+func velocity(vo2 float64) float64 {
+	a, b, c := 0.000104, 0.182258, -4.60-vo2
+	return (-b + math.Sqrt(b*b-4*a*c)) / (2 * a)
+}
@@ -0,0 +1,179 @@
+package prediction
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/coros"
+	"fitgo/pkg/sport"
+)
+
+// predictionService 是PredictionService接口的具体实现
+type predictionService struct {
+	activities activity.ActivityService
+	now        func() time.Time
+}
+
+// NewPredictionService 创建比赛成绩预测服务
+func NewPredictionService(activities activity.ActivityService) PredictionService {
+	return &predictionService{
+		activities: activities,
+		now:        time.Now,
+	}
+}
+
+func (s *predictionService) Predict(opts Options) (*Prediction, error) {
+	until := opts.Until
+	if until.IsZero() {
+		until = s.now()
+	}
+	days := opts.Days
+	if days <= 0 {
+		days = DefaultDays
+	}
+	from := until.AddDate(0, 0, -days)
+
+	for _, race := range opts.Races {
+		if race.Distance <= 0 || race.Duration <= 0 {
+			return nil, fmt.Errorf("%w: %.0f 米 %.0f 秒", ErrInvalidPerformance, race.Distance, race.Duration)
+		}
+	}
+	efforts, err := s.bestEfforts(opts.AccountID, from, until)
+	if err != nil {
+		return nil, err
+	}
+	performances := fastest(append(efforts, races(opts.Races)...))
+	if len(performances) == 0 {
+		return nil, fmt.Errorf("%w: %s 至 %s", ErrNotEnoughData, from.Format(coros.DateLayout), until.Add(-time.Second).Format(coros.DateLayout))
+	}
+
+	p := predict(performances)
+	p.From = from.Format(coros.DateLayout)
+	p.To = until.Add(-time.Second).Format(coros.DateLayout) // until 不含在内
+	return p, nil
+}
+
+// bestEfforts 账号在 [from, until) 内路跑、跑步机和操场跑的最好成绩。越野跑受地形影响，不参与预测
+func (s *predictionService) bestEfforts(accountID string, from, until time.Time) ([]Performance, error) {
+	summaries, err := s.activities.List()
+	if err != nil {
+		return nil, err
+	}
+	var efforts []Performance
+	for _, summary := range summaries {
+		if summary.Source.AccountID != accountID {
+			continue
+		}
+		if summary.Sport.Family() != sport.FamilyRunning || summary.Sport == sport.TrailRun {
+			continue
+		}
+		start, err := time.Parse(time.RFC3339, summary.StartTime)
+		if err != nil || start.Before(from) || !start.Before(until) {
+			continue
+		}
+		act, err := s.activities.Get(summary.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, effort := range activity.BestEfforts(act, activity.StandardDistances) {
+			efforts = append(efforts, Performance{
+				Distance:   effort.Distance,
+				Duration:   effort.Duration,
+				Date:       start.Local().Format(coros.DateLayout),
+				ActivityID: effort.ActivityID,
+				Source:     SourceBestEffort,
+			})
+		}
+	}
+	return efforts, nil
+}
+
+// races 标记请求中的比赛成绩
+func races(performances []Performance) []Performance {
+	result := make([]Performance, 0, len(performances))
+	for _, p := range performances {
+		p.Source = SourceRace
+		result = append(result, p)
+	}
+	return result
+}
+
+// fastest 每个距离只保留最快的一次成绩，按距离排序
+func fastest(performances []Performance) []Performance {
+	best := map[float64]Performance{}
+	for _, p := range performances {
+		if current, ok := best[p.Distance]; !ok || p.Duration < current.Duration {
+			best[p.Distance] = p
+		}
+	}
+	result := make([]Performance, 0, len(best))
+	for _, p := range best {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Distance < result[j].Distance })
+	return result
+}
+
+// predict 用三个模型预测各比赛距离的成绩。
+// 置信区间取各模型结果的最小和最大值，再按推算的距离跨度放宽：
+// 基础 1%，距离每翻一倍再加 2%，由 10 公里推算全程马拉松约为 ±5%
+func predict(performances []Performance) *Prediction {
+	basis := performances
+	var long []Performance
+	for _, p := range performances {
+		if p.Distance >= minBasisDistance {
+			long = append(long, p)
+		}
+	}
+	if len(long) > 0 {
+		basis = long
+	}
+
+	p := &Prediction{Performances: performances}
+	for _, b := range basis {
+		if vdot := VDOT(b.Distance, b.Duration); vdot > p.VDOT {
+			p.VDOT, p.VDOTBasis = vdot, b
+		}
+	}
+	cs, dPrime, hasCS := CriticalSpeed(performances)
+	if hasCS {
+		p.CriticalSpeed, p.DPrime = cs, dPrime
+	}
+
+	for _, race := range Races {
+		nearest := basis[0]
+		for _, b := range basis[1:] {
+			if math.Abs(math.Log(b.Distance/race.Distance)) < math.Abs(math.Log(nearest.Distance/race.Distance)) {
+				nearest = b
+			}
+		}
+		r := RacePrediction{
+			Race:   race,
+			Riegel: Riegel(nearest.Distance, nearest.Duration, race.Distance),
+			VDOT:   TimeForVDOT(p.VDOT, race.Distance),
+			Basis:  nearest.Distance,
+		}
+		models := []float64{r.Riegel, r.VDOT}
+		if hasCS && race.Distance > dPrime {
+			if t := (race.Distance - dPrime) / cs; t <= maxCriticalTarget {
+				r.CriticalSpeed = t
+				models = append(models, t)
+			}
+		}
+
+		low, high, sum := models[0], models[0], 0.0
+		for _, t := range models {
+			low, high, sum = math.Min(low, t), math.Max(high, t), sum+t
+		}
+		margin := 0.01 + 0.02*math.Abs(math.Log2(race.Distance/nearest.Distance))
+		r.Time = sum / float64(len(models))
+		r.Low = low * (1 - margin)
+		r.High = high * (1 + margin)
+		p.Predictions = append(p.Predictions, r)
+	}
+	p.Paces = Paces(p.VDOT)
+	return p
+}
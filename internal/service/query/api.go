@@ -0,0 +1,88 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidQuery 查询不符合 DSL 的约束
+var ErrInvalidQuery = errors.New("查询不合法")
+
+// ErrEmptyQuestion 问题为空
+var ErrEmptyQuestion = errors.New("问题不能为空")
+
+// ErrNoAccount 查询没有指定账号
+var ErrNoAccount = errors.New("查询需要指定账号")
+
+// QueryService 定义了基于本地活动的自然语言查询接口。
+// 模型只负责把问题翻译成受约束的查询 DSL(见 Query)，查询经过校验后由程序执行，
+// 每次生成的查询都记录在日志中供复核。查询只包含来源账号为 accountID 的活动，accountID 为空时返回 ErrNoAccount
+type QueryService interface {
+	// Ask 把问题翻译成查询并执行，返回结果表格和模型根据结果写的简短回答
+	Ask(ctx context.Context, accountID, question string) (*Answer, error)
+
+	// Execute 校验并直接执行查询
+	Execute(accountID string, q *Query) (*Table, error)
+
+	// Log 返回账号最近 limit 条查询日志，按时间倒序
+	Log(accountID string, limit int) ([]*LogEntry, error)
+}
+
+// Answer 一次自然语言查询的结果
+type Answer struct {
+	Question string `json:"question"`
+	Query    *Query `json:"query"`
+	Table    *Table `json:"table"`
+	Answer   string `json:"answer"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+// Table 查询结果，数值保留两位小数
+type Table struct {
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+	Truncated bool            `json:"truncated,omitempty"` // 结果超过行数上限被截断
+}
+
+// LogEntry 一条查询日志
+type LogEntry struct {
+	Time      string `json:"time"`
+	AccountID string `json:"account_id"`
+	Question  string `json:"question"`
+	Output    string `json:"output"`          // 模型的原始输出
+	Query     *Query `json:"query,omitempty"` // 通过校验的查询
+	Error     string `json:"error,omitempty"`
+	Rows      int    `json:"rows"`
+	Provider  string `json:"provider,omitempty"`
+	Model     string `json:"model,omitempty"`
+}
+
+// Markdown 把结果格式化为 Markdown 表格，空值显示为 -
+func (t *Table) Markdown() string {
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(t.Columns, " | ") + " |\n|")
+	for range t.Columns {
+		b.WriteString("---|")
+	}
+	b.WriteString("\n")
+	for _, row := range t.Rows {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			cells[i] = "-"
+			if v != nil {
+				cells[i] = fmt.Sprint(v)
+			}
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	if len(t.Rows) == 0 {
+		b.WriteString("(没有数据)\n")
+	}
+	if t.Truncated {
+		b.WriteString("(结果已截断)\n")
+	}
+	return b.String()
+}
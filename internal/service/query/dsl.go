@@ -0,0 +1,269 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 查询粒度
+const (
+	ScopeActivity = "activity" // 每次活动一行
+	ScopeLap      = "lap"      // 每个分段一行
+	ScopePoint    = "point"    // 每个轨迹点一行，用于"心率高于 160 时跑了多少公里"这类问题
+)
+
+// 行数上限
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// Query 查询 DSL。过滤条件之间是"且"的关系；有聚合时按 GroupBy 分组输出聚合值，
+// 没有聚合时输出 Select 中的字段
+type Query struct {
+	Scope      string      `json:"scope"`
+	Filters    []Filter    `json:"filters,omitempty"`
+	GroupBy    []string    `json:"group_by,omitempty"`
+	Aggregates []Aggregate `json:"aggregates,omitempty"`
+	Select     []string    `json:"select,omitempty"`
+	OrderBy    *Order      `json:"order_by,omitempty"`
+	Limit      int         `json:"limit,omitempty"`
+}
+
+// Filter 过滤条件，between 的值为 [下限, 上限](含两端)，in 的值为数组
+type Filter struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value"`
+}
+
+// Aggregate 聚合，count 不需要字段
+type Aggregate struct {
+	Func  string `json:"func"`
+	Field string `json:"field,omitempty"`
+}
+
+// Order 排序，Column 为结果中的列名
+type Order struct {
+	Column string `json:"column"`
+	Desc   bool   `json:"desc,omitempty"`
+}
+
+// Column 聚合结果的列名，如 sum(distance_km)
+func (a Aggregate) Column() string {
+	if a.Field == "" {
+		return a.Func
+	}
+	return a.Func + "(" + a.Field + ")"
+}
+
+// 字段类型
+const (
+	kindNumber = "number"
+	kindString = "string"
+)
+
+// field 可查询的字段
+type field struct {
+	name   string
+	kind   string
+	desc   string
+	scopes []string // 为空表示所有粒度都可用
+}
+
+// fields 字段白名单，提示词中的 DSL 说明也由它生成
+var fields = []field{
+	{name: "date", kind: kindString, desc: "本地日期 YYYY-MM-DD"},
+	{name: "month", kind: kindString, desc: "本地月份 YYYY-MM"},
+	{name: "week", kind: kindString, desc: "ISO 周 YYYY-Www"},
+	{name: "sport", kind: kindString, desc: "运动类型，如 run、trail_run、road_bike"},
+	{name: "family", kind: kindString, desc: "运动大类：running、cycling、swimming、strength、hiking、other"},
+	{name: "activity_id", kind: kindString, desc: "活动ID"},
+	{name: "distance_km", kind: kindNumber, desc: "距离(公里)；point 粒度为与上一个点之间的距离"},
+	{name: "duration_min", kind: kindNumber, desc: "计时时间(分钟)；point 粒度为与上一个点之间的时间，超过 30 秒的间隔视为暂停不计"},
+	{name: "pace_s_per_km", kind: kindNumber, desc: "平均配速(秒/公里)", scopes: []string{ScopeActivity, ScopeLap}},
+	{name: "avg_hr", kind: kindNumber, desc: "平均心率(bpm)", scopes: []string{ScopeActivity, ScopeLap}},
+	{name: "max_hr", kind: kindNumber, desc: "最大心率(bpm)", scopes: []string{ScopeActivity, ScopeLap}},
+	{name: "ascent_m", kind: kindNumber, desc: "累计爬升(米)", scopes: []string{ScopeActivity}},
+	{name: "calories", kind: kindNumber, desc: "热量(千卡)", scopes: []string{ScopeActivity, ScopeLap}},
+	{name: "avg_cadence", kind: kindNumber, desc: "平均步频(spm)", scopes: []string{ScopeLap}},
+	{name: "avg_power", kind: kindNumber, desc: "平均功率(W)", scopes: []string{ScopeLap}},
+	{name: "lap", kind: kindNumber, desc: "分段序号，从 1 开始", scopes: []string{ScopeLap}},
+	{name: "heart_rate", kind: kindNumber, desc: "心率(bpm)", scopes: []string{ScopePoint}},
+	{name: "cadence", kind: kindNumber, desc: "步频(spm)", scopes: []string{ScopePoint}},
+	{name: "speed_kmh", kind: kindNumber, desc: "速度(公里/小时)", scopes: []string{ScopePoint}},
+	{name: "power", kind: kindNumber, desc: "功率(W)", scopes: []string{ScopePoint}},
+	{name: "altitude_m", kind: kindNumber, desc: "海拔(米)", scopes: []string{ScopePoint}},
+}
+
+var (
+	operators  = []string{"eq", "ne", "gt", "gte", "lt", "lte", "between", "in"}
+	functions  = []string{"count", "sum", "avg", "min", "max"}
+	scopes     = []string{ScopeActivity, ScopeLap, ScopePoint}
+	maxGroupBy = 2
+)
+
+func lookupField(name, scope string) (field, bool) {
+	for _, f := range fields {
+		if f.name != name {
+			continue
+		}
+		if len(f.scopes) > 0 && !contains(f.scopes, scope) {
+			return field{}, false
+		}
+		return f, true
+	}
+	return field{}, false
+}
+
+// Validate 校验查询，通过后补齐默认的 Limit
+func (q *Query) Validate() error {
+	if !contains(scopes, q.Scope) {
+		return invalid("scope 必须是 %s 之一", strings.Join(scopes, "、"))
+	}
+
+	for _, f := range q.Filters {
+		def, ok := lookupField(f.Field, q.Scope)
+		if !ok {
+			return invalid("%s 粒度没有字段 %q", q.Scope, f.Field)
+		}
+		if err := validateFilter(f, def); err != nil {
+			return err
+		}
+	}
+
+	if len(q.Aggregates) == 0 {
+		if len(q.GroupBy) > 0 {
+			return invalid("group_by 需要配合 aggregates 使用")
+		}
+		if len(q.Select) == 0 {
+			return invalid("没有聚合时 select 不能为空")
+		}
+		for _, name := range q.Select {
+			if _, ok := lookupField(name, q.Scope); !ok {
+				return invalid("%s 粒度没有字段 %q", q.Scope, name)
+			}
+		}
+	} else {
+		if len(q.Select) > 0 {
+			return invalid("有聚合时不能同时使用 select")
+		}
+		if len(q.GroupBy) > maxGroupBy {
+			return invalid("group_by 最多 %d 个字段", maxGroupBy)
+		}
+		for _, name := range q.GroupBy {
+			def, ok := lookupField(name, q.Scope)
+			if !ok || def.kind != kindString {
+				return invalid("不能按 %q 分组", name)
+			}
+		}
+		for _, a := range q.Aggregates {
+			if !contains(functions, a.Func) {
+				return invalid("聚合函数必须是 %s 之一", strings.Join(functions, "、"))
+			}
+			if a.Func == "count" && a.Field == "" {
+				continue
+			}
+			def, ok := lookupField(a.Field, q.Scope)
+			if !ok || def.kind != kindNumber {
+				return invalid("不能对 %q 做 %s 聚合", a.Field, a.Func)
+			}
+		}
+	}
+
+	if q.OrderBy != nil && !contains(q.columns(), q.OrderBy.Column) {
+		return invalid("order_by 的列 %q 不在结果中", q.OrderBy.Column)
+	}
+	if q.Limit < 0 || q.Limit > MaxLimit {
+		return invalid("limit 必须在 0 到 %d 之间", MaxLimit)
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultLimit
+	}
+	return nil
+}
+
+func validateFilter(f Filter, def field) error {
+	switch f.Op {
+	case "between":
+		values, ok := f.Value.([]interface{})
+		if !ok || len(values) != 2 {
+			return invalid("%s 的 between 需要 [下限, 上限] 两个值", f.Field)
+		}
+		for _, v := range values {
+			if !matchesKind(v, def.kind) {
+				return invalid("%s 的取值类型应为 %s", f.Field, def.kind)
+			}
+		}
+	case "in":
+		values, ok := f.Value.([]interface{})
+		if !ok || len(values) == 0 {
+			return invalid("%s 的 in 需要非空数组", f.Field)
+		}
+		for _, v := range values {
+			if !matchesKind(v, def.kind) {
+				return invalid("%s 的取值类型应为 %s", f.Field, def.kind)
+			}
+		}
+	default:
+		if !contains(operators, f.Op) {
+			return invalid("运算符必须是 %s 之一", strings.Join(operators, "、"))
+		}
+		if !matchesKind(f.Value, def.kind) {
+			return invalid("%s 的取值类型应为 %s", f.Field, def.kind)
+		}
+	}
+	return nil
+}
+
+// columns 结果的列名
+func (q *Query) columns() []string {
+	if len(q.Aggregates) == 0 {
+		return q.Select
+	}
+	columns := append([]string(nil), q.GroupBy...)
+	for _, a := range q.Aggregates {
+		columns = append(columns, a.Column())
+	}
+	return columns
+}
+
+// Spec 生成 DSL 的文字说明，写入提示词
+func Spec() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "scope：%s\n", strings.Join(scopes, " | "))
+	fmt.Fprintf(&b, "运算符：%s\n", strings.Join(operators, " | "))
+	fmt.Fprintf(&b, "聚合函数：%s\n", strings.Join(functions, " | "))
+	b.WriteString("字段：\n")
+	for _, f := range fields {
+		available := "全部"
+		if len(f.scopes) > 0 {
+			available = strings.Join(f.scopes, "、")
+		}
+		fmt.Fprintf(&b, "- %s (%s，粒度：%s)：%s\n", f.name, f.kind, available, f.desc)
+	}
+	return b.String()
+}
+
+func matchesKind(value interface{}, kind string) bool {
+	switch value.(type) {
+	case float64, int:
+		return kind == kindNumber
+	case string:
+		return kind == kindString
+	}
+	return false
+}
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidQuery, fmt.Sprintf(format, args...))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
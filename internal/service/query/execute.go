@@ -0,0 +1,368 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"fitgo/internal/service/activity"
+)
+
+// pauseGap 轨迹点间隔超过该值视为暂停，不计入时间
+const pauseGap = 30 * time.Second
+
+// record 一行数据，没有记录的指标不出现在 map 中，任何条件都不匹配
+type record map[string]interface{}
+
+// execute 在账号的本地活动上执行已校验的查询。先用活动级的字段过滤，只在需要时读取轨迹点
+func execute(activities activity.ActivityService, accountID string, q *Query) (*Table, error) {
+	summaries, err := activities.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []record
+	for _, summary := range summaries {
+		if summary.Source.AccountID != accountID {
+			continue
+		}
+		base := baseRecord(summary)
+		if !matchAll(q.Filters, base, true) {
+			continue
+		}
+
+		var candidates []record
+		switch q.Scope {
+		case ScopeActivity:
+			candidates = []record{activityRecord(base, summary)}
+		case ScopeLap:
+			candidates = lapRecords(base, summary)
+		case ScopePoint:
+			a, err := activities.Get(summary.ID)
+			if err != nil {
+				return nil, err
+			}
+			candidates = pointRecords(base, a)
+		}
+		for _, r := range candidates {
+			if matchAll(q.Filters, r, false) {
+				records = append(records, r)
+			}
+		}
+	}
+
+	table := &Table{Columns: q.columns()}
+	if len(q.Aggregates) == 0 {
+		for _, r := range records {
+			row := make([]interface{}, len(q.Select))
+			for i, name := range q.Select {
+				row[i] = round(r[name])
+			}
+			table.Rows = append(table.Rows, row)
+		}
+	} else {
+		table.Rows = aggregate(q, records)
+	}
+
+	if q.OrderBy != nil {
+		column := indexOf(table.Columns, q.OrderBy.Column)
+		sort.SliceStable(table.Rows, func(i, j int) bool {
+			c := compareValues(table.Rows[i][column], table.Rows[j][column])
+			if q.OrderBy.Desc {
+				return c > 0
+			}
+			return c < 0
+		})
+	}
+	if len(table.Rows) > q.Limit {
+		table.Rows = table.Rows[:q.Limit]
+		table.Truncated = true
+	}
+	if table.Rows == nil {
+		table.Rows = [][]interface{}{}
+	}
+	return table, nil
+}
+
+// baseRecord 活动级的字段，所有粒度共用
+func baseRecord(a *activity.Activity) record {
+	r := record{
+		"activity_id": a.ID,
+		"sport":       string(a.Sport),
+		"family":      string(a.Sport.Family()),
+	}
+	if start, err := time.Parse(time.RFC3339, a.StartTime); err == nil {
+		local := start.Local()
+		year, week := local.ISOWeek()
+		r["date"] = local.Format("2006-01-02")
+		r["month"] = local.Format("2006-01")
+		r["week"] = fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return r
+}
+
+func activityRecord(base record, a *activity.Activity) record {
+	r := copyRecord(base)
+	r["distance_km"] = a.Distance / 1000
+	r["duration_min"] = a.MovingTime / 60
+	if a.Distance > 0 && a.MovingTime > 0 {
+		r["pace_s_per_km"] = a.MovingTime / (a.Distance / 1000)
+	}
+	setPositive(r, "avg_hr", float64(a.AvgHR))
+	setPositive(r, "max_hr", float64(a.MaxHR))
+	setPositive(r, "calories", float64(a.Calories))
+	r["ascent_m"] = a.Ascent
+	return r
+}
+
+func lapRecords(base record, a *activity.Activity) []record {
+	records := make([]record, 0, len(a.Laps))
+	for i, lap := range a.Laps {
+		r := copyRecord(base)
+		r["lap"] = float64(i + 1)
+		r["distance_km"] = lap.Distance / 1000
+		r["duration_min"] = lap.Duration / 60
+		if lap.AvgSpeed > 0 {
+			r["pace_s_per_km"] = 1000 / lap.AvgSpeed
+		}
+		setPositive(r, "avg_hr", float64(lap.AvgHR))
+		setPositive(r, "max_hr", float64(lap.MaxHR))
+		setPositive(r, "calories", float64(lap.Calories))
+		setPositive(r, "avg_cadence", float64(lap.AvgCadence))
+		setPositive(r, "avg_power", float64(lap.AvgPower))
+		records = append(records, r)
+	}
+	return records
+}
+
+// pointRecords 每个轨迹点(第一个除外)一行，距离和时间为与上一个点之间的增量
+func pointRecords(base record, a *activity.Activity) []record {
+	records := make([]record, 0, len(a.Points))
+	for i := 1; i < len(a.Points); i++ {
+		prev, p := a.Points[i-1], a.Points[i]
+		r := copyRecord(base)
+
+		distance := 0.0
+		if p.Distance > prev.Distance {
+			distance = (p.Distance - prev.Distance) / 1000
+		}
+		r["distance_km"] = distance
+		duration := 0.0
+		t0, err0 := time.Parse(time.RFC3339, prev.Time)
+		t1, err1 := time.Parse(time.RFC3339, p.Time)
+		if gap := t1.Sub(t0); err0 == nil && err1 == nil && gap > 0 && gap <= pauseGap {
+			duration = gap.Minutes()
+		}
+		r["duration_min"] = duration
+
+		setPositive(r, "heart_rate", float64(p.HeartRate))
+		setPositive(r, "cadence", float64(p.Cadence))
+		setPositive(r, "speed_kmh", p.Speed*3.6)
+		setPositive(r, "power", float64(p.Power))
+		if p.Altitude != 0 {
+			r["altitude_m"] = p.Altitude
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// accumulator 一组记录上某个聚合的中间结果
+type accumulator struct {
+	count    int
+	sum      float64
+	min, max float64
+}
+
+func (acc *accumulator) add(v float64) {
+	if acc.count == 0 || v < acc.min {
+		acc.min = v
+	}
+	if acc.count == 0 || v > acc.max {
+		acc.max = v
+	}
+	acc.count++
+	acc.sum += v
+}
+
+func (acc *accumulator) value(fn string) interface{} {
+	if fn == "count" {
+		return float64(acc.count)
+	}
+	if acc.count == 0 {
+		return nil
+	}
+	switch fn {
+	case "sum":
+		return round(acc.sum)
+	case "avg":
+		return round(acc.sum / float64(acc.count))
+	case "min":
+		return round(acc.min)
+	default:
+		return round(acc.max)
+	}
+}
+
+// aggregate 按 GroupBy 分组计算聚合，分组按键升序；没有分组时总是返回一行
+func aggregate(q *Query, records []record) [][]interface{} {
+	type group struct {
+		keys []interface{}
+		accs []accumulator
+	}
+	groups := map[string]*group{}
+	var order []string
+	if len(q.GroupBy) == 0 {
+		groups[""] = &group{accs: make([]accumulator, len(q.Aggregates))}
+		order = append(order, "")
+	}
+
+	for _, r := range records {
+		keys := make([]interface{}, len(q.GroupBy))
+		parts := make([]string, len(q.GroupBy))
+		for i, name := range q.GroupBy {
+			keys[i] = r[name]
+			parts[i] = fmt.Sprint(r[name])
+		}
+		id := strings.Join(parts, "\x00")
+		g, ok := groups[id]
+		if !ok {
+			g = &group{keys: keys, accs: make([]accumulator, len(q.Aggregates))}
+			groups[id] = g
+			order = append(order, id)
+		}
+		for i, a := range q.Aggregates {
+			if a.Field == "" {
+				g.accs[i].add(0)
+				continue
+			}
+			if v, ok := r[a.Field].(float64); ok {
+				g.accs[i].add(v)
+			}
+		}
+	}
+
+	sort.Strings(order)
+	rows := make([][]interface{}, 0, len(order))
+	for _, id := range order {
+		g := groups[id]
+		row := append([]interface{}(nil), g.keys...)
+		for i, a := range q.Aggregates {
+			row = append(row, g.accs[i].value(a.Func))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// matchAll 所有条件都满足时返回 true。partial 为 true 时跳过记录中没有的字段，用于活动级的预过滤
+func matchAll(filters []Filter, r record, partial bool) bool {
+	for _, f := range filters {
+		v, ok := r[f.Field]
+		if !ok {
+			if partial {
+				continue
+			}
+			return false
+		}
+		if !match(f, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func match(f Filter, v interface{}) bool {
+	switch f.Op {
+	case "eq":
+		return compareValues(v, f.Value) == 0
+	case "ne":
+		return compareValues(v, f.Value) != 0
+	case "gt":
+		return compareValues(v, f.Value) > 0
+	case "gte":
+		return compareValues(v, f.Value) >= 0
+	case "lt":
+		return compareValues(v, f.Value) < 0
+	case "lte":
+		return compareValues(v, f.Value) <= 0
+	case "between":
+		bounds := f.Value.([]interface{})
+		return compareValues(v, bounds[0]) >= 0 && compareValues(v, bounds[1]) <= 0
+	case "in":
+		for _, candidate := range f.Value.([]interface{}) {
+			if compareValues(v, candidate) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compareValues 比较两个值，数字按大小、字符串按字典序(日期格式可以直接比较)，nil 最小
+func compareValues(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func round(v interface{}) interface{} {
+	if n, ok := v.(float64); ok {
+		return math.Round(n*100) / 100
+	}
+	return v
+}
+
+func setPositive(r record, name string, value float64) {
+	if value > 0 {
+		r[name] = value
+	}
+}
+
+func copyRecord(r record) record {
+	c := make(record, len(r)+10)
+	for k, v := range r {
+		c[k] = v
+	}
+	return c
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
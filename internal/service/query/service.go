@@ -0,0 +1,222 @@
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/ai/client"
+	"fitgo/internal/service/ai/prompt"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/usage"
+	"fitgo/pkg/sport"
+)
+
+// maxRetries 生成的查询不合法时，把错误反馈给模型重试的次数
+const maxRetries = 1
+
+// queryService 是QueryService接口的具体实现，查询日志追加写入 queries/log.jsonl
+type queryService struct {
+	logPath    string
+	prompts    *prompt.Store
+	activities activity.ActivityService
+	ai         *aiservice.AIService
+	mu         sync.Mutex // 保护日志文件
+}
+
+// NewQueryService 创建查询服务，日志保存在 dataDir/queries 下。ai 为 nil 时 Ask 返回 ErrNoProvider
+func NewQueryService(dataDir string, prompts *prompt.Store, activities activity.ActivityService, ai *aiservice.AIService) QueryService {
+	return &queryService{
+		logPath:    filepath.Join(dataDir, "queries", "log.jsonl"),
+		prompts:    prompts,
+		activities: activities,
+		ai:         ai,
+	}
+}
+
+func (s *queryService) Ask(ctx context.Context, accountID, question string) (*Answer, error) {
+	if accountID == "" {
+		return nil, ErrNoAccount
+	}
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, ErrEmptyQuestion
+	}
+	if s.ai == nil {
+		return nil, aiservice.ErrNoProvider
+	}
+	ctx = usage.WithUser(ctx, accountID) // 用量和限额记在账号名下
+	q, entry, err := s.translate(ctx, accountID, question)
+	if err != nil {
+		return nil, err
+	}
+	table, err := s.Execute(accountID, q)
+	if err != nil {
+		entry.Error = err.Error()
+		s.append(entry)
+		return nil, err
+	}
+	entry.Rows = len(table.Rows)
+	s.append(entry)
+
+	answer, err := s.answer(ctx, question, q, table)
+	if err != nil {
+		return nil, err
+	}
+	return &Answer{
+		Question: question,
+		Query:    q,
+		Table:    table,
+		Answer:   answer.Content,
+		Provider: answer.Provider,
+		Model:    answer.Model,
+	}, nil
+}
+
+func (s *queryService) Execute(accountID string, q *Query) (*Table, error) {
+	if accountID == "" {
+		return nil, ErrNoAccount
+	}
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	return execute(s.activities, accountID, q)
+}
+
+func (s *queryService) Log(accountID string, limit int) ([]*LogEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.logPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return []*LogEntry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取查询日志失败: %v", err)
+	}
+
+	var entries []*LogEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.AccountID != accountID {
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+
+	result := make([]*LogEntry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0 && (limit <= 0 || len(result) < limit); i-- {
+		result = append(result, entries[i])
+	}
+	return result, nil
+}
+
+// translate 让模型把问题翻译成查询，不合法时把校验错误反馈给模型重试。
+// 不合法的输出直接写入日志，通过校验的查询返回日志条目，由调用方补上执行结果后写入
+func (s *queryService) translate(ctx context.Context, accountID, question string) (*Query, *LogEntry, error) {
+	tmpl, err := s.prompts.Lookup(sport.Unknown, prompt.TaskQuery, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	system, err := tmpl.Render(prompt.Query{Today: time.Now().Format("2006-01-02"), Spec: Spec()})
+	if err != nil {
+		return nil, nil, err
+	}
+	messages := []client.ChatMessage{{Role: "system", Content: system}, {Role: "user", Content: question}}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		resp, err := s.ai.Complete(ctx, aiservice.TaskQuery, messages)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		entry := &LogEntry{AccountID: accountID, Question: question, Output: resp.Content, Provider: resp.Provider, Model: resp.Model}
+		q, err := parse(resp.Content)
+		if err == nil {
+			entry.Query = q
+			return q, entry, nil
+		}
+		entry.Error = err.Error()
+		s.append(entry)
+		lastErr = err
+
+		messages = append(messages,
+			client.ChatMessage{Role: "assistant", Content: resp.Content},
+			client.ChatMessage{Role: "user", Content: fmt.Sprintf("上面的查询没有通过校验：%v。请只输出修正后的 JSON 对象。", err)},
+		)
+	}
+	return nil, nil, lastErr
+}
+
+// answer 让模型根据查询结果写简短的回答
+func (s *queryService) answer(ctx context.Context, question string, q *Query, table *Table) (*aiservice.Response, error) {
+	tmpl, err := s.prompts.Lookup(sport.Unknown, prompt.TaskAnswer, "")
+	if err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(q)
+	content, err := tmpl.Render(prompt.QueryAnswer{Question: question, Query: string(data), Table: table.Markdown()})
+	if err != nil {
+		return nil, err
+	}
+	return s.ai.Complete(ctx, aiservice.TaskQuery, []client.ChatMessage{{Role: "user", Content: content}})
+}
+
+// parse 从模型输出中取出 JSON 对象并严格解析，未定义的字段视为不合法
+func parse(output string) (*Query, error) {
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("%w: 没有找到 JSON 对象", ErrInvalidQuery)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(output[start : end+1]))
+	decoder.DisallowUnknownFields()
+	var q Query
+	if err := decoder.Decode(&q); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// append 追加一条日志，写入失败只影响复核，不影响查询
+func (s *queryService) append(entry *LogEntry) {
+	entry.Time = time.Now().Format(time.RFC3339)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("序列化查询日志失败: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.logPath), 0o755); err != nil {
+		log.Printf("创建查询日志目录失败: %v", err)
+		return
+	}
+	f, err := os.OpenFile(s.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Printf("打开查询日志失败: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		log.Printf("写入查询日志失败: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package report
+
+import (
+	"errors"
+
+	"fitgo/internal/service/ai/tools"
+	"fitgo/internal/service/ai/verify"
+)
+
+// ErrReportNotFound 报告不存在
+var ErrReportNotFound = errors.New("AI 报告不存在")
+
+// 报告格式
+const (
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json" // 内容为 structured.Analysis 的 JSON
+)
+
+// ReportService 定义了 AI 分析报告的持久化缓存接口。
+// 同一活动的每次生成都保存为一个新版本，旧版本保留用于对比
+type ReportService interface {
+	// Find 查找活动ID、输入哈希、提示词版本都相同且模型在 models 中的最新报告，
+	// inputHash 为空时不比较输入哈希，models 为空时不限制模型；没有时返回 ErrReportNotFound
+	Find(activityID, inputHash, promptVersion string, models []string) (*Report, error)
+
+	// Save 保存一个新版本并返回带ID的报告
+	Save(r *Report) (*Report, error)
+
+	// List 列出活动的所有报告版本，按生成时间倒序
+	List(activityID string) ([]*Report, error)
+
+	// Get 获取活动的某个报告版本
+	Get(activityID, id string) (*Report, error)
+}
+
+// Report 一次 AI 分析的结果及生成它的输入
+type Report struct {
+	ID            string `json:"id"`
+	ActivityID    string `json:"activity_id"` // 与本地活动ID一致，如 coros-<labelId>
+	AccountID     string `json:"account_id,omitempty"`
+	InputHash     string `json:"input_hash"`     // 输入数据的 SHA-256
+	PromptVersion string `json:"prompt_version"` // 提示词模板版本
+	Provider      string `json:"provider"`
+	Model         string `json:"model"`
+	Content       string `json:"content"`
+	Format        string `json:"format,omitempty"` // 为空等同于 markdown
+	CreatedAt     string `json:"created_at"`
+	Cached        bool   `json:"cached,omitempty"`     // 本次请求是否命中缓存，不持久化
+	Compaction    string `json:"compaction,omitempty"` // 提示词超出 token 预算时对分段数据的压缩说明
+
+	ToolCalls    []tools.Call   `json:"tool_calls,omitempty"`   // 生成报告时模型调用的工具
+	Verification *verify.Result `json:"verification,omitempty"` // 报告中的数值与运动数据的核对结果
+}
@@ -0,0 +1,133 @@
+package report
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reportService 是ReportService接口的具体实现。
+// 报告保存为 reports/<活动ID>/<报告ID>.json，报告ID以纳秒时间戳开头，按ID排序即按生成时间排序
+type reportService struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewReportService 创建报告服务，数据保存在 dataDir/reports 下
+func NewReportService(dataDir string) ReportService {
+	return &reportService{dir: filepath.Join(dataDir, "reports")}
+}
+
+func (s *reportService) Find(activityID, inputHash, promptVersion string, models []string) (*Report, error) {
+	reports, err := s.List(activityID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range reports {
+		if (inputHash != "" && r.InputHash != inputHash) || r.PromptVersion != promptVersion {
+			continue
+		}
+		if len(models) > 0 && !contains(models, r.Model) {
+			continue
+		}
+		return r, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrReportNotFound, activityID)
+}
+
+func (s *reportService) Save(r *Report) (*Report, error) {
+	if r.ActivityID == "" {
+		return nil, fmt.Errorf("报告缺少活动ID")
+	}
+	saved := *r
+	saved.ID = newReportID()
+	saved.Cached = false
+	saved.CreatedAt = time.Now().Format(time.RFC3339)
+
+	data, err := json.MarshalIndent(&saved, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化报告失败: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dir := s.activityDir(saved.ActivityID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建数据目录失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, saved.ID+".json"), data, 0o644); err != nil {
+		return nil, fmt.Errorf("写入报告失败: %v", err)
+	}
+	return &saved, nil
+}
+
+func (s *reportService) List(activityID string) ([]*Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	files, err := filepath.Glob(filepath.Join(s.activityDir(activityID), "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("列出报告失败: %v", err)
+	}
+
+	reports := make([]*Report, 0, len(files))
+	for _, file := range files {
+		r, err := read(file)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].ID > reports[j].ID })
+	return reports, nil
+}
+
+func (s *reportService) Get(activityID, id string) (*Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return read(filepath.Join(s.activityDir(activityID), filepath.Base(id)+".json"))
+}
+
+func (s *reportService) activityDir(activityID string) string {
+	return filepath.Join(s.dir, filepath.Base(activityID))
+}
+
+func read(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrReportNotFound, strings.TrimSuffix(filepath.Base(path), ".json"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取报告失败: %v", err)
+	}
+
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("解析报告失败: %v", err)
+	}
+	return &r, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// newReportID 纳秒时间戳加随机后缀，同一纳秒内生成的报告也不会冲突
+func newReportID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%d-%s", time.Now().UnixNano(), hex.EncodeToString(b))
+}
@@ -0,0 +1,100 @@
+package review
+
+import (
+	"context"
+	"errors"
+
+	"fitgo/internal/service/report"
+	"fitgo/pkg/sport"
+)
+
+// ErrInvalidPeriod 周期不是 week 或 month
+var ErrInvalidPeriod = errors.New("周期只能是 week 或 month")
+
+// ErrInvalidDate 日期格式错误
+var ErrInvalidDate = errors.New("日期格式应为 YYYY-MM-DD")
+
+// ErrNoActivities 周期内没有活动
+var ErrNoActivities = errors.New("周期内没有活动")
+
+// 回顾周期
+const (
+	PeriodWeek  = "week"  // 周一到周日
+	PeriodMonth = "month" // 自然月
+)
+
+// ReviewService 定义了周期训练回顾的接口。
+// 统计数据由程序根据高驰账号导入的本地活动计算，模型只根据统计结果撰写回顾和下一周期的建议。
+// 回顾与单次运动报告一样保存在报告服务中，统计数据和提示词都未变化时直接返回缓存
+type ReviewService interface {
+	// Review 生成账号在 date(YYYY-MM-DD，为空时为今天)所在周期的回顾
+	Review(ctx context.Context, accountID, period, date string, opts Options) (*Review, error)
+
+	// Stats 只计算账号在 date 所在周期的统计数据，不调用模型
+	Stats(accountID, period, date string) (*Stats, error)
+}
+
+// Options 回顾选项
+type Options struct {
+	Refresh  bool   // 忽略缓存重新生成
+	Language string // 报告语言，为空时使用配置的默认语言
+}
+
+// Review 一次周期回顾
+type Review struct {
+	Stats  *Stats         `json:"stats"`
+	Report *report.Report `json:"report"`
+}
+
+// Stats 一个周期的统计数据，距离为米、时间为秒
+type Stats struct {
+	Period     string `json:"period"`
+	InProgress bool   `json:"in_progress"` // 周期尚未结束，统计截止到今天
+	Volume
+
+	BySport     []SportVolume `json:"by_sport"`              // 按运动大类汇总，按运动时间倒序
+	MaxHR       int           `json:"max_hr,omitempty"`      // 划分心率区间使用的最大心率，取最近一年活动中的最大值
+	Zones       []float64     `json:"zones,omitempty"`       // Z1-Z5 各心率区间的时间，没有心率数据时为空
+	LongestRun  *Session      `json:"longest_run,omitempty"` // 距离最长的一次跑步
+	Consistency Consistency   `json:"consistency"`
+	Previous    Volume        `json:"previous"`        // 上一周期的训练量
+	Weeks       []Volume      `json:"weeks,omitempty"` // 月回顾中每周(按周一划分)的训练量
+	Sessions    []Session     `json:"activities"`
+}
+
+// Volume 一段时间内的训练量
+type Volume struct {
+	From       string  `json:"from"` // YYYY-MM-DD
+	To         string  `json:"to"`   // YYYY-MM-DD，含当天
+	Count      int     `json:"sessions"`
+	Distance   float64 `json:"distance"`
+	MovingTime float64 `json:"moving_time"`
+	Ascent     float64 `json:"ascent"`
+	Load       float64 `json:"load"` // 按心率区间计算的训练负荷(Edwards TRIMP)
+}
+
+// SportVolume 一个运动大类的训练量
+type SportVolume struct {
+	Family     sport.Family `json:"family"`
+	Count      int          `json:"sessions"`
+	Distance   float64      `json:"distance"`
+	MovingTime float64      `json:"moving_time"`
+}
+
+// Consistency 训练的连续性
+type Consistency struct {
+	Days         int `json:"days"`          // 统计的天数，进行中的周期截止到今天
+	ActiveDays   int `json:"active_days"`   // 有训练的天数
+	LongestBreak int `json:"longest_break"` // 最长连续休息天数
+}
+
+// Session 周期内的一次活动
+type Session struct {
+	ActivityID string      `json:"activity_id"`
+	Date       string      `json:"date"`
+	Sport      sport.Sport `json:"sport"`
+	Distance   float64     `json:"distance"`
+	MovingTime float64     `json:"moving_time"`
+	AvgHR      int         `json:"avg_hr,omitempty"`
+	Load       float64     `json:"load"`
+}
@@ -0,0 +1,238 @@
+package review
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/ai/client"
+	"fitgo/internal/service/ai/prompt"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/coros"
+	"fitgo/internal/service/report"
+	"fitgo/internal/service/usage"
+	"fitgo/pkg/sport"
+	"fitgo/pkg/units"
+)
+
+// reviewService 是ReviewService接口的具体实现，回顾作为报告保存，
+// 报告的活动ID为 review-<账号>-<周期>-<起始日期>
+type reviewService struct {
+	prompts    *prompt.Store
+	activities activity.ActivityService
+	reports    report.ReportService
+	ai         *aiservice.AIService
+	now        func() time.Time
+}
+
+// NewReviewService 创建周期回顾服务。ai 为 nil 时 Review 返回 ErrNoProvider，Stats 不受影响
+func NewReviewService(prompts *prompt.Store, activities activity.ActivityService, reports report.ReportService, ai *aiservice.AIService) ReviewService {
+	return &reviewService{
+		prompts:    prompts,
+		activities: activities,
+		reports:    reports,
+		ai:         ai,
+		now:        time.Now,
+	}
+}
+
+func (s *reviewService) Stats(accountID, period, date string) (*Stats, error) {
+	if period == "" {
+		period = PeriodWeek
+	}
+	if period != PeriodWeek && period != PeriodMonth {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPeriod, period)
+	}
+	day := s.now()
+	if date != "" {
+		parsed, err := time.ParseInLocation(coros.DateLayout, date, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidDate, date)
+		}
+		day = parsed
+	}
+
+	from, to := bounds(period, day)
+	c, err := newCollector(s.activities, accountID, to)
+	if err != nil {
+		return nil, err
+	}
+	return c.stats(period, from, to, s.now())
+}
+
+func (s *reviewService) Review(ctx context.Context, accountID, period, date string, opts Options) (*Review, error) {
+	stats, err := s.Stats(accountID, period, date)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Count == 0 {
+		return nil, fmt.Errorf("%w: %s 至 %s", ErrNoActivities, stats.From, stats.To)
+	}
+	if s.ai == nil {
+		return nil, aiservice.ErrNoProvider
+	}
+	ctx = usage.WithUser(ctx, accountID) // 用量和限额记在账号名下
+
+	tmpl, err := s.prompts.Lookup(sport.Unknown, prompt.TaskReview, opts.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	// 超出 token 预算时省略活动明细，只保留汇总
+	input := format(stats)
+	content, err := tmpl.Render(input)
+	if err != nil {
+		return nil, err
+	}
+	compaction := ""
+	estimator, budget := s.ai.InputBudget(aiservice.TaskReview)
+	if estimator.Messages([]client.ChatMessage{{Role: "user", Content: content}}) > budget {
+		input.SessionsOmitted, input.Sessions = len(input.Sessions), nil
+		if content, err = tmpl.Render(input); err != nil {
+			return nil, err
+		}
+		compaction = fmt.Sprintf("提示词超出 token 预算，省略了 %d 次活动的明细", input.SessionsOmitted)
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("序列化统计数据失败: %v", err)
+	}
+	hash := sha256.Sum256(data)
+	inputHash := hex.EncodeToString(hash[:])
+	// 不同账号的统计数据可能相同，缓存按账号区分
+	reviewID := strings.Join([]string{"review", accountID, stats.Period, stats.From}, "-")
+
+	if !opts.Refresh {
+		if cached, err := s.reports.Find(reviewID, inputHash, tmpl.ID, s.ai.Models()); err == nil {
+			cached.Cached = true
+			return &Review{Stats: stats, Report: cached}, nil
+		}
+	}
+
+	response, err := s.ai.Complete(ctx, aiservice.TaskReview, []client.ChatMessage{{Role: "user", Content: content}})
+	if err != nil {
+		return nil, fmt.Errorf("AI回顾失败: %w", err)
+	}
+	r := &report.Report{
+		ActivityID:    reviewID,
+		InputHash:     inputHash,
+		PromptVersion: tmpl.ID,
+		Provider:      response.Provider,
+		Model:         response.Model,
+		Content:       response.Content,
+		Format:        report.FormatMarkdown,
+		Compaction:    compaction,
+	}
+	saved, err := s.reports.Save(r)
+	if err != nil {
+		log.Printf("保存周期回顾失败: %v", err)
+		saved = r
+	}
+	return &Review{Stats: stats, Report: saved}, nil
+}
+
+// format 把统计数据换算为模板输入
+func format(s *Stats) prompt.BlockReview {
+	r := prompt.BlockReview{
+		Period:       s.Period,
+		From:         s.From,
+		To:           s.To,
+		InProgress:   s.InProgress,
+		Volume:       formatVolume(s.Volume),
+		Previous:     formatVolume(s.Previous),
+		Changes:      prompt.ReviewChanges{Distance: change(s.Distance, s.Previous.Distance), MovingTime: change(s.MovingTime, s.Previous.MovingTime), Load: change(s.Load, s.Previous.Load)},
+		Days:         s.Consistency.Days,
+		ActiveDays:   s.Consistency.ActiveDays,
+		LongestBreak: s.Consistency.LongestBreak,
+	}
+	for _, v := range s.BySport {
+		sv := prompt.ReviewSport{Family: string(v.Family), Name: v.Family.Name(), Sessions: v.Count, Distance: units.Distance(v.Distance), MovingTime: units.Duration(v.MovingTime)}
+		if s.MovingTime > 0 {
+			sv.Share = units.Percent(v.MovingTime / s.MovingTime)
+		}
+		r.BySport = append(r.BySport, sv)
+	}
+
+	if len(s.Zones) > 0 {
+		r.MaxHR = units.HeartRate(float64(s.MaxHR))
+		total := 0.0
+		for _, seconds := range s.Zones {
+			total += seconds
+		}
+		for i, seconds := range s.Zones {
+			z := prompt.ReviewZone{Name: fmt.Sprintf("Z%d", i+1), Range: zoneRange(i, s.MaxHR), Time: units.Duration(seconds)}
+			if total > 0 {
+				z.Share = units.Percent(seconds / total)
+			}
+			r.Zones = append(r.Zones, z)
+		}
+	}
+
+	if s.LongestRun != nil {
+		longest := formatSession(*s.LongestRun)
+		r.LongestRun = &longest
+	}
+	for _, week := range s.Weeks {
+		r.Weeks = append(r.Weeks, formatVolume(week))
+	}
+	for _, session := range s.Sessions {
+		r.Sessions = append(r.Sessions, formatSession(session))
+	}
+	return r
+}
+
+func formatVolume(v Volume) prompt.ReviewVolume {
+	return prompt.ReviewVolume{
+		From:       v.From,
+		To:         v.To,
+		Sessions:   v.Count,
+		Distance:   units.Distance(v.Distance),
+		MovingTime: units.Duration(v.MovingTime),
+		Ascent:     units.Elevation(v.Ascent),
+		Load:       fmt.Sprintf("%.0f", v.Load),
+	}
+}
+
+func formatSession(s Session) prompt.ReviewSession {
+	r := prompt.ReviewSession{
+		Date:       s.Date,
+		Sport:      string(s.Sport),
+		SportName:  s.Sport.Name(),
+		Distance:   units.Distance(s.Distance),
+		MovingTime: units.Duration(s.MovingTime),
+		AvgHR:      units.HeartRate(float64(s.AvgHR)),
+		Load:       fmt.Sprintf("%.0f", s.Load),
+	}
+	if family := s.Sport.Family(); (family == sport.FamilyRunning || family == sport.FamilyHiking) && s.Distance > 0 {
+		r.Pace = units.Pace(s.MovingTime / (s.Distance / 1000))
+	}
+	return r
+}
+
+// change 相对上一周期的变化，上一周期为 0 时为空
+func change(current, previous float64) string {
+	if previous <= 0 {
+		return ""
+	}
+	return units.Change(current/previous - 1)
+}
+
+// zoneRange 心率区间的范围，如 < 114 bpm、114-133 bpm、≥ 171 bpm
+func zoneRange(i, maxHR int) string {
+	bound := func(j int) int { return int(zoneBounds[j]*float64(maxHR) + 0.5) }
+	switch {
+	case i == 0:
+		return "< " + units.HeartRate(float64(bound(0)))
+	case i == len(zoneBounds):
+		return "≥ " + units.HeartRate(float64(bound(i-1)))
+	default:
+		return fmt.Sprintf("%d-%s", bound(i-1), units.HeartRate(float64(bound(i)-1)))
+	}
+}
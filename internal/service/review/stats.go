@@ -0,0 +1,291 @@
+package review
+
+import (
+	"sort"
+	"time"
+
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/coros"
+	"fitgo/pkg/sport"
+)
+
+// zoneBounds Z1-Z4 的上限(占最大心率的比例)，Z5 为 90% 以上；TRIMP 权重为区间序号
+var zoneBounds = []float64{0.6, 0.7, 0.8, 0.9}
+
+// maxPointGap 相邻轨迹点的最大间隔(秒)，超过时视为暂停，只计入这么多
+const maxPointGap = 10
+
+// maxHRWindow 估算最大心率时回看的天数
+const maxHRWindow = 365
+
+// bounds 返回 date 所在周期的起止时间(本地时间，不含 to)
+func bounds(period string, date time.Time) (time.Time, time.Time) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	if period == PeriodMonth {
+		from := day.AddDate(0, 0, 1-day.Day())
+		return from, from.AddDate(0, 1, 0)
+	}
+	from := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	return from, from.AddDate(0, 0, 7)
+}
+
+// previous 返回上一周期的起止时间
+func previous(period string, from time.Time) (time.Time, time.Time) {
+	if period == PeriodMonth {
+		return from.AddDate(0, -1, 0), from
+	}
+	return from.AddDate(0, 0, -7), from
+}
+
+// weeks 把月份按周一划分为若干周，首尾两周截断在月份内
+func weeks(from, to time.Time) [][2]time.Time {
+	var result [][2]time.Time
+	for start := from; start.Before(to); {
+		end := start.AddDate(0, 0, 7-(int(start.Weekday())+6)%7)
+		if end.After(to) {
+			end = to
+		}
+		result = append(result, [2]time.Time{start, end})
+		start = end
+	}
+	return result
+}
+
+// collector 计算一个账号的统计数据：活动摘要来自 List，心率区间需要 Get 读取轨迹点
+type collector struct {
+	activities activity.ActivityService
+	summaries  []*activity.Activity
+	maxHR      int
+}
+
+// newCollector 只统计 accountID 导入的活动，其他账号和手动上传的活动不参与
+func newCollector(activities activity.ActivityService, accountID string, to time.Time) (*collector, error) {
+	all, err := activities.List()
+	if err != nil {
+		return nil, err
+	}
+	var summaries []*activity.Activity
+	for _, act := range all {
+		if act.Source.AccountID == accountID {
+			summaries = append(summaries, act)
+		}
+	}
+	c := &collector{activities: activities, summaries: summaries}
+
+	since := to.AddDate(0, 0, -maxHRWindow)
+	for _, act := range summaries {
+		if start, ok := startTime(act); ok && !start.Before(since) && start.Before(to) && act.MaxHR > c.maxHR {
+			c.maxHR = act.MaxHR
+		}
+	}
+	return c, nil
+}
+
+// sessions 返回 [from, to) 内的活动(按时间正序)及各自在心率区间的时间
+func (c *collector) sessions(from, to time.Time) ([]Session, [][]float64, []*activity.Activity, error) {
+	var selected []*activity.Activity
+	for _, act := range c.summaries {
+		if start, ok := startTime(act); ok && !start.Before(from) && start.Before(to) {
+			selected = append(selected, act)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].StartTime < selected[j].StartTime })
+
+	sessions := make([]Session, 0, len(selected))
+	zones := make([][]float64, 0, len(selected))
+	for _, summary := range selected {
+		act, err := c.activities.Get(summary.ID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		z := zoneTimes(act, c.maxHR)
+		start, _ := startTime(act)
+		sessions = append(sessions, Session{
+			ActivityID: act.ID,
+			Date:       start.Format(coros.DateLayout),
+			Sport:      act.Sport,
+			Distance:   act.Distance,
+			MovingTime: act.MovingTime,
+			AvgHR:      act.AvgHR,
+			Load:       trimp(z),
+		})
+		zones = append(zones, z)
+	}
+	return sessions, zones, selected, nil
+}
+
+// volume 汇总活动的训练量
+func volume(from, to time.Time, sessions []Session, summaries []*activity.Activity) Volume {
+	v := Volume{From: from.Format(coros.DateLayout), To: to.AddDate(0, 0, -1).Format(coros.DateLayout), Count: len(sessions)}
+	for i, s := range sessions {
+		v.Distance += s.Distance
+		v.MovingTime += s.MovingTime
+		v.Load += s.Load
+		v.Ascent += summaries[i].Ascent
+	}
+	return v
+}
+
+// stats 计算 [from, to) 的统计数据，now 用于判断周期是否进行中
+func (c *collector) stats(period string, from, to, now time.Time) (*Stats, error) {
+	sessions, zones, summaries, err := c.sessions(from, to)
+	if err != nil {
+		return nil, err
+	}
+	s := &Stats{
+		Period:   period,
+		Volume:   volume(from, to, sessions, summaries),
+		MaxHR:    c.maxHR,
+		Sessions: sessions,
+	}
+
+	// 按运动大类汇总，累计心率区间时间，找出最长的一次跑步
+	families := map[sport.Family]*SportVolume{}
+	total := make([]float64, len(zoneBounds)+1)
+	hasZones := false
+	for i, session := range sessions {
+		family := session.Sport.Family()
+		if families[family] == nil {
+			families[family] = &SportVolume{Family: family}
+		}
+		families[family].Count++
+		families[family].Distance += session.Distance
+		families[family].MovingTime += session.MovingTime
+
+		for z, seconds := range zones[i] {
+			total[z] += seconds
+			hasZones = true
+		}
+		if family == sport.FamilyRunning && (s.LongestRun == nil || session.Distance > s.LongestRun.Distance) {
+			longest := session
+			s.LongestRun = &longest
+		}
+	}
+	for _, v := range families {
+		s.BySport = append(s.BySport, *v)
+	}
+	sort.Slice(s.BySport, func(i, j int) bool { return s.BySport[i].MovingTime > s.BySport[j].MovingTime })
+	if hasZones {
+		s.Zones = total
+	}
+
+	// 连续性只统计已经过去的天数
+	end := to
+	if today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1); today.Before(to) {
+		end = today
+		s.InProgress = true
+	}
+	s.Consistency = consistency(from, end, sessions)
+
+	if period == PeriodMonth {
+		for _, week := range weeks(from, to) {
+			var inWeek []Session
+			var weekSummaries []*activity.Activity
+			for i, session := range sessions {
+				start, _ := startTime(summaries[i])
+				if !start.Before(week[0]) && start.Before(week[1]) {
+					inWeek = append(inWeek, session)
+					weekSummaries = append(weekSummaries, summaries[i])
+				}
+			}
+			s.Weeks = append(s.Weeks, volume(week[0], week[1], inWeek, weekSummaries))
+		}
+	}
+
+	prevFrom, prevTo := previous(period, from)
+	prevSessions, _, prevSummaries, err := c.sessions(prevFrom, prevTo)
+	if err != nil {
+		return nil, err
+	}
+	s.Previous = volume(prevFrom, prevTo, prevSessions, prevSummaries)
+	return s, nil
+}
+
+// consistency 统计 [from, end) 内有训练的天数和最长连续休息天数
+func consistency(from, end time.Time, sessions []Session) Consistency {
+	active := map[string]bool{}
+	for _, s := range sessions {
+		active[s.Date] = true
+	}
+	c := Consistency{}
+	rest := 0
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		c.Days++
+		if active[day.Format(coros.DateLayout)] {
+			c.ActiveDays++
+			rest = 0
+			continue
+		}
+		rest++
+		if rest > c.LongestBreak {
+			c.LongestBreak = rest
+		}
+	}
+	return c
+}
+
+// zoneTimes 活动在各心率区间的时间(秒)。有逐点心率时按轨迹点累计，
+// 只有平均心率时整个运动时间计入平均心率所在的区间；没有心率或最大心率时返回 nil
+func zoneTimes(act *activity.Activity, maxHR int) []float64 {
+	if maxHR <= 0 {
+		return nil
+	}
+	zones := make([]float64, len(zoneBounds)+1)
+	counted := 0.0
+	var last time.Time
+	lastHR := 0
+	for _, p := range act.Points {
+		t, err := time.Parse(time.RFC3339, p.Time)
+		if err != nil {
+			continue
+		}
+		if lastHR > 0 && !last.IsZero() {
+			gap := t.Sub(last).Seconds()
+			if gap > maxPointGap {
+				gap = maxPointGap
+			}
+			if gap > 0 {
+				zones[zone(lastHR, maxHR)] += gap
+				counted += gap
+			}
+		}
+		last, lastHR = t, p.HeartRate
+	}
+	if counted > 0 {
+		return zones
+	}
+	if act.AvgHR > 0 && act.MovingTime > 0 {
+		zones[zone(act.AvgHR, maxHR)] = act.MovingTime
+		return zones
+	}
+	return nil
+}
+
+// zone 心率所在区间的下标(0 为 Z1)
+func zone(hr, maxHR int) int {
+	ratio := float64(hr) / float64(maxHR)
+	for i, bound := range zoneBounds {
+		if ratio < bound {
+			return i
+		}
+	}
+	return len(zoneBounds)
+}
+
+// trimp Edwards TRIMP：各区间的分钟数乘以区间序号
+func trimp(zones []float64) float64 {
+	load := 0.0
+	for i, seconds := range zones {
+		load += seconds / 60 * float64(i+1)
+	}
+	return load
+}
+
+// startTime 活动开始的本地时间
+func startTime(act *activity.Activity) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, act.StartTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t.Local(), true
+}
@@ -1,9 +1,12 @@
 package tcx
 
 import (
+	"fitgo/internal/service/activity"
+	"fitgo/pkg/sport"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"time"
 )
 
 // TCXService 定义了处理TCX文件的服务接口
@@ -20,45 +23,31 @@ type TCXService interface {
 
 // TCXSummary 表示TCX文件的摘要信息
 type TCXSummary struct {
-	ID          string  `json:"id"`
-	Filename    string  `json:"filename"`
-	Duration    int     `json:"duration"`     // 活动持续时间（秒）
-	Distance    float64 `json:"distance"`     // 总距离（米）
-	Calories    float64 `json:"calories"`     // 消耗卡路里
-	StartTime   string  `json:"start_time"`   // 开始时间
-	EndTime     string  `json:"end_time"`     // 结束时间
-	SportType   string  `json:"sport_type"`   // 运动类型
-	AverageHR   int     `json:"average_hr"`   // 平均心率
-	MaxHR       int     `json:"max_hr"`       // 最大心率
-	TotalAscent float64 `json:"total_ascent"` // 总爬升高度
-	CreatedAt   string  `json:"created_at"`
+	ID          string      `json:"id"`
+	Filename    string      `json:"filename"`
+	Duration    int         `json:"duration"`     // 活动持续时间（秒）
+	Distance    float64     `json:"distance"`     // 总距离（米）
+	Calories    float64     `json:"calories"`     // 消耗卡路里
+	StartTime   string      `json:"start_time"`   // 开始时间
+	EndTime     string      `json:"end_time"`     // 结束时间
+	Sport       sport.Sport `json:"sport"`        // 运动类型
+	AverageHR   int         `json:"average_hr"`   // 平均心率
+	MaxHR       int         `json:"max_hr"`       // 最大心率
+	TotalAscent float64     `json:"total_ascent"` // 总爬升高度
+	CreatedAt   string      `json:"created_at"`
 }
 
 // ParseTCX 解析TCX文件内容并提取摘要信息
 func ParseTCX(content []byte) (*TCXSummary, error) {
-	// TODO: 实现TCX文件解析逻辑
-	// 这里应该解析XML格式的TCX文件并提取相关信息
-
-	// 示例返回一个空的摘要结构
-	summary := &TCXSummary{
-		ID:        "example-id",
-		Filename:  "example.tcx",
-		Duration:  3600,
-		Distance:  10000.0,
-		Calories:  500.0,
-		SportType: "Running",
-		AverageHR: 140,
-		MaxHR:     180,
+	a, err := activity.ParseTCX(content)
+	if err != nil {
+		return nil, err
 	}
-
-	return summary, nil
+	return toSummary(a), nil
 }
 
 // ValidateTCX 验证TCX文件格式是否正确
 func ValidateTCX(file multipart.File) error {
-	// TODO: 实现TCX文件验证逻辑
-	// 检查文件是否为有效的XML格式以及是否符合TCX规范
-
 	// 重置文件指针到开头
 	if _, err := file.Seek(0, 0); err != nil {
 		return fmt.Errorf("failed to reset file pointer: %w", err)
@@ -70,12 +59,33 @@ func ValidateTCX(file multipart.File) error {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
-	// 简单检查是否包含TCX基本元素
 	if len(content) == 0 {
 		return fmt.Errorf("empty file")
 	}
-
-	// TODO: 更详细的XML和TCX格式验证
+	if activity.DetectFormat("", content) != activity.FormatTCX {
+		return fmt.Errorf("missing TrainingCenterDatabase element")
+	}
 
 	return nil
 }
+
+// toSummary 由导入的活动生成摘要，结束时间为开始时间加总时间
+func toSummary(a *activity.Activity) *TCXSummary {
+	summary := &TCXSummary{
+		ID:          a.ID,
+		Filename:    a.Filename,
+		Duration:    int(a.Duration),
+		Distance:    a.Distance,
+		Calories:    float64(a.Calories),
+		StartTime:   a.StartTime,
+		Sport:       a.Sport,
+		AverageHR:   a.AvgHR,
+		MaxHR:       a.MaxHR,
+		TotalAscent: a.Ascent,
+		CreatedAt:   a.CreatedAt,
+	}
+	if start, err := time.Parse(time.RFC3339, a.StartTime); err == nil {
+		summary.EndTime = start.Add(time.Duration(a.Duration * float64(time.Second))).Format(time.RFC3339)
+	}
+	return summary
+}
@@ -1,18 +1,20 @@
 package tcx
 
 import (
+	"fitgo/internal/service/activity"
 	"fmt"
+	"io"
 	"mime/multipart"
 )
 
-// tcxService 是TCXService接口的具体实现
+// tcxService 是TCXService接口的具体实现，文件经由活动导入流程解析并保存
 type tcxService struct {
-	// 可以添加数据库连接、缓存等依赖
+	activities activity.ActivityService
 }
 
 // NewTCXService 创建一个新的TCX服务实例
-func NewTCXService() TCXService {
-	return &tcxService{}
+func NewTCXService(activities activity.ActivityService) TCXService {
+	return &tcxService{activities: activities}
 }
 
 // UploadTCX 实现TCXService接口的UploadTCX方法
@@ -28,43 +30,44 @@ func (s *tcxService) UploadTCX(file multipart.File, filename string) (*TCXSummar
 	}
 
 	// 读取文件内容
-	// content, err := io.ReadAll(file)
-	// if err != nil {
-	// 	return nil, fmt.Errorf("failed to read file: %w", err)
-	// }
-
-	// 解析TCX文件
-	// summary, err := ParseTCX(content)
-	// if err != nil {
-	// 	return nil, fmt.Errorf("failed to parse TCX file: %w", err)
-	// }
-
-	// TODO: 保存文件到存储系统
-	// TODO: 保存摘要信息到数据库
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
 
-	// 临时返回示例数据
-	summary := &TCXSummary{
-		ID:        "temp-id",
-		Filename:  filename,
-		Duration:  3600,
-		Distance:  5000.0,
-		Calories:  300.0,
-		SportType: "Running",
-		AverageHR: 140,
-		MaxHR:     180,
+	// 解析并保存
+	a, err := s.activities.Ingest(filename, content, activity.Source{Name: activity.SourceUpload})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TCX file: %w", err)
 	}
 
-	return summary, nil
+	return toSummary(a), nil
 }
 
 // GetTCXSummary 实现TCXService接口的GetTCXSummary方法
 func (s *tcxService) GetTCXSummary(id string) (*TCXSummary, error) {
-	// TODO: 从数据库中获取指定ID的TCX摘要信息
-	return nil, fmt.Errorf("not implemented")
+	a, err := s.activities.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if a.Format != activity.FormatTCX {
+		return nil, fmt.Errorf("%w: %s", activity.ErrActivityNotFound, id)
+	}
+	return toSummary(a), nil
 }
 
 // ListTCXSummaries 实现TCXService接口的ListTCXSummaries方法
 func (s *tcxService) ListTCXSummaries() ([]*TCXSummary, error) {
-	// TODO: 从数据库中获取所有TCX摘要信息
-	return []*TCXSummary{}, nil
+	activities, err := s.activities.List()
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []*TCXSummary{}
+	for _, a := range activities {
+		if a.Format == activity.FormatTCX {
+			summaries = append(summaries, toSummary(a))
+		}
+	}
+	return summaries, nil
 }
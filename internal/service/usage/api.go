@@ -0,0 +1,84 @@
+package usage
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSpendCapExceeded 今天的 AI 花费已达到配置的上限
+var ErrSpendCapExceeded = errors.New("已达到今日 AI 花费上限")
+
+// ErrInvalidGroupBy 不支持的分组方式
+var ErrInvalidGroupBy = errors.New("groupBy 必须是 day、model 或 user")
+
+// ErrInvalidRange 汇总的日期区间不合法
+var ErrInvalidRange = errors.New("日期区间不合法")
+
+// 汇总的分组方式
+const (
+	GroupByDay   = "day"
+	GroupByModel = "model"
+	GroupByUser  = "user"
+)
+
+// UsageService 定义了 AI 用量的记录、汇总和限额接口。
+// 每次模型调用(包括失败和回退前的尝试)都记录一条，费用按配置的价格表计算
+type UsageService interface {
+	// Allow 今天的总花费或 ctx 中用户的花费达到上限时返回 ErrSpendCapExceeded
+	Allow(ctx context.Context) error
+
+	// Record 保存一次调用的用量，补齐时间、用户(来自 ctx)和费用
+	Record(ctx context.Context, r *Record)
+
+	// Summary 按 groupBy 汇总 from 到 to(YYYY-MM-DD，含两端)的用量，为空时默认最近 30 天
+	Summary(groupBy, from, to string) (*Summary, error)
+}
+
+// Record 一次模型调用的用量
+type Record struct {
+	Time             string  `json:"time"`
+	User             string  `json:"user,omitempty"`
+	Task             string  `json:"task"`
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Estimated        bool    `json:"estimated,omitempty"` // 提供者没有返回用量，token 数为估算值
+	LatencyMs        int64   `json:"latency_ms"`
+	Cost             float64 `json:"cost"`
+	Error            string  `json:"error,omitempty"`
+}
+
+// Summary 用量汇总
+type Summary struct {
+	GroupBy  string   `json:"group_by"`
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Currency string   `json:"currency,omitempty"`
+	Groups   []*Group `json:"groups"` // 按分组键升序
+	Total    *Group   `json:"total"`
+}
+
+// Group 一个分组的用量合计
+type Group struct {
+	Key              string  `json:"key"`
+	Requests         int     `json:"requests"`
+	Errors           int     `json:"errors"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+	AvgLatencyMs     int64   `json:"avg_latency_ms"`
+}
+
+type userKey struct{}
+
+// WithUser 返回带有用户标识的 ctx，用于按用户统计和限额
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFrom 返回 ctx 中的用户标识，没有时为空
+func UserFrom(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
@@ -0,0 +1,242 @@
+package usage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"fitgo/pkg/config"
+)
+
+// 汇总的日期区间
+const (
+	defaultDays = 30
+	maxDays     = 366
+	dateLayout  = "2006-01-02"
+)
+
+// anonymous 没有用户标识的调用在按用户汇总时的分组键
+const anonymous = "-"
+
+// usageService 是UsageService接口的具体实现。用量按本地日期追加写入 usage/<YYYY-MM-DD>.jsonl，
+// 当天的花费缓存在内存中用于限额，日期变化后重新从文件加载
+type usageService struct {
+	dir string
+	cfg config.UsageConfig
+
+	mu    sync.Mutex
+	day   string             // 缓存的日期
+	total float64            // 当天所有用户的花费
+	users map[string]float64 // 当天每个用户的花费
+}
+
+// NewUsageService 创建用量服务，数据保存在 dataDir/usage 下
+func NewUsageService(dataDir string, cfg config.UsageConfig) UsageService {
+	return &usageService{dir: filepath.Join(dataDir, "usage"), cfg: cfg}
+}
+
+func (s *usageService) Allow(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadToday()
+
+	if s.cfg.DailyCap > 0 && s.total >= s.cfg.DailyCap {
+		return fmt.Errorf("%w: 今日合计 %.4f %s，上限 %.4f", ErrSpendCapExceeded, s.total, s.cfg.Currency, s.cfg.DailyCap)
+	}
+	user := UserFrom(ctx)
+	if s.cfg.UserDailyCap > 0 && s.users[user] >= s.cfg.UserDailyCap {
+		return fmt.Errorf("%w: 用户 %s 今日 %.4f %s，上限 %.4f", ErrSpendCapExceeded, displayUser(user), s.users[user], s.cfg.Currency, s.cfg.UserDailyCap)
+	}
+	return nil
+}
+
+func (s *usageService) Record(ctx context.Context, r *Record) {
+	now := time.Now()
+	r.Time = now.Format(time.RFC3339)
+	if r.User == "" {
+		r.User = UserFrom(ctx)
+	}
+	r.Cost = s.cost(r.Model, r.PromptTokens, r.CompletionTokens)
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		log.Printf("序列化 AI 用量失败: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadToday()
+	s.total += r.Cost
+	s.users[r.User] += r.Cost
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		log.Printf("创建 AI 用量目录失败: %v", err)
+		return
+	}
+	f, err := os.OpenFile(s.path(now.Format(dateLayout)), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Printf("打开 AI 用量文件失败: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		log.Printf("写入 AI 用量失败: %v", err)
+	}
+}
+
+func (s *usageService) Summary(groupBy, from, to string) (*Summary, error) {
+	switch groupBy {
+	case GroupByDay, GroupByModel, GroupByUser:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidGroupBy, groupBy)
+	}
+
+	end := time.Now()
+	if to != "" {
+		t, err := time.ParseInLocation(dateLayout, to, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: to 应为 YYYY-MM-DD", ErrInvalidRange)
+		}
+		end = t
+	}
+	start := end.AddDate(0, 0, -(defaultDays - 1))
+	if from != "" {
+		t, err := time.ParseInLocation(dateLayout, from, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: from 应为 YYYY-MM-DD", ErrInvalidRange)
+		}
+		start = t
+	}
+	from, to = start.Format(dateLayout), end.Format(dateLayout)
+	if from > to {
+		return nil, fmt.Errorf("%w: from 晚于 to", ErrInvalidRange)
+	}
+	if end.Sub(start) > maxDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: 最长 %d 天", ErrInvalidRange, maxDays)
+	}
+
+	// 文件按行追加写入，不完整的行在读取时跳过，因此不需要持有锁，汇总不会阻塞 Allow 和 Record
+	groups := map[string]*Group{}
+	total := &Group{Key: "total"}
+	latency := map[*Group]int64{}
+	for day := start; day.Format(dateLayout) <= to; day = day.AddDate(0, 0, 1) {
+		records, err := s.read(day.Format(dateLayout))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			key := day.Format(dateLayout)
+			switch groupBy {
+			case GroupByModel:
+				key = r.Model
+			case GroupByUser:
+				key = displayUser(r.User)
+			}
+			g, ok := groups[key]
+			if !ok {
+				g = &Group{Key: key}
+				groups[key] = g
+			}
+			for _, g := range []*Group{g, total} {
+				g.Requests++
+				if r.Error != "" {
+					g.Errors++
+				}
+				g.PromptTokens += r.PromptTokens
+				g.CompletionTokens += r.CompletionTokens
+				g.Cost += r.Cost
+				latency[g] += r.LatencyMs
+			}
+		}
+	}
+
+	summary := &Summary{GroupBy: groupBy, From: from, To: to, Currency: s.cfg.Currency, Groups: []*Group{}, Total: total}
+	for _, g := range groups {
+		summary.Groups = append(summary.Groups, g)
+	}
+	sort.Slice(summary.Groups, func(i, j int) bool { return summary.Groups[i].Key < summary.Groups[j].Key })
+	for _, g := range append(summary.Groups, total) {
+		g.Cost = roundCost(g.Cost)
+		if g.Requests > 0 {
+			g.AvgLatencyMs = latency[g] / int64(g.Requests)
+		}
+	}
+	return summary, nil
+}
+
+// cost 按价格表计算费用，模型没有配置价格时使用 "*"，都没有时为 0
+func (s *usageService) cost(model string, promptTokens, completionTokens int) float64 {
+	price, ok := s.cfg.Prices[model]
+	if !ok {
+		price = s.cfg.Prices["*"]
+	}
+	return roundCost((float64(promptTokens)*price.Input + float64(completionTokens)*price.Output) / 1e6)
+}
+
+// loadToday 日期变化后从当天的文件重新统计花费，调用方需持有锁
+func (s *usageService) loadToday() {
+	today := time.Now().Format(dateLayout)
+	if s.day == today {
+		return
+	}
+	s.day, s.total, s.users = today, 0, map[string]float64{}
+	records, err := s.read(today)
+	if err != nil {
+		log.Printf("读取 AI 用量失败: %v", err)
+		return
+	}
+	for _, r := range records {
+		s.total += r.Cost
+		s.users[r.User] += r.Cost
+	}
+}
+
+// read 读取某一天的用量，跳过无法解析的行(包括正在写入的行)
+func (s *usageService) read(day string) ([]*Record, error) {
+	f, err := os.Open(s.path(day))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 AI 用量失败: %v", err)
+	}
+	defer f.Close()
+
+	var records []*Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		records = append(records, &r)
+	}
+	return records, scanner.Err()
+}
+
+func (s *usageService) path(day string) string {
+	return filepath.Join(s.dir, day+".jsonl")
+}
+
+func displayUser(user string) string {
+	if user == "" {
+		return anonymous
+	}
+	return user
+}
+
+// roundCost 费用保留 6 位小数
+func roundCost(cost float64) float64 {
+	return math.Round(cost*1e6) / 1e6
+}
@@ -0,0 +1,158 @@
+package workout
+
+import (
+	"fmt"
+
+	"fitgo/pkg/sport"
+)
+
+// WorkoutService 定义了管理结构化训练课的服务接口
+type WorkoutService interface {
+	// Create 校验并保存训练课
+	Create(w *Workout) (*Workout, error)
+
+	// Get 根据ID获取训练课
+	Get(id string) (*Workout, error)
+
+	// List 列出所有训练课
+	List() ([]*Workout, error)
+
+	// Delete 删除训练课
+	Delete(id string) error
+
+	// ExportTCX 导出为 TCX Workouts 文档
+	ExportTCX(id string) ([]byte, error)
+
+	// ExportFIT 导出为 FIT workout 文件
+	ExportFIT(id string) ([]byte, error)
+}
+
+// StepType 训练步骤类型
+type StepType string
+
+const (
+	StepWarmup   StepType = "warmup"
+	StepActive   StepType = "active"
+	StepRecovery StepType = "recovery"
+	StepRest     StepType = "rest"
+	StepCooldown StepType = "cooldown"
+	StepRepeat   StepType = "repeat" // 重复块，包含子步骤
+)
+
+// DurationType 步骤结束条件
+type DurationType string
+
+const (
+	DurationTime     DurationType = "time"     // Value 为秒
+	DurationDistance DurationType = "distance" // Value 为米
+	DurationOpen     DurationType = "open"     // 手动按圈结束
+)
+
+// TargetType 步骤强度目标
+type TargetType string
+
+const (
+	TargetNone      TargetType = "none"
+	TargetPace      TargetType = "pace"       // 秒/公里，Low 为较快配速(数值较小)
+	TargetHeartRate TargetType = "heart_rate" // bpm
+	TargetPower     TargetType = "power"      // W
+	TargetCadence   TargetType = "cadence"    // 步/分钟 或 转/分钟
+)
+
+// Duration 步骤时长或距离
+type Duration struct {
+	Type  DurationType `json:"type"`
+	Value float64      `json:"value,omitempty"`
+}
+
+// Target 步骤目标区间
+type Target struct {
+	Type TargetType `json:"type"`
+	Low  float64    `json:"low,omitempty"`
+	High float64    `json:"high,omitempty"`
+}
+
+// Step 训练步骤，Type 为 repeat 时使用 Repeat 与 Steps，其余使用 Duration 与 Target
+type Step struct {
+	Type     StepType `json:"type"`
+	Name     string   `json:"name,omitempty"`
+	Duration Duration `json:"duration"`
+	Target   Target   `json:"target"`
+	Repeat   int      `json:"repeat,omitempty"`
+	Steps    []Step   `json:"steps,omitempty"`
+}
+
+// Workout 结构化训练课
+type Workout struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Sport       sport.Sport `json:"sport"`
+	Description string      `json:"description,omitempty"`
+	Steps       []Step      `json:"steps"`
+	CreatedAt   string      `json:"created_at"`
+}
+
+// Validate 校验训练课定义，重复块最多嵌套一层
+func (w *Workout) Validate() error {
+	if w.Name == "" {
+		return fmt.Errorf("name 不能为空")
+	}
+	if !w.Sport.Valid() {
+		return fmt.Errorf("不支持的运动类型: %q", w.Sport)
+	}
+	if len(w.Steps) == 0 {
+		return fmt.Errorf("steps 不能为空")
+	}
+	for i := range w.Steps {
+		if err := w.Steps[i].validate(fmt.Sprintf("steps[%d]", i), true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Step) validate(path string, allowRepeat bool) error {
+	switch s.Type {
+	case StepRepeat:
+		if !allowRepeat {
+			return fmt.Errorf("%s: 重复块不能嵌套", path)
+		}
+		if s.Repeat < 2 {
+			return fmt.Errorf("%s: repeat 至少为 2", path)
+		}
+		if len(s.Steps) == 0 {
+			return fmt.Errorf("%s: 重复块需要子步骤", path)
+		}
+		for i := range s.Steps {
+			if err := s.Steps[i].validate(fmt.Sprintf("%s.steps[%d]", path, i), false); err != nil {
+				return err
+			}
+		}
+		return nil
+	case StepWarmup, StepActive, StepRecovery, StepRest, StepCooldown:
+	default:
+		return fmt.Errorf("%s: 不支持的步骤类型 %q", path, s.Type)
+	}
+
+	switch s.Duration.Type {
+	case DurationTime, DurationDistance:
+		if s.Duration.Value <= 0 {
+			return fmt.Errorf("%s: duration.value 必须大于 0", path)
+		}
+	case DurationOpen:
+	default:
+		return fmt.Errorf("%s: 不支持的时长类型 %q", path, s.Duration.Type)
+	}
+
+	switch s.Target.Type {
+	case "", TargetNone:
+		s.Target.Type = TargetNone
+	case TargetPace, TargetHeartRate, TargetPower, TargetCadence:
+		if s.Target.Low <= 0 || s.Target.High < s.Target.Low {
+			return fmt.Errorf("%s: target 需要 0 < low <= high", path)
+		}
+	default:
+		return fmt.Errorf("%s: 不支持的目标类型 %q", path, s.Target.Type)
+	}
+	return nil
+}
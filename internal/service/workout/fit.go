@@ -0,0 +1,163 @@
+package workout
+
+import (
+	"time"
+
+	"fitgo/pkg/fit"
+)
+
+// FIT workout_step 的枚举取值
+const (
+	fitDurationTime     byte = 0
+	fitDurationDistance byte = 1
+	fitDurationOpen     byte = 5
+	fitDurationRepeat   byte = 6 // repeat_until_steps_cmplt
+
+	fitTargetSpeed     byte = 0
+	fitTargetHeartRate byte = 1
+	fitTargetOpen      byte = 2
+	fitTargetCadence   byte = 3
+	fitTargetPower     byte = 4
+
+	fitIntensityActive   byte = 0
+	fitIntensityRest     byte = 1
+	fitIntensityWarmup   byte = 2
+	fitIntensityCooldown byte = 3
+	fitIntensityRecovery byte = 4
+)
+
+// fitStep 展开后的单个 FIT 步骤
+type fitStep struct {
+	name          string
+	durationType  byte
+	durationValue uint32
+	targetType    byte
+	targetLow     uint32
+	targetHigh    uint32
+	repeatCount   uint32
+	intensity     byte
+}
+
+// EncodeFIT 将训练课编码为 FIT workout 文件。
+// 重复块展开为子步骤加一个 repeat_until_steps_cmplt 步骤
+func EncodeFIT(w *Workout) ([]byte, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+
+	steps := flattenFIT(w.Steps)
+	enc := fit.NewEncoder()
+
+	if err := enc.Write(0, fit.MesgFileID, []fit.Field{
+		{Num: 0, Type: fit.Enum, Value: fit.FileTypeWorkout},
+		{Num: 1, Type: fit.Uint16, Value: fit.ManufacturerDevelopment},
+		{Num: 2, Type: fit.Uint16, Value: uint16(0)},
+		{Num: 4, Type: fit.Uint32, Value: fit.Timestamp(time.Now())},
+	}); err != nil {
+		return nil, err
+	}
+
+	fitSport, fitSub := w.Sport.FIT()
+	if err := enc.Write(1, fit.MesgWorkout, []fit.Field{
+		{Num: 4, Type: fit.Enum, Value: fitSport},
+		{Num: 11, Type: fit.Enum, Value: fitSub},
+		{Num: 6, Type: fit.Uint16, Value: uint16(len(steps))},
+		{Num: 8, Type: fit.String, Size: 32, Value: w.Name},
+	}); err != nil {
+		return nil, err
+	}
+
+	for i, step := range steps {
+		var targetValue interface{} = uint32(0)
+		if step.durationType == fitDurationRepeat {
+			targetValue = step.repeatCount
+		}
+		if err := enc.Write(2, fit.MesgWorkoutStep, []fit.Field{
+			{Num: 254, Type: fit.Uint16, Value: uint16(i)},
+			{Num: 0, Type: fit.String, Size: 16, Value: step.name},
+			{Num: 1, Type: fit.Enum, Value: step.durationType},
+			{Num: 2, Type: fit.Uint32, Value: step.durationValue},
+			{Num: 3, Type: fit.Enum, Value: step.targetType},
+			{Num: 4, Type: fit.Uint32, Value: targetValue},
+			{Num: 5, Type: fit.Uint32, Value: step.targetLow},
+			{Num: 6, Type: fit.Uint32, Value: step.targetHigh},
+			{Num: 7, Type: fit.Enum, Value: step.intensity},
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return enc.Bytes(), nil
+}
+
+func flattenFIT(steps []Step) []fitStep {
+	var result []fitStep
+	for _, step := range steps {
+		if step.Type != StepRepeat {
+			result = append(result, toFITStep(step))
+			continue
+		}
+
+		first := len(result)
+		for _, child := range step.Steps {
+			result = append(result, toFITStep(child))
+		}
+		result = append(result, fitStep{
+			durationType:  fitDurationRepeat,
+			durationValue: uint32(first),
+			targetType:    fitTargetOpen,
+			repeatCount:   uint32(step.Repeat),
+			intensity:     fitIntensityActive,
+		})
+	}
+	return result
+}
+
+func toFITStep(step Step) fitStep {
+	s := fitStep{name: step.Name, targetType: fitTargetOpen}
+
+	switch step.Duration.Type {
+	case DurationTime:
+		s.durationType = fitDurationTime
+		s.durationValue = uint32(step.Duration.Value * 1000) // 毫秒
+	case DurationDistance:
+		s.durationType = fitDurationDistance
+		s.durationValue = uint32(step.Duration.Value * 100) // 厘米
+	default:
+		s.durationType = fitDurationOpen
+	}
+
+	// 自定义目标区间的取值偏移见 FIT Profile: 心率 +100，功率 +1000，速度为 mm/s
+	switch step.Target.Type {
+	case TargetPace:
+		s.targetType = fitTargetSpeed
+		s.targetLow = uint32(1000 / step.Target.High * 1000)
+		s.targetHigh = uint32(1000 / step.Target.Low * 1000)
+	case TargetHeartRate:
+		s.targetType = fitTargetHeartRate
+		s.targetLow = uint32(step.Target.Low) + 100
+		s.targetHigh = uint32(step.Target.High) + 100
+	case TargetPower:
+		s.targetType = fitTargetPower
+		s.targetLow = uint32(step.Target.Low) + 1000
+		s.targetHigh = uint32(step.Target.High) + 1000
+	case TargetCadence:
+		s.targetType = fitTargetCadence
+		s.targetLow = uint32(step.Target.Low)
+		s.targetHigh = uint32(step.Target.High)
+	}
+
+	switch step.Type {
+	case StepWarmup:
+		s.intensity = fitIntensityWarmup
+	case StepCooldown:
+		s.intensity = fitIntensityCooldown
+	case StepRest:
+		s.intensity = fitIntensityRest
+	case StepRecovery:
+		s.intensity = fitIntensityRecovery
+	default:
+		s.intensity = fitIntensityActive
+	}
+	return s
+}
@@ -0,0 +1,135 @@
+package workout
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrWorkoutNotFound 训练课不存在
+var ErrWorkoutNotFound = errors.New("训练课不存在")
+
+// workoutService 是WorkoutService接口的具体实现，每个训练课保存为一个 JSON 文件
+type workoutService struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewWorkoutService 创建训练课服务，数据保存在 dataDir/workouts 下
+func NewWorkoutService(dataDir string) WorkoutService {
+	return &workoutService{dir: filepath.Join(dataDir, "workouts")}
+}
+
+func (s *workoutService) Create(w *Workout) (*Workout, error) {
+	saved := *w
+	if err := saved.Validate(); err != nil {
+		return nil, err
+	}
+	saved.ID = newWorkoutID()
+	saved.CreatedAt = time.Now().Format(time.RFC3339)
+
+	data, err := json.MarshalIndent(&saved, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化训练课失败: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建数据目录失败: %v", err)
+	}
+	if err := os.WriteFile(s.path(saved.ID), data, 0o644); err != nil {
+		return nil, fmt.Errorf("写入训练课失败: %v", err)
+	}
+	return &saved, nil
+}
+
+func (s *workoutService) Get(id string) (*Workout, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.read(s.path(id))
+}
+
+func (s *workoutService) List() ([]*Workout, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("列出训练课失败: %v", err)
+	}
+
+	workouts := make([]*Workout, 0, len(files))
+	for _, file := range files {
+		w, err := s.read(file)
+		if err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, w)
+	}
+	sort.Slice(workouts, func(i, j int) bool { return workouts[i].CreatedAt > workouts[j].CreatedAt })
+	return workouts, nil
+}
+
+func (s *workoutService) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrWorkoutNotFound, id)
+	}
+	return err
+}
+
+func (s *workoutService) ExportTCX(id string) ([]byte, error) {
+	w, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeTCX(w)
+}
+
+func (s *workoutService) ExportFIT(id string) ([]byte, error) {
+	w, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeFIT(w)
+}
+
+func (s *workoutService) path(id string) string {
+	return filepath.Join(s.dir, filepath.Base(id)+".json")
+}
+
+func (s *workoutService) read(path string) (*Workout, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrWorkoutNotFound, strings.TrimSuffix(filepath.Base(path), ".json"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取训练课失败: %v", err)
+	}
+
+	var w Workout
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, fmt.Errorf("解析训练课失败: %v", err)
+	}
+	return &w, nil
+}
+
+func newWorkoutID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
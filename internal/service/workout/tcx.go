@@ -0,0 +1,116 @@
+package workout
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+)
+
+// TCX 对名称长度有限制: Workout/Name 最多 15 个字符，Step/Name 最多 15 个字符
+const tcxNameLimit = 15
+
+// EncodeTCX 将训练课编码为 TCX Workouts 文档。
+// TCX 没有功率目标，功率目标的步骤导出为无目标
+func EncodeTCX(w *Workout) ([]byte, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` + "\n")
+	buf.WriteString("  <Workouts>\n")
+	fmt.Fprintf(&buf, "    <Workout Sport=%q>\n", w.Sport.TCX())
+	writeElement(&buf, 6, "Name", truncate(w.Name, tcxNameLimit))
+
+	stepID := 0
+	for _, step := range w.Steps {
+		writeTCXStep(&buf, 6, "Step", step, &stepID)
+	}
+	if stepID > 20 {
+		return nil, fmt.Errorf("TCX 最多支持 20 个步骤，当前 %d 个", stepID)
+	}
+
+	if w.Description != "" {
+		writeElement(&buf, 6, "Notes", w.Description)
+	}
+	buf.WriteString("    </Workout>\n")
+	buf.WriteString("  </Workouts>\n")
+	buf.WriteString("</TrainingCenterDatabase>\n")
+	return buf.Bytes(), nil
+}
+
+func writeTCXStep(buf *bytes.Buffer, indent int, tag string, step Step, stepID *int) {
+	*stepID++
+	pad := spaces(indent)
+
+	if step.Type == StepRepeat {
+		fmt.Fprintf(buf, "%s<%s xsi:type=\"Repeat_t\">\n", pad, tag)
+		writeElement(buf, indent+2, "StepId", strconv.Itoa(*stepID))
+		writeElement(buf, indent+2, "Repetitions", strconv.Itoa(step.Repeat))
+		for _, child := range step.Steps {
+			writeTCXStep(buf, indent+2, "Child", child, stepID)
+		}
+		fmt.Fprintf(buf, "%s</%s>\n", pad, tag)
+		return
+	}
+
+	fmt.Fprintf(buf, "%s<%s xsi:type=\"Step_t\">\n", pad, tag)
+	inner := spaces(indent + 2)
+	writeElement(buf, indent+2, "StepId", strconv.Itoa(*stepID))
+	if step.Name != "" {
+		writeElement(buf, indent+2, "Name", truncate(step.Name, tcxNameLimit))
+	}
+
+	switch step.Duration.Type {
+	case DurationTime:
+		fmt.Fprintf(buf, "%s<Duration xsi:type=\"Time_t\"><Seconds>%d</Seconds></Duration>\n", inner, int(step.Duration.Value))
+	case DurationDistance:
+		fmt.Fprintf(buf, "%s<Duration xsi:type=\"Distance_t\"><Meters>%d</Meters></Duration>\n", inner, int(step.Duration.Value))
+	default:
+		fmt.Fprintf(buf, "%s<Duration xsi:type=\"UserInitiated_t\"/>\n", inner)
+	}
+
+	intensity := "Active"
+	if step.Type == StepRest || step.Type == StepRecovery {
+		intensity = "Resting"
+	}
+	writeElement(buf, indent+2, "Intensity", intensity)
+
+	switch step.Target.Type {
+	case TargetPace:
+		// 配速区间转换为速度区间，较快配速对应较高速度
+		fmt.Fprintf(buf, "%s<Target xsi:type=\"Speed_t\"><SpeedZone xsi:type=\"CustomSpeedZone_t\"><ViewAs>Pace</ViewAs><LowInMetersPerSecond>%.3f</LowInMetersPerSecond><HighInMetersPerSecond>%.3f</HighInMetersPerSecond></SpeedZone></Target>\n",
+			inner, 1000/step.Target.High, 1000/step.Target.Low)
+	case TargetHeartRate:
+		fmt.Fprintf(buf, "%s<Target xsi:type=\"HeartRate_t\"><HeartRateZone xsi:type=\"CustomHeartRateZone_t\"><Low xsi:type=\"HeartRateInBeatsPerMinute_t\"><Value>%d</Value></Low><High xsi:type=\"HeartRateInBeatsPerMinute_t\"><Value>%d</Value></High></HeartRateZone></Target>\n",
+			inner, int(step.Target.Low), int(step.Target.High))
+	case TargetCadence:
+		fmt.Fprintf(buf, "%s<Target xsi:type=\"Cadence_t\"><Low>%d</Low><High>%d</High></Target>\n",
+			inner, int(step.Target.Low), int(step.Target.High))
+	default:
+		fmt.Fprintf(buf, "%s<Target xsi:type=\"None_t\"/>\n", inner)
+	}
+
+	fmt.Fprintf(buf, "%s</%s>\n", pad, tag)
+}
+
+func writeElement(buf *bytes.Buffer, indent int, tag, value string) {
+	buf.WriteString(spaces(indent))
+	buf.WriteString("<" + tag + ">")
+	xml.EscapeText(buf, []byte(value))
+	buf.WriteString("</" + tag + ">\n")
+}
+
+func spaces(n int) string {
+	return string(bytes.Repeat([]byte(" "), n))
+}
+
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,69 @@
+// Package breaker 实现简单的熔断器：连续失败达到阈值后熔断，冷却结束后放行一次试探请求。
+// 高驰接口和 AI 提供者共用。
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// Breaker 熔断器，可在多个 goroutine 间共享
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// New 创建熔断器，threshold 为负数时不熔断
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Threshold 返回熔断阈值(连续失败次数)
+func (b *Breaker) Threshold() int { return b.threshold }
+
+// Cooldown 返回熔断后的冷却时间
+func (b *Breaker) Cooldown() time.Duration { return b.cooldown }
+
+// Allow 返回是否放行本次请求，冷却结束后只放行一次试探请求
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold < 0 || b.failures < b.threshold {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// Release 请求被取消、结果不计入健康状态时释放试探名额
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// Record 记录一次请求结果，返回熔断器是否因此打开
+func (b *Breaker) Record(success bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if success {
+		b.failures = 0
+		return false
+	}
+	b.failures++
+	if b.threshold > 0 && b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+		return true
+	}
+	return false
+}
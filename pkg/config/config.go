@@ -2,16 +2,20 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+
+	"fitgo/pkg/secrets"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Server ServerConfig `json:"server"`
-	App    AppConfig    `json:"app"`
-	Coros  CorosConfig  `json:"coros"`
-	AI     AIConfig     `json:"ai"`
+	Server  ServerConfig  `json:"server"`
+	App     AppConfig     `json:"app"`
+	Coros   CorosConfig   `json:"coros"`
+	AI      AIConfig      `json:"ai"`
+	Storage StorageConfig `json:"storage"`
 }
 
 // ServerConfig represents the server configuration
@@ -26,23 +30,137 @@ type AppConfig struct {
 	Version string `json:"version"`
 }
 
-// CorosConfig represents the Coros service configuration
+// StorageConfig represents the local storage configuration
+type StorageConfig struct {
+	DataDir string `json:"data_dir"` // 本地数据目录，默认为 data
+}
+
+// Dir returns the data directory, falling back to "data" when unset
+func (c StorageConfig) Dir() string {
+	if c.DataDir == "" {
+		return "data"
+	}
+	return c.DataDir
+}
+
+// CorosConfig represents the Coros service configuration.
+// Username/Password 作为默认账号(ID 为 default)在启动时注册
 type CorosConfig struct {
-	Username int    `json:"username"`
-	Password string `json:"password"`
-	Address  string `json:"address"`
+	Username int             `json:"username"`
+	Password string          `json:"password"`
+	Address  string          `json:"address"`
+	HTTP     CorosHTTPConfig `json:"http"`
+}
+
+// CorosHTTPConfig represents the shared COROS HTTP transport configuration.
+// 零值表示使用默认值
+type CorosHTTPConfig struct {
+	Timeout          int     `json:"timeout"`           // 单次请求超时(秒)，默认 15
+	MaxRetries       int     `json:"max_retries"`       // 5xx/网络错误最大重试次数，默认 3，负数表示不重试
+	BackoffBase      int     `json:"backoff_base_ms"`   // 退避基础时长(毫秒)，默认 200
+	BackoffMax       int     `json:"backoff_max_ms"`    // 退避最大时长(毫秒)，默认 5000
+	RateLimit        float64 `json:"rate_limit"`        // 每秒请求数，默认 5
+	Burst            int     `json:"burst"`             // 令牌桶容量，默认 10
+	BreakerThreshold int     `json:"breaker_threshold"` // 连续失败多少次后熔断，0 表示不启用
+	BreakerCooldown  int     `json:"breaker_cooldown"`  // 熔断持续时间(秒)，默认 30
 }
 type AIConfig struct {
-	Provider string `json:"provider"` // 提供者，如 "qwen"
-	Config   struct {
-		BaseURL string `json:"base_url"` // API 基础地址
-		APIKey  string `json:"api_key"`  // API 密钥
-		Model   string `json:"model"`    // 模型名称
-		Timeout int    `json:"timeout"`  // 超时时间(秒)
-	} `json:"config"`
+	Provider string           `json:"provider"` // 提供者: qwen, gemini, openai-compatible, ollama, fake
+	Config   AIProviderConfig `json:"config"`
+
+	// Providers 多个提供者，按 priority 从小到大依次尝试；为空时只使用 Provider/Config
+	Providers []AIProviderEntry `json:"providers"`
+	// Routes 按任务类型选择提供者的规则，按顺序匹配第一条
+	Routes           []AIRouteConfig `json:"routes"`
+	BreakerThreshold int             `json:"breaker_threshold"` // 连续失败多少次后暂时跳过该提供者，默认 3，负数表示不启用
+	BreakerCooldown  int             `json:"breaker_cooldown"`  // 跳过的时长(秒)，默认 60
+
+	// MaxToolIterations 分析时模型最多调用几轮工具，默认 5
+	MaxToolIterations int `json:"max_tool_iterations"`
+	// MaxInputTokens 分析提示词的 token 预算(估算值)，超出时压缩分段数据，默认 6000；
+	// 提供者单独配置了 max_input_tokens 时以提供者的为准
+	MaxInputTokens int `json:"max_input_tokens"`
+
+	Prompts PromptConfig `json:"prompts"`
+	Chat    ChatConfig   `json:"chat"`
+	Usage   UsageConfig  `json:"usage"`
+}
+
+// UsageConfig AI 用量计费与限额，零值表示只记录 token 和耗时，不计费也不限额
+type UsageConfig struct {
+	Currency     string                `json:"currency"`       // 价格的货币单位，如 CNY，只用于显示
+	Prices       map[string]ModelPrice `json:"prices"`         // 按模型名称配置的价格，"*" 匹配其余模型
+	DailyCap     float64               `json:"daily_cap"`      // 每天所有用户合计的花费上限，0 表示不限制
+	UserDailyCap float64               `json:"user_daily_cap"` // 每个用户每天的花费上限，0 表示不限制
+	AdminToken   string                `json:"admin_token"`    // 访问 /admin/ai/usage 的令牌(Authorization: Bearer)，为空时拒绝访问
+}
+
+// ModelPrice 每百万 token 的价格
+type ModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// ChatConfig 活动问答的历史长度限制，零值表示使用默认值
+type ChatConfig struct {
+	MaxHistoryChars int `json:"max_history_chars"` // 发送给模型的历史超过该字符数时压缩，默认 12000
+	KeepRecent      int `json:"keep_recent"`       // 压缩时保留的最近消息条数，默认 6
+}
+
+// PromptConfig 提示词模板配置，零值表示只使用内置的中文模板
+type PromptConfig struct {
+	Dir       string `json:"dir"`        // 模板目录，其中的文件覆盖同名内置模板
+	Language  string `json:"language"`   // 默认语言，默认 zh
+	HotReload bool   `json:"hot_reload"` // 每次使用前检查模板目录是否变化，开发时使用
+}
+
+// AIProviderEntry 提供者链中的一项
+type AIProviderEntry struct {
+	Name     string           `json:"name"`     // 名称，用于路由规则和响应中标识提供者，默认与 provider 相同
+	Provider string           `json:"provider"` // 提供者类型，同 AIConfig.Provider
+	Priority int              `json:"priority"` // 优先级，越小越先尝试
+	Config   AIProviderConfig `json:"config"`
+}
+
+// AIRouteConfig 路由规则：任务匹配时先按顺序尝试 providers，全部失败再按优先级尝试其余提供者
+type AIRouteConfig struct {
+	Task          string   `json:"task"`            // 任务类型: summary, review, chat 等，* 匹配所有任务
+	MaxInputChars int      `json:"max_input_chars"` // 输入不超过该字符数时才匹配，0 表示不限制
+	Providers     []string `json:"providers"`       // 提供者名称
+}
+
+// AIProviderConfig 单个模型提供者的配置，采样参数未设置时使用提供者的默认值
+type AIProviderConfig struct {
+	BaseURL     string            `json:"base_url"`    // API 基础地址
+	APIKey      string            `json:"api_key"`     // API 密钥
+	Model       string            `json:"model"`       // 模型名称
+	Timeout     int               `json:"timeout"`     // 超时时间(秒)
+	Headers     map[string]string `json:"headers"`     // 附加的请求头，如网关鉴权
+	Temperature *float64          `json:"temperature"` // 采样温度
+	TopP        *float64          `json:"top_p"`       // 核采样
+	MaxTokens   int               `json:"max_tokens"`  // 最大输出 token 数，0 表示不限制
+
+	MaxInputTokens int `json:"max_input_tokens"` // 分析提示词的 token 预算，0 表示使用 ai.max_input_tokens
+
+	Fake *FakeConfig `json:"fake,omitempty"` // 只用于 fake 提供者
 }
 
-// LoadConfig loads the configuration from a JSON file
+// FakeConfig 内置 fake 提供者的行为，用于离线运行和测试，零值时返回带提示词摘要的固定回答
+type FakeConfig struct {
+	Replies    []string `json:"replies"`     // 按顺序返回的回答，用完后从头循环
+	Template   string   `json:"template"`    // 回答模板(text/template)，replies 为空时使用
+	EchoDigest bool     `json:"echo_digest"` // 在回答末尾附加提示词摘要，用于断言提示词是否变化
+
+	LatencyMs    int    `json:"latency_ms"`     // 每次调用返回前的延迟
+	ChunkDelayMs int    `json:"chunk_delay_ms"` // 流式输出每段之间的延迟
+	ChunkSize    int    `json:"chunk_size"`     // 流式输出每段的字符数，默认 8
+	FailEvery    int    `json:"fail_every"`     // 第 N、2N... 次调用失败，0 表示不失败
+	FailAfter    int    `json:"fail_after"`     // 流式调用失败前先输出的段数
+	Error        string `json:"error"`          // 失败时的错误信息
+}
+
+// LoadConfig loads the configuration from a JSON file and resolves
+// env://, file:// and secret:// references in its string values
 func LoadConfig(filepath string) (*Config, error) {
 	// Read the config file
 	data, err := os.ReadFile(filepath)
@@ -56,10 +174,16 @@ func LoadConfig(filepath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	resolver := secrets.NewResolver(VaultPath(filepath), secrets.KeyFromEnv())
+	if err := resolveSecrets(&config, resolver); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
 
-// LoadConfigWithDefaults loads the configuration with fallback paths
+// LoadConfigWithDefaults loads the configuration with fallback paths.
+// The fallback is only tried when the primary file does not exist
 func LoadConfigWithDefaults(primaryPath, fallbackPath string) (*Config, error) {
 	// Try primary path first
 	cfg, err := LoadConfig(primaryPath)
@@ -67,6 +191,12 @@ func LoadConfigWithDefaults(primaryPath, fallbackPath string) (*Config, error) {
 		return cfg, nil
 	}
 
+	// Other errors (invalid JSON, missing vault or key) are returned as is; a missing
+	// vault also wraps os.ErrNotExist, so check the primary file itself
+	if _, statErr := os.Stat(primaryPath); !errors.Is(statErr, os.ErrNotExist) {
+		return nil, err
+	}
+
 	// Try fallback path
 	cfg, err = LoadConfig(fallbackPath)
 	if err == nil {
@@ -77,7 +207,13 @@ func LoadConfigWithDefaults(primaryPath, fallbackPath string) (*Config, error) {
 	return nil, fmt.Errorf("failed to load config from both %s and %s: %w", primaryPath, fallbackPath, err)
 }
 
-// LoadDefaultConfig loads the configuration using default paths
+// EnvConfigPath 指定配置文件路径的环境变量，设置后不再查找默认路径
+const EnvConfigPath = "FITGO_CONFIG"
+
+// LoadDefaultConfig loads the configuration from FITGO_CONFIG or the default paths
 func LoadDefaultConfig() (*Config, error) {
+	if path := os.Getenv(EnvConfigPath); path != "" {
+		return LoadConfig(path)
+	}
 	return LoadConfigWithDefaults("configs/config.json", "../../configs/config.json")
 }
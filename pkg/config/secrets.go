@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"fitgo/pkg/secrets"
+)
+
+// VaultPath returns the vault used for secret:// references in the given
+// config file: FITGO_VAULT_PATH, or secrets.vault next to the config file
+func VaultPath(configPath string) string {
+	return secrets.VaultPathFromEnv(filepath.Join(filepath.Dir(configPath), "secrets.vault"))
+}
+
+// resolveSecrets replaces every env://, file:// and secret:// reference in
+// the string fields of cfg with its resolved value
+func resolveSecrets(cfg *Config, resolver *secrets.Resolver) error {
+	return resolveValue(reflect.ValueOf(cfg).Elem(), "", resolver)
+}
+
+func resolveValue(v reflect.Value, path string, resolver *secrets.Resolver) error {
+	switch v.Kind() {
+	case reflect.String:
+		if !secrets.IsReference(v.String()) {
+			return nil
+		}
+		resolved, err := resolver.Resolve(v.String())
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		v.SetString(resolved)
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			if err := resolveValue(v.Field(i), joinPath(path, jsonName(t.Field(i))), resolver); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return resolveValue(v.Elem(), path, resolver)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := resolveValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), resolver); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			value := iter.Value().String()
+			if !secrets.IsReference(value) {
+				continue
+			}
+			resolved, err := resolver.Resolve(value)
+			if err != nil {
+				return fmt.Errorf("failed to resolve %s.%v: %w", path, iter.Key(), err)
+			}
+			v.SetMapIndex(iter.Key(), reflect.ValueOf(resolved))
+		}
+	}
+
+	return nil
+}
+
+func jsonName(field reflect.StructField) string {
+	tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if tag == "" || tag == "-" {
+		return field.Name
+	}
+	return tag
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
@@ -0,0 +1,255 @@
+package fit
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvalidFile 不是合法的 FIT 文件
+var ErrInvalidFile = errors.New("不是合法的 FIT 文件")
+
+// FieldNumTimestamp 所有消息通用的 timestamp 字段号
+const FieldNumTimestamp byte = 253
+
+// Message 解码后的数据消息。整数字段为 int64，浮点为 float64，字符串为 string，
+// 无效值不会出现在 Fields 中；数组字段只保留第一个元素
+type Message struct {
+	Global uint16
+	Fields map[byte]interface{}
+}
+
+// Int 返回整数字段
+func (m Message) Int(num byte) (int64, bool) {
+	v, ok := m.Fields[num].(int64)
+	return v, ok
+}
+
+// Float 返回数值字段，整数字段按 scale 与 offset 换算: value/scale - offset
+func (m Message) Float(num byte, scale, offset float64) (float64, bool) {
+	switch v := m.Fields[num].(type) {
+	case int64:
+		return float64(v)/scale - offset, true
+	case float64:
+		return v/scale - offset, true
+	}
+	return 0, false
+}
+
+// String 返回字符串字段
+func (m Message) String(num byte) (string, bool) {
+	v, ok := m.Fields[num].(string)
+	return v, ok
+}
+
+type fieldDef struct {
+	num      byte
+	size     int
+	baseType BaseType
+}
+
+type messageDef struct {
+	global    uint16
+	bigEndian bool
+	fields    []fieldDef
+	devSize   int // 开发者字段总字节数，解码时跳过
+}
+
+// Decode 解码 FIT 文件中的全部数据消息，会校验文件头与文件 CRC。
+// 支持压缩时间戳消息头，开发者字段被跳过
+func Decode(data []byte) ([]Message, error) {
+	if len(data) < 12 {
+		return nil, ErrInvalidFile
+	}
+	headerLen := int(data[0])
+	if (headerLen != 12 && headerLen != 14) || len(data) < headerLen || string(data[8:12]) != ".FIT" {
+		return nil, ErrInvalidFile
+	}
+	dataSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	end := headerLen + dataSize
+	if len(data) < end+2 {
+		return nil, fmt.Errorf("%w: 文件被截断", ErrInvalidFile)
+	}
+	if crc := binary.LittleEndian.Uint16(data[end:]); crc != 0 && crc != CRC(data[:end]) {
+		return nil, fmt.Errorf("%w: CRC 校验失败", ErrInvalidFile)
+	}
+
+	d := decoder{data: data[:end], pos: headerLen, defs: make(map[byte]*messageDef)}
+	var messages []Message
+	for d.pos < end {
+		msg, ok, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+type decoder struct {
+	data          []byte
+	pos           int
+	defs          map[byte]*messageDef
+	lastTimestamp uint32
+}
+
+// next 读取一条记录，定义消息返回 ok=false
+func (d *decoder) next() (Message, bool, error) {
+	h, err := d.read(1)
+	if err != nil {
+		return Message{}, false, err
+	}
+	header := h[0]
+
+	// 压缩时间戳消息头: bit7=1，bit5-6 为本地消息号，bit0-4 为时间偏移
+	if header&0x80 != 0 {
+		local := (header >> 5) & 0x03
+		offset := uint32(header & 0x1F)
+		ts := (d.lastTimestamp &^ 0x1F) + offset
+		if offset < d.lastTimestamp&0x1F {
+			ts += 0x20
+		}
+		msg, err := d.readData(local)
+		if err != nil {
+			return Message{}, false, err
+		}
+		d.lastTimestamp = ts
+		msg.Fields[FieldNumTimestamp] = int64(ts)
+		return msg, true, nil
+	}
+
+	local := header & 0x0F
+	if header&0x40 != 0 {
+		return Message{}, false, d.readDefinition(local, header&0x20 != 0)
+	}
+
+	msg, err := d.readData(local)
+	if err != nil {
+		return Message{}, false, err
+	}
+	if ts, ok := msg.Int(FieldNumTimestamp); ok {
+		d.lastTimestamp = uint32(ts)
+	}
+	return msg, true, nil
+}
+
+func (d *decoder) readDefinition(local byte, developer bool) error {
+	h, err := d.read(5)
+	if err != nil {
+		return err
+	}
+	def := &messageDef{bigEndian: h[1] == 1}
+	if def.bigEndian {
+		def.global = binary.BigEndian.Uint16(h[2:4])
+	} else {
+		def.global = binary.LittleEndian.Uint16(h[2:4])
+	}
+
+	fields, err := d.read(int(h[4]) * 3)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(fields); i += 3 {
+		def.fields = append(def.fields, fieldDef{num: fields[i], size: int(fields[i+1]), baseType: BaseType(fields[i+2])})
+	}
+
+	if developer {
+		n, err := d.read(1)
+		if err != nil {
+			return err
+		}
+		devFields, err := d.read(int(n[0]) * 3)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(devFields); i += 3 {
+			def.devSize += int(devFields[i+1])
+		}
+	}
+
+	d.defs[local] = def
+	return nil
+}
+
+func (d *decoder) readData(local byte) (Message, error) {
+	def, ok := d.defs[local]
+	if !ok {
+		return Message{}, fmt.Errorf("%w: 本地消息 %d 缺少定义", ErrInvalidFile, local)
+	}
+
+	msg := Message{Global: def.global, Fields: make(map[byte]interface{}, len(def.fields))}
+	for _, f := range def.fields {
+		raw, err := d.read(f.size)
+		if err != nil {
+			return Message{}, err
+		}
+		if v, ok := decodeValue(raw, f.baseType, def.bigEndian); ok {
+			msg.Fields[f.num] = v
+		}
+	}
+	if _, err := d.read(def.devSize); err != nil {
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+func (d *decoder) read(n int) ([]byte, error) {
+	if d.pos+n > len(d.data) {
+		return nil, fmt.Errorf("%w: 数据不完整", ErrInvalidFile)
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// decodeValue 解码单个字段，无效值返回 ok=false
+func decodeValue(raw []byte, t BaseType, bigEndian bool) (interface{}, bool) {
+	if t == String {
+		n := 0
+		for n < len(raw) && raw[n] != 0 {
+			n++
+		}
+		return string(raw[:n]), n > 0
+	}
+
+	size := t.Size()
+	if len(raw) < size {
+		return nil, false
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+
+	var u uint64
+	switch size {
+	case 1:
+		u = uint64(raw[0])
+	case 2:
+		u = uint64(order.Uint16(raw))
+	case 4:
+		u = uint64(order.Uint32(raw))
+	}
+	if u == t.invalid() && t != Float32 {
+		return nil, false
+	}
+
+	switch t {
+	case Sint8:
+		return int64(int8(u)), true
+	case Sint16:
+		return int64(int16(u)), true
+	case Sint32:
+		return int64(int32(u)), true
+	case Float32:
+		f := math.Float32frombits(uint32(u))
+		if u == 0xFFFFFFFF || math.IsNaN(float64(f)) {
+			return nil, false
+		}
+		return float64(f), true
+	}
+	return int64(u), true
+}
@@ -0,0 +1,134 @@
+package fit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// Field 要写入的字段，Value 为 nil 时写入该类型的无效值。
+// String/Byte 类型的 Size 为固定字节数，其余类型忽略 Size
+type Field struct {
+	Num   byte
+	Type  BaseType
+	Size  int
+	Value interface{}
+}
+
+// Encoder 按顺序写入消息，生成完整的 FIT 文件
+type Encoder struct {
+	data bytes.Buffer
+	defs map[byte]string // 每个本地消息号当前的定义
+}
+
+// NewEncoder 创建编码器
+func NewEncoder() *Encoder {
+	return &Encoder{defs: make(map[byte]string)}
+}
+
+// Write 写入一条消息，定义与该本地消息号上次不同时先写入定义消息
+func (e *Encoder) Write(local byte, global uint16, fields []Field) error {
+	if local > 15 {
+		return fmt.Errorf("本地消息号必须小于 16: %d", local)
+	}
+
+	def := e.definition(local, global, fields)
+	if e.defs[local] != string(def) {
+		e.data.Write(def)
+		e.defs[local] = string(def)
+	}
+
+	e.data.WriteByte(local)
+	for _, f := range fields {
+		if err := writeValue(&e.data, f); err != nil {
+			return fmt.Errorf("写入字段 %d 失败: %v", f.Num, err)
+		}
+	}
+	return nil
+}
+
+// Bytes 返回带文件头和 CRC 的 FIT 文件内容
+func (e *Encoder) Bytes() []byte {
+	out := header(e.data.Len())
+	out = append(out, e.data.Bytes()...)
+	crc := make([]byte, 2)
+	binary.LittleEndian.PutUint16(crc, CRC(out))
+	return append(out, crc...)
+}
+
+func (e *Encoder) definition(local byte, global uint16, fields []Field) []byte {
+	def := []byte{0x40 | local, 0, 0} // 定义消息头、保留字节、小端
+	def = binary.LittleEndian.AppendUint16(def, global)
+	def = append(def, byte(len(fields)))
+	for _, f := range fields {
+		def = append(def, f.Num, byte(fieldSize(f)), byte(f.Type))
+	}
+	return def
+}
+
+func fieldSize(f Field) int {
+	if (f.Type == String || f.Type == Byte) && f.Size > 0 {
+		return f.Size
+	}
+	return f.Type.Size()
+}
+
+func writeValue(buf *bytes.Buffer, f Field) error {
+	if f.Type == String {
+		s, _ := f.Value.(string)
+		b := make([]byte, fieldSize(f))
+		// 保留最后一个字节作为结束符，超长时在字符边界截断，避免把多字节字符(如中文)截成非法 UTF-8
+		if n := len(b) - 1; len(s) > n {
+			for n > 0 && !utf8.RuneStart(s[n]) {
+				n--
+			}
+			s = s[:n]
+		}
+		copy(b, s)
+		buf.Write(b)
+		return nil
+	}
+
+	if f.Value == nil {
+		return writeUint(buf, f.Type, f.Type.invalid())
+	}
+
+	switch v := f.Value.(type) {
+	case int:
+		return writeUint(buf, f.Type, uint64(int64(v)))
+	case int8:
+		return writeUint(buf, f.Type, uint64(int64(v)))
+	case int16:
+		return writeUint(buf, f.Type, uint64(int64(v)))
+	case int32:
+		return writeUint(buf, f.Type, uint64(int64(v)))
+	case uint8:
+		return writeUint(buf, f.Type, uint64(v))
+	case uint16:
+		return writeUint(buf, f.Type, uint64(v))
+	case uint32:
+		return writeUint(buf, f.Type, uint64(v))
+	case float32:
+		if f.Type != Float32 {
+			return fmt.Errorf("float32 只能写入 Float32 字段")
+		}
+		return writeUint(buf, f.Type, uint64(math.Float32bits(v)))
+	}
+	return fmt.Errorf("不支持的值类型 %T", f.Value)
+}
+
+func writeUint(buf *bytes.Buffer, t BaseType, v uint64) error {
+	switch t.Size() {
+	case 1:
+		buf.WriteByte(byte(v))
+	case 2:
+		buf.Write(binary.LittleEndian.AppendUint16(nil, uint16(v)))
+	case 4:
+		buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(v)))
+	default:
+		return fmt.Errorf("不支持的字段长度 %d", t.Size())
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Package fit 实现 Garmin FIT 协议中 fitgo 需要的部分：文件头、定义/数据消息的编码与解码以及 CRC。
+package fit
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// BaseType FIT 字段的基础类型
+type BaseType byte
+
+const (
+	Enum    BaseType = 0x00
+	Sint8   BaseType = 0x01
+	Uint8   BaseType = 0x02
+	Sint16  BaseType = 0x83
+	Uint16  BaseType = 0x84
+	Sint32  BaseType = 0x85
+	Uint32  BaseType = 0x86
+	String  BaseType = 0x07
+	Float32 BaseType = 0x88
+	Uint8z  BaseType = 0x0A
+	Uint16z BaseType = 0x8B
+	Uint32z BaseType = 0x8C
+	Byte    BaseType = 0x0D
+)
+
+// Size 返回基础类型单个值的字节数，字符串与字节数组返回 1
+func (t BaseType) Size() int {
+	switch t {
+	case Sint16, Uint16, Uint16z:
+		return 2
+	case Sint32, Uint32, Uint32z, Float32:
+		return 4
+	}
+	return 1
+}
+
+// invalid 各基础类型表示"无效值"的取值
+func (t BaseType) invalid() uint64 {
+	switch t {
+	case Enum, Uint8, Byte:
+		return 0xFF
+	case Sint8:
+		return 0x7F
+	case Sint16:
+		return 0x7FFF
+	case Uint16:
+		return 0xFFFF
+	case Sint32:
+		return 0x7FFFFFFF
+	case Uint32:
+		return 0xFFFFFFFF
+	}
+	// uint*z 与字符串以 0 表示无效
+	return 0
+}
+
+// 全局消息号
+const (
+	MesgFileID      uint16 = 0
+	MesgSession     uint16 = 18
+	MesgLap         uint16 = 19
+	MesgRecord      uint16 = 20
+	MesgWorkout     uint16 = 26
+	MesgWorkoutStep uint16 = 27
+	MesgActivity    uint16 = 34
+	MesgSport       uint16 = 12
+)
+
+// FileType file_id.type 取值
+const (
+	FileTypeActivity byte = 4
+	FileTypeWorkout  byte = 5
+)
+
+// ManufacturerDevelopment 开发者自定义设备的厂商号
+const ManufacturerDevelopment uint16 = 255
+
+// fitEpoch FIT 时间戳的起点 1989-12-31T00:00:00Z
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// Timestamp 将时间转换为 FIT 时间戳(秒)
+func Timestamp(t time.Time) uint32 {
+	return uint32(t.Sub(fitEpoch) / time.Second)
+}
+
+// Time 将 FIT 时间戳转换为时间
+func Time(ts uint32) time.Time {
+	return fitEpoch.Add(time.Duration(ts) * time.Second)
+}
+
+var crcTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+// CRC 计算 FIT 的 CRC-16
+func CRC(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		tmp := crcTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ crcTable[b&0xF]
+
+		tmp = crcTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ crcTable[(b>>4)&0xF]
+	}
+	return crc
+}
+
+const (
+	headerSize      = 14
+	protocolVersion = 0x20 // 2.0
+	profileVersion  = 2132 // 21.32
+)
+
+// header 生成 14 字节文件头
+func header(dataSize int) []byte {
+	h := make([]byte, headerSize)
+	h[0] = headerSize
+	h[1] = protocolVersion
+	binary.LittleEndian.PutUint16(h[2:], profileVersion)
+	binary.LittleEndian.PutUint32(h[4:], uint32(dataSize))
+	copy(h[8:], ".FIT")
+	binary.LittleEndian.PutUint16(h[12:], CRC(h[:12]))
+	return h
+}
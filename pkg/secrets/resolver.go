@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 支持的引用前缀
+const (
+	SchemeEnv    = "env://"    // env://NAME 读取环境变量
+	SchemeFile   = "file://"   // file:///run/secrets/name 读取文件（Docker secret）
+	SchemeSecret = "secret://" // secret://coros/password 读取本地保险库
+)
+
+// 保险库相关的环境变量
+const (
+	EnvVaultPath       = "FITGO_VAULT_PATH"
+	EnvVaultPassphrase = "FITGO_VAULT_PASSPHRASE"
+	EnvVaultKeyFile    = "FITGO_VAULT_KEY_FILE"
+)
+
+// IsReference 判断值是否为密钥引用
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, SchemeEnv) ||
+		strings.HasPrefix(value, SchemeFile) ||
+		strings.HasPrefix(value, SchemeSecret)
+}
+
+// KeyFromEnv 从环境变量读取保险库口令或密钥文件
+func KeyFromEnv() Key {
+	return Key{
+		Passphrase: os.Getenv(EnvVaultPassphrase),
+		KeyFile:    os.Getenv(EnvVaultKeyFile),
+	}
+}
+
+// VaultPathFromEnv 返回 FITGO_VAULT_PATH，未设置时返回 defaultPath
+func VaultPathFromEnv(defaultPath string) string {
+	if path := os.Getenv(EnvVaultPath); path != "" {
+		return path
+	}
+	return defaultPath
+}
+
+// Resolver 解析密钥引用，保险库只在第一次遇到 secret:// 引用时打开
+type Resolver struct {
+	vaultPath string
+	key       Key
+
+	once     sync.Once
+	vault    *Vault
+	vaultErr error
+}
+
+// NewResolver 创建解析器
+func NewResolver(vaultPath string, key Key) *Resolver {
+	return &Resolver{vaultPath: vaultPath, key: key}
+}
+
+// Resolve 解析引用，非引用的值原样返回
+func (r *Resolver) Resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, SchemeEnv):
+		name := strings.TrimPrefix(value, SchemeEnv)
+		resolved, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("环境变量 %s 未设置", name)
+		}
+		return resolved, nil
+
+	case strings.HasPrefix(value, SchemeFile):
+		path := strings.TrimPrefix(value, SchemeFile)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("读取密钥文件 %s 失败: %w", path, err)
+		}
+		// Docker secret 文件通常以换行结尾
+		return strings.TrimRight(string(data), "\r\n"), nil
+
+	case strings.HasPrefix(value, SchemeSecret):
+		name := strings.TrimPrefix(value, SchemeSecret)
+		vault, err := r.openVault()
+		if err != nil {
+			return "", err
+		}
+		return vault.Get(name)
+	}
+
+	return value, nil
+}
+
+func (r *Resolver) openVault() (*Vault, error) {
+	r.once.Do(func() {
+		if _, err := os.Stat(r.vaultPath); err != nil {
+			r.vaultErr = fmt.Errorf("保险库 %s 不可用: %w", r.vaultPath, err)
+			return
+		}
+		r.vault, r.vaultErr = OpenVault(r.vaultPath, r.key)
+	})
+	return r.vault, r.vaultErr
+}
@@ -0,0 +1,292 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	vaultVersion     = 1
+	vaultAAD         = "fitgo-vault-v1" // GCM 附加数据，与文件名无关，重命名或挂载到其他路径后仍可解密
+	vaultKDF         = "pbkdf2-sha256"
+	vaultIterations  = 200000
+	vaultKeyLength   = 32
+	vaultSaltLength  = 16
+	vaultKindKeyFile = "key"
+)
+
+// ErrSecretNotFound 保险库中不存在该密钥
+var ErrSecretNotFound = errors.New("secret not found")
+
+// Key 打开保险库使用的密钥来源，Passphrase 与 KeyFile 二选一
+type Key struct {
+	Passphrase string // 口令，通过 PBKDF2 派生 AES 密钥
+	KeyFile    string // 密钥文件，内容为 32 字节原始密钥或其 hex/base64 编码
+}
+
+// vaultFile 保险库文件的磁盘格式，所有密钥作为一个 JSON 对象整体加密
+type vaultFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations,omitempty"`
+	Salt       string `json:"salt,omitempty"`
+	Nonce      string `json:"nonce"`
+	Data       string `json:"data"`
+}
+
+// Vault 使用 AES-GCM 加密的本地保险库
+type Vault struct {
+	path    string
+	key     Key
+	salt    []byte
+	secrets map[string]string
+}
+
+// OpenVault 打开保险库，文件不存在时返回一个空保险库，首次 Save 时创建
+func OpenVault(path string, key Key) (*Vault, error) {
+	if key.Passphrase == "" && key.KeyFile == "" {
+		return nil, fmt.Errorf("打开保险库需要口令或密钥文件")
+	}
+
+	v := &Vault{path: path, key: key, secrets: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取保险库失败: %w", err)
+	}
+
+	var file vaultFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析保险库失败: %w", err)
+	}
+	if file.Version != vaultVersion {
+		return nil, fmt.Errorf("不支持的保险库版本: %d", file.Version)
+	}
+
+	if file.Salt != "" {
+		if v.salt, err = base64.StdEncoding.DecodeString(file.Salt); err != nil {
+			return nil, fmt.Errorf("保险库 salt 无效: %w", err)
+		}
+	}
+	nonce, err := base64.StdEncoding.DecodeString(file.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("保险库 nonce 无效: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(file.Data)
+	if err != nil {
+		return nil, fmt.Errorf("保险库数据无效: %w", err)
+	}
+
+	aead, err := v.aead(file.KDF, file.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(vaultAAD))
+	if err != nil {
+		return nil, fmt.Errorf("解密保险库失败，口令或密钥错误")
+	}
+	if err := json.Unmarshal(plaintext, &v.secrets); err != nil {
+		return nil, fmt.Errorf("解析保险库内容失败: %w", err)
+	}
+
+	return v, nil
+}
+
+// Get 读取密钥
+func (v *Vault) Get(name string) (string, error) {
+	value, ok := v.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	return value, nil
+}
+
+// Set 写入密钥，需调用 Save 持久化
+func (v *Vault) Set(name, value string) error {
+	if name == "" || strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") {
+		return fmt.Errorf("无效的密钥名称: %q", name)
+	}
+	v.secrets[name] = value
+	return nil
+}
+
+// Delete 删除密钥，需调用 Save 持久化
+func (v *Vault) Delete(name string) error {
+	if _, ok := v.secrets[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	delete(v.secrets, name)
+	return nil
+}
+
+// Names 按字母序列出所有密钥名称
+func (v *Vault) Names() []string {
+	names := make([]string, 0, len(v.secrets))
+	for name := range v.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save 加密并写回保险库文件，每次保存使用新的 nonce
+func (v *Vault) Save() error {
+	file := vaultFile{Version: vaultVersion}
+	if v.key.KeyFile != "" {
+		file.KDF = vaultKindKeyFile
+	} else {
+		if v.salt == nil {
+			v.salt = make([]byte, vaultSaltLength)
+			if _, err := rand.Read(v.salt); err != nil {
+				return fmt.Errorf("生成 salt 失败: %w", err)
+			}
+		}
+		file.KDF = vaultKDF
+		file.Iterations = vaultIterations
+		file.Salt = base64.StdEncoding.EncodeToString(v.salt)
+	}
+
+	aead, err := v.aead(file.KDF, file.Iterations)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(v.secrets)
+	if err != nil {
+		return fmt.Errorf("序列化密钥失败: %w", err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("生成 nonce 失败: %w", err)
+	}
+	file.Nonce = base64.StdEncoding.EncodeToString(nonce)
+	file.Data = base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, plaintext, []byte(vaultAAD)))
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化保险库失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(v.path), 0o700); err != nil {
+		return fmt.Errorf("创建保险库目录失败: %w", err)
+	}
+
+	// 先写临时文件再重命名，避免写入中断损坏保险库
+	tmp := v.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("写入保险库失败: %w", err)
+	}
+	if err := os.Rename(tmp, v.path); err != nil {
+		return fmt.Errorf("写入保险库失败: %w", err)
+	}
+	return nil
+}
+
+// aead 根据密钥来源构造 AES-256-GCM
+func (v *Vault) aead(kdf string, iterations int) (cipher.AEAD, error) {
+	var key []byte
+	switch kdf {
+	case vaultKindKeyFile:
+		if v.key.KeyFile == "" {
+			return nil, fmt.Errorf("该保险库使用密钥文件加密，请提供密钥文件")
+		}
+		var err error
+		if key, err = readKeyFile(v.key.KeyFile); err != nil {
+			return nil, err
+		}
+	case vaultKDF:
+		if v.key.Passphrase == "" {
+			return nil, fmt.Errorf("该保险库使用口令加密，请提供口令")
+		}
+		if iterations <= 0 {
+			return nil, fmt.Errorf("保险库迭代次数无效: %d", iterations)
+		}
+		key = pbkdf2SHA256([]byte(v.key.Passphrase), v.salt, iterations, vaultKeyLength)
+	default:
+		return nil, fmt.Errorf("不支持的密钥派生方式: %s", kdf)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建 AES 失败: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// readKeyFile 读取 32 字节密钥，支持原始字节、hex 与 base64
+func readKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+	if len(data) == vaultKeyLength {
+		return data, nil
+	}
+
+	text := strings.TrimSpace(string(data))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == vaultKeyLength {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == vaultKeyLength {
+		return key, nil
+	}
+	return nil, fmt.Errorf("密钥文件 %s 必须是 32 字节密钥或其 hex/base64 编码", path)
+}
+
+// GenerateKeyFile 生成 hex 编码的随机密钥文件
+func GenerateKeyFile(path string) error {
+	key := make([]byte, vaultKeyLength)
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("生成密钥失败: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0o600); err != nil {
+		return fmt.Errorf("写入密钥文件失败: %w", err)
+	}
+	return nil
+}
+
+// pbkdf2SHA256 按 RFC 8018 实现的 PBKDF2-HMAC-SHA256
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	mac := hmac.New(sha256.New, password)
+	prf := func(data []byte) []byte {
+		mac.Reset()
+		mac.Write(data)
+		return mac.Sum(nil)
+	}
+	hashLen := sha256.Size
+	blocks := (keyLen + hashLen - 1) / hashLen
+
+	derived := make([]byte, 0, blocks*hashLen)
+	buf := make([]byte, 4)
+	for block := 1; block <= blocks; block++ {
+		buf[0] = byte(block >> 24)
+		buf[1] = byte(block >> 16)
+		buf[2] = byte(block >> 8)
+		buf[3] = byte(block)
+
+		u := prf(append(append([]byte{}, salt...), buf...))
+		t := append([]byte{}, u...)
+		for i := 1; i < iterations; i++ {
+			u = prf(u)
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		derived = append(derived, t...)
+	}
+	return derived[:keyLen]
+}
@@ -0,0 +1,255 @@
+// Package sport 定义 fitgo 统一的运动类型，以及它与高驰 sportType、TCX Sport 属性、
+// FIT sport/sub_sport 之间的双向映射。
+package sport
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Sport 统一的运动类型，JSON 中为小写下划线名称
+type Sport string
+
+const (
+	Unknown       Sport = "unknown"
+	Run           Sport = "run"
+	TreadmillRun  Sport = "treadmill_run"
+	TrailRun      Sport = "trail_run"
+	TrackRun      Sport = "track_run"
+	Hike          Sport = "hike"
+	MountainClimb Sport = "mountain_climb"
+	Walk          Sport = "walk"
+	Bike          Sport = "bike"
+	IndoorBike    Sport = "indoor_bike"
+	PoolSwim      Sport = "pool_swim"
+	OpenWaterSwim Sport = "open_water_swim"
+	Strength      Sport = "strength"
+	Cardio        Sport = "cardio"
+	Ski           Sport = "ski"
+	Snowboard     Sport = "snowboard"
+	XCSki         Sport = "xc_ski"
+	Row           Sport = "row"
+	IndoorRow     Sport = "indoor_row"
+	Triathlon     Sport = "triathlon"
+)
+
+// Family 运动大类，分析器按大类选择
+type Family string
+
+const (
+	FamilyRunning  Family = "running"
+	FamilyCycling  Family = "cycling"
+	FamilySwimming Family = "swimming"
+	FamilyStrength Family = "strength"
+	FamilyHiking   Family = "hiking"
+	FamilyOther    Family = "other"
+)
+
+// familyNames 运动大类的中文名称
+var familyNames = map[Family]string{
+	FamilyRunning:  "跑步",
+	FamilyCycling:  "骑行",
+	FamilySwimming: "游泳",
+	FamilyStrength: "力量与有氧",
+	FamilyHiking:   "徒步",
+	FamilyOther:    "其它",
+}
+
+// Name 运动大类的中文名称
+func (f Family) Name() string {
+	if name, ok := familyNames[f]; ok {
+		return name
+	}
+	return familyNames[FamilyOther]
+}
+
+// info 单个运动类型的映射表项，FIT 取值见 FIT Profile 的 sport/sub_sport 枚举
+type info struct {
+	name     string // 中文名称
+	family   Family
+	coros    int    // 高驰 sportType
+	tcx      string // TCX Activity/Workout 的 Sport 属性
+	fitSport byte
+	fitSub   byte
+}
+
+var table = map[Sport]info{
+	Unknown:       {"未知运动", FamilyOther, 0, "Other", 0, 0},
+	Run:           {"跑步", FamilyRunning, 100, "Running", 1, 0},
+	TreadmillRun:  {"室内跑", FamilyRunning, 101, "Running", 1, 1},
+	TrailRun:      {"越野跑", FamilyRunning, 102, "Running", 1, 3},
+	TrackRun:      {"操场跑", FamilyRunning, 103, "Running", 1, 4},
+	Hike:          {"徒步", FamilyHiking, 104, "Other", 17, 0},
+	MountainClimb: {"登山", FamilyHiking, 105, "Other", 16, 0},
+	Walk:          {"健走", FamilyHiking, 900, "Other", 11, 0},
+	Bike:          {"骑行", FamilyCycling, 200, "Biking", 2, 0},
+	IndoorBike:    {"室内骑行", FamilyCycling, 201, "Biking", 2, 6},
+	PoolSwim:      {"泳池游泳", FamilySwimming, 300, "Other", 5, 17},
+	OpenWaterSwim: {"公开水域", FamilySwimming, 301, "Other", 5, 18},
+	Cardio:        {"有氧运动", FamilyStrength, 400, "Other", 10, 26},
+	Strength:      {"力量训练", FamilyStrength, 402, "Other", 10, 20},
+	Ski:           {"滑雪", FamilyOther, 500, "Other", 13, 0},
+	Snowboard:     {"单板滑雪", FamilyOther, 501, "Other", 14, 0},
+	XCSki:         {"越野滑雪", FamilyOther, 503, "Other", 12, 0},
+	Row:           {"划船", FamilyOther, 700, "Other", 15, 0},
+	IndoorRow:     {"室内划船", FamilyOther, 701, "Other", 15, 14},
+	Triathlon:     {"铁人三项", FamilyOther, 10000, "Other", 18, 0},
+}
+
+// byFITSport 没有通用子类型(sub_sport 为 generic)表项的 FIT sport 回退到的运动类型，
+// 如 swimming/generic 和 training/generic
+var byFITSport = map[byte]Sport{
+	5:  PoolSwim,
+	10: Cardio,
+}
+
+// byCoros 高驰 sportType 到运动类型的反向表
+var byCoros = func() map[int]Sport {
+	m := make(map[int]Sport, len(table))
+	for s, i := range table {
+		if s != Unknown {
+			m[i.coros] = s
+		}
+	}
+	return m
+}()
+
+// aliases 兼容的其它写法，如旧版训练课使用的运动大类名称
+var aliases = map[string]Sport{
+	"running":   Run,
+	"treadmill": TreadmillRun,
+	"trail":     TrailRun,
+	"track":     TrackRun,
+	"hiking":    Hike,
+	"walking":   Walk,
+	"cycling":   Bike,
+	"biking":    Bike,
+	"swimming":  PoolSwim,
+	"swim":      PoolSwim,
+	"skiing":    Ski,
+	"rowing":    Row,
+}
+
+// All 返回所有已知运动类型(不含 Unknown)
+func All() []Sport {
+	sports := make([]Sport, 0, len(table)-1)
+	for s := range table {
+		if s != Unknown {
+			sports = append(sports, s)
+		}
+	}
+	return sports
+}
+
+// Parse 解析运动类型，接受统一名称、兼容别名或高驰数字 sportType
+func Parse(value string) (Sport, error) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if code, err := strconv.Atoi(v); err == nil {
+		if s, ok := byCoros[code]; ok {
+			return s, nil
+		}
+		return Unknown, fmt.Errorf("未知的高驰运动类型: %d", code)
+	}
+	if _, ok := table[Sport(v)]; ok && Sport(v) != Unknown {
+		return Sport(v), nil
+	}
+	if s, ok := aliases[v]; ok {
+		return s, nil
+	}
+	return Unknown, fmt.Errorf("未知的运动类型: %q", value)
+}
+
+// FromCoros 高驰 sportType 对应的运动类型，未知代码返回 Unknown
+func FromCoros(code int) Sport {
+	if s, ok := byCoros[code]; ok {
+		return s
+	}
+	return Unknown
+}
+
+// FromTCX TCX Sport 属性对应的运动类型，TCX 只区分跑步和骑行
+func FromTCX(value string) Sport {
+	switch value {
+	case "Running":
+		return Run
+	case "Biking":
+		return Bike
+	}
+	return Unknown
+}
+
+// FromFIT FIT sport/sub_sport 对应的运动类型，没有精确匹配的子类型时回退到该 sport 的通用类型
+func FromFIT(fitSport, fitSub byte) Sport {
+	fallback := Unknown
+	for s, i := range table {
+		if s == Unknown || i.fitSport != fitSport {
+			continue
+		}
+		if i.fitSub == fitSub {
+			return s
+		}
+		if i.fitSub == 0 {
+			fallback = s
+		}
+	}
+	if fallback == Unknown {
+		if s, ok := byFITSport[fitSport]; ok {
+			return s
+		}
+	}
+	return fallback
+}
+
+// Valid 是否为已知运动类型
+func (s Sport) Valid() bool {
+	_, ok := table[s]
+	return ok && s != Unknown
+}
+
+// Name 中文名称
+func (s Sport) Name() string {
+	return s.info().name
+}
+
+// Family 运动大类
+func (s Sport) Family() Family {
+	return s.info().family
+}
+
+// Coros 高驰 sportType，Unknown 返回 0
+func (s Sport) Coros() int {
+	return s.info().coros
+}
+
+// TCX TCX Sport 属性(Running/Biking/Other)
+func (s Sport) TCX() string {
+	return s.info().tcx
+}
+
+// FIT FIT sport 与 sub_sport 枚举值
+func (s Sport) FIT() (fitSport, fitSub byte) {
+	i := s.info()
+	return i.fitSport, i.fitSub
+}
+
+func (s Sport) info() info {
+	if i, ok := table[s]; ok {
+		return i
+	}
+	return table[Unknown]
+}
+
+// UnmarshalText 解析 JSON 中的运动类型，兼容别名与高驰数字代码，保存过的 unknown 原样读回
+func (s *Sport) UnmarshalText(text []byte) error {
+	if Sport(text) == Unknown {
+		*s = Unknown
+		return nil
+	}
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
@@ -0,0 +1,178 @@
+// Package units 把运动数据格式化为带单位的易读文本。
+// 所有换算和取整都在这里完成，交给模型的数值不需要再做任何计算。
+// 单位使用与语言无关的符号(km、/km、bpm、spm、W、m、kcal)，中英文提示词共用。
+package units
+
+import (
+	"fmt"
+	"math"
+)
+
+// Duration 把秒数格式化为 M:SS，满一小时为 H:MM:SS，四舍五入到秒
+func Duration(seconds float64) string {
+	total := int(math.Round(seconds))
+	if total < 0 {
+		total = 0
+	}
+	h, m, s := total/3600, total%3600/60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// Pace 把秒/公里格式化为 M:SS /km，0 表示没有数据，返回空字符串
+func Pace(secondsPerKm float64) string {
+	if secondsPerKm <= 0 {
+		return ""
+	}
+	return Duration(secondsPerKm) + " /km"
+}
+
+// PaceFromSpeed 把米/秒换算为配速
+func PaceFromSpeed(metersPerSecond float64) string {
+	if metersPerSecond <= 0 {
+		return ""
+	}
+	return Pace(1000 / metersPerSecond)
+}
+
+// SwimPace 把秒/百米格式化为 M:SS /100m，0 表示没有数据
+func SwimPace(secondsPer100m float64) string {
+	if secondsPer100m <= 0 {
+		return ""
+	}
+	return Duration(secondsPer100m) + " /100m"
+}
+
+// Distance 把米格式化为公里(保留两位小数)，不足 1 公里时为整数米
+func Distance(meters float64) string {
+	if meters < 1000 {
+		return fmt.Sprintf("%d m", int(math.Round(meters)))
+	}
+	return fmt.Sprintf("%.2f km", meters/1000)
+}
+
+// Speed 把公里/小时格式化为一位小数
+func Speed(kmPerHour float64) string {
+	if kmPerHour <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f km/h", kmPerHour)
+}
+
+// HeartRate 心率，0 表示没有数据
+func HeartRate(bpm float64) string {
+	return integer(bpm, "bpm")
+}
+
+// Cadence 步频(步/分钟)，0 表示没有数据
+func Cadence(spm float64) string {
+	return integer(spm, "spm")
+}
+
+// RPM 骑行踏频(转/分钟)，0 表示没有数据
+func RPM(rpm float64) string {
+	return integer(rpm, "rpm")
+}
+
+// StrokeRate 游泳划频(次/分钟)，0 表示没有数据
+func StrokeRate(strokesPerMin float64) string {
+	return integer(strokesPerMin, "strokes/min")
+}
+
+// StrokeLength 每次划水的距离，保留两位小数，0 表示没有数据
+func StrokeLength(meters float64) string {
+	if meters <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.2f m", meters)
+}
+
+// Power 功率，0 表示没有数据
+func Power(watts float64) string {
+	return integer(watts, "W")
+}
+
+// Work 机械功，0 表示没有数据
+func Work(kJ float64) string {
+	return integer(kJ, "kJ")
+}
+
+// Weight 负重，保留一位小数，0 表示没有数据(徒手)
+func Weight(kg float64) string {
+	if kg <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f kg", kg)
+}
+
+// Calories 热量，0 表示没有数据
+func Calories(kcal float64) string {
+	return integer(kcal, "kcal")
+}
+
+// Elevation 爬升/下降，0 是有效值
+func Elevation(meters float64) string {
+	return fmt.Sprintf("%d m", int(math.Round(meters)))
+}
+
+// StrideLength 步幅，0 表示没有数据
+func StrideLength(cm float64) string {
+	return integer(cm, "cm")
+}
+
+// Decimal 保留一位小数，用于训练效果等无单位指标，0 表示没有数据
+func Decimal(value float64) string {
+	if value == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", value)
+}
+
+// Count 无单位的计数，如 SWOLF、组数、次数，0 表示没有数据
+func Count(n float64) string {
+	if n <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", int(math.Round(n)))
+}
+
+// Ratio 保留两位小数的比值，如功率变异指数，0 表示没有数据
+func Ratio(value float64) string {
+	if value <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", value)
+}
+
+// Percent 把比例格式化为整数百分比，如 0.356 为 36%
+func Percent(fraction float64) string {
+	return fmt.Sprintf("%d%%", int(math.Round(fraction*100)))
+}
+
+// Change 把相对变化格式化为带符号的百分比，如 0.12 为 +12%、-0.05 为 -5%
+func Change(fraction float64) string {
+	return fmt.Sprintf("%+d%%", int(math.Round(fraction*100)))
+}
+
+// Milliseconds 毫秒，用于 HRV，0 表示没有数据
+func Milliseconds(ms float64) string {
+	return integer(ms, "ms")
+}
+
+// Minutes 把分钟数格式化为 H:MM，用于睡眠等以分钟计的时长，0 表示没有数据
+func Minutes(minutes float64) string {
+	total := int(math.Round(minutes))
+	if total <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
+
+func integer(value float64, unit string) string {
+	if value <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d %s", int(math.Round(value)), unit)
+}
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"expvar"
 	"fitgo/internal/handler"
 	"net/http"
 )
@@ -15,9 +16,75 @@ func SetupTcxRoutes(mux *http.ServeMux, tcxHandler *handler.TCXHandler) {
 
 }
 
+// SetCorosRoutes 设置高驰路由，数据接口均挂在 /coros/accounts/{accountId} 下
 func SetCorosRoutes(mux *http.ServeMux, corosHandler *handler.CorosHandler) {
-	mux.HandleFunc("GET /coros/login", corosHandler.Login)
-	mux.HandleFunc("GET /coros/sports/summary", corosHandler.SportsSummary)
-	mux.HandleFunc("GET /coros/active", corosHandler.ActivityList)
-	mux.HandleFunc("GET /coros/ai/summary", corosHandler.GetAiSportsSummary)
+	mux.HandleFunc("GET /coros/accounts", corosHandler.ListAccounts)
+	mux.HandleFunc("POST /coros/accounts", corosHandler.AddAccount)
+	mux.HandleFunc("DELETE /coros/accounts/{accountId}", corosHandler.RemoveAccount)
+
+	mux.HandleFunc("GET /coros/accounts/{accountId}/login", corosHandler.Login)
+	mux.HandleFunc("GET /coros/accounts/{accountId}/sports/summary", corosHandler.SportsSummary)
+	mux.HandleFunc("GET /coros/accounts/{accountId}/active", corosHandler.ActivityList)
+	mux.HandleFunc("GET /coros/accounts/{accountId}/ai/summary", corosHandler.GetAiSportsSummary)
+	mux.HandleFunc("GET /coros/accounts/{accountId}/ai/summary/stream", corosHandler.GetAiSportsSummaryStream)
+	mux.HandleFunc("GET /coros/accounts/{accountId}/activities/{labelId}/ai/reports", corosHandler.ListAiReports)
+	mux.HandleFunc("GET /coros/accounts/{accountId}/activities/{labelId}/ai/reports/{reportId}", corosHandler.GetAiReport)
+	mux.HandleFunc("GET /coros/accounts/{accountId}/dashboard", corosHandler.Dashboard)
+	mux.HandleFunc("GET /coros/accounts/{accountId}/daily", corosHandler.DailyMetrics)
+	mux.HandleFunc("POST /coros/accounts/{accountId}/import", corosHandler.ImportActivities)
+	mux.HandleFunc("POST /coros/accounts/{accountId}/activities/{labelId}/import", corosHandler.ImportActivity)
+}
+
+// SetActivityRoutes 设置本地活动路由，上传与高驰导入的 FIT/TCX 文件都保存为活动
+func SetActivityRoutes(mux *http.ServeMux, activityHandler *handler.ActivityHandler) {
+	mux.HandleFunc("GET /activities", activityHandler.ListActivities)
+	mux.HandleFunc("POST /activities/import", activityHandler.ImportActivity)
+	mux.HandleFunc("GET /activities/{id}", activityHandler.GetActivity)
+}
+
+// SetChatRoutes 设置活动问答路由，会话挂在活动下创建，之后按会话ID访问
+func SetChatRoutes(mux *http.ServeMux, chatHandler *handler.ChatHandler) {
+	mux.HandleFunc("POST /activities/{id}/chat", chatHandler.StartChat)
+	mux.HandleFunc("GET /activities/{id}/chats", chatHandler.ListChats)
+	mux.HandleFunc("GET /chats/{id}", chatHandler.GetChat)
+	mux.HandleFunc("POST /chats/{id}/messages", chatHandler.SendMessage)
+	mux.HandleFunc("DELETE /chats/{id}", chatHandler.DeleteChat)
+}
+
+// SetQueryRoutes 设置自然语言查询路由
+func SetQueryRoutes(mux *http.ServeMux, queryHandler *handler.QueryHandler) {
+	mux.HandleFunc("POST /coros/accounts/{accountId}/query", queryHandler.Query)
+	mux.HandleFunc("GET /coros/accounts/{accountId}/query/log", queryHandler.QueryLog)
+}
+
+// SetPredictionRoutes 设置比赛成绩预测路由，POST 时请求体可附带近期比赛成绩
+func SetPredictionRoutes(mux *http.ServeMux, predictionHandler *handler.PredictionHandler) {
+	mux.HandleFunc("GET /coros/accounts/{accountId}/predictions", predictionHandler.GetPrediction)
+	mux.HandleFunc("POST /coros/accounts/{accountId}/predictions", predictionHandler.PostPrediction)
+}
+
+// SetReviewRoutes 设置周期训练回顾路由
+func SetReviewRoutes(mux *http.ServeMux, reviewHandler *handler.ReviewHandler) {
+	mux.HandleFunc("GET /coros/accounts/{accountId}/ai/review", reviewHandler.GetReview)
+}
+
+// SetUsageRoutes 设置 AI 用量管理路由
+func SetUsageRoutes(mux *http.ServeMux, usageHandler *handler.UsageHandler) {
+	mux.HandleFunc("GET /admin/ai/usage", usageHandler.GetUsage)
+}
+
+// SetWorkoutRoutes 设置训练课路由，上传到高驰挂在账号路径下
+func SetWorkoutRoutes(mux *http.ServeMux, workoutHandler *handler.WorkoutHandler) {
+	mux.HandleFunc("GET /workouts", workoutHandler.ListWorkouts)
+	mux.HandleFunc("POST /workouts", workoutHandler.CreateWorkout)
+	mux.HandleFunc("GET /workouts/{id}", workoutHandler.GetWorkout)
+	mux.HandleFunc("DELETE /workouts/{id}", workoutHandler.DeleteWorkout)
+	mux.HandleFunc("GET /workouts/{id}/tcx", workoutHandler.ExportTCX)
+	mux.HandleFunc("GET /workouts/{id}/fit", workoutHandler.ExportFIT)
+	mux.HandleFunc("POST /coros/accounts/{accountId}/workouts/{id}", workoutHandler.UploadToCoros)
+}
+
+// SetDebugRoutes 注册 /debug/vars，输出 expvar 指标(如高驰请求的重试与延迟统计)
+func SetDebugRoutes(mux *http.ServeMux) {
+	mux.Handle("GET /debug/vars", expvar.Handler())
 }
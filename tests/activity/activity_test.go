@@ -0,0 +1,106 @@
+package activity_test
+
+import (
+	"context"
+	"math"
+	"net/http/httptest"
+	"testing"
+
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/coros"
+	"fitgo/internal/service/coros/fakeserver"
+	"fitgo/pkg/config"
+	"fitgo/pkg/sport"
+)
+
+const (
+	fitLabelID     = "472913588747534541" // 夹具中只有 FIT 文件
+	tcxLabelID     = "472913588747534700" // 夹具中只有 TCX 文件
+	missingLabelID = "472913588747534600" // 夹具中没有原始文件
+)
+
+func newServices(t *testing.T) (coros.CorosService, activity.ActivityService) {
+	t.Helper()
+	server := httptest.NewServer(fakeserver.New(nil))
+	t.Cleanup(server.Close)
+
+	dataDir := t.TempDir()
+	accounts, err := coros.NewAccountStore(dataDir)
+	if err != nil {
+		t.Fatalf("创建账号存储失败: %v", err)
+	}
+	activities := activity.NewActivityService(dataDir)
+	service := coros.NewCorosService(&config.CorosConfig{
+		Username: 13800000000,
+		Password: "md5",
+		Address:  server.URL,
+	}, accounts, coros.NewDailyStore(dataDir), activities)
+	return service, activities
+}
+
+func TestImportActivities(t *testing.T) {
+	service, activities := newServices(t)
+
+	result, err := service.ImportActivities(context.Background(), coros.DefaultAccountID, 10)
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if len(result.Imported) != 2 {
+		t.Fatalf("期望导入 2 条活动，实际 %d 条，失败: %v", len(result.Imported), result.Failed)
+	}
+	if _, ok := result.Failed[missingLabelID]; !ok {
+		t.Errorf("没有原始文件的活动应记录为失败: %v", result.Failed)
+	}
+
+	run, err := activities.Get("coros-" + fitLabelID)
+	if err != nil {
+		t.Fatalf("读取 FIT 导入的活动失败: %v", err)
+	}
+	if run.Format != activity.FormatFIT || run.Sport != sport.Run {
+		t.Errorf("format = %s, sport = %s", run.Format, run.Sport)
+	}
+	if math.Abs(run.Distance-10000) > 1 || run.MovingTime != 2244 || run.AvgHR != 146 {
+		t.Errorf("汇总数据错误: distance=%.1f moving=%.0f avgHR=%d", run.Distance, run.MovingTime, run.AvgHR)
+	}
+	if len(run.Laps) != 10 || len(run.Points) < 400 {
+		t.Errorf("期望 10 个分段和逐点轨迹，实际 %d 个分段 %d 个点", len(run.Laps), len(run.Points))
+	}
+	if p := run.Points[0]; p.Lat == 0 || p.HeartRate == 0 || p.Speed == 0 {
+		t.Errorf("轨迹点缺少数据: %+v", p)
+	}
+
+	trail, err := activities.Get("coros-" + tcxLabelID)
+	if err != nil {
+		t.Fatalf("读取 TCX 导入的活动失败: %v", err)
+	}
+	// TCX 只能表示 Running，运动类型以高驰为准
+	if trail.Format != activity.FormatTCX || trail.Sport != sport.TrailRun {
+		t.Errorf("format = %s, sport = %s", trail.Format, trail.Sport)
+	}
+	if len(trail.Laps) != 7 || math.Abs(trail.Distance-6650) > 1 || trail.Ascent == 0 {
+		t.Errorf("TCX 汇总错误: laps=%d distance=%.1f ascent=%.1f", len(trail.Laps), trail.Distance, trail.Ascent)
+	}
+
+	again, err := service.ImportActivities(context.Background(), coros.DefaultAccountID, 10)
+	if err != nil {
+		t.Fatalf("重复导入失败: %v", err)
+	}
+	if len(again.Imported) != 0 || len(again.Skipped) != 2 {
+		t.Errorf("重复导入应跳过已有活动: imported=%d skipped=%v", len(again.Imported), again.Skipped)
+	}
+
+	list, err := activities.List()
+	if err != nil {
+		t.Fatalf("列出活动失败: %v", err)
+	}
+	if len(list) != 2 || list[0].Points != nil {
+		t.Errorf("列表应有 2 条不含轨迹点的活动，实际 %d 条", len(list))
+	}
+}
+
+func TestIngestRejectsUnknownFormat(t *testing.T) {
+	activities := activity.NewActivityService(t.TempDir())
+	if _, err := activities.Ingest("notes.txt", []byte("hello"), activity.Source{}); err == nil {
+		t.Error("未知格式应返回错误")
+	}
+}
@@ -2,70 +2,208 @@ package ai_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 
+	"fitgo/internal/service/activity"
 	"fitgo/internal/service/ai/client"
+	"fitgo/internal/service/ai/fake"
+	"fitgo/internal/service/ai/prompt"
 	aiservice "fitgo/internal/service/ai/service"
-	"fitgo/internal/service/analyzer/running"
+	"fitgo/internal/service/analyzer"
+	"fitgo/internal/service/analyzer/builtin"
+	"fitgo/internal/service/coros"
+	"fitgo/internal/service/coros/fakeserver"
+	"fitgo/internal/service/report"
+	"fitgo/internal/service/usage"
 	"fitgo/pkg/config"
+	"fitgo/pkg/sport"
 )
 
-func TestAIService(t *testing.T) {
-	// 1. 加载配置
-	cfg, err := config.LoadConfig("../../configs/config.json")
+// fakeConfig 使用内置 fake 提供者的 AI 配置，不访问网络
+func fakeConfig(fakeCfg *config.FakeConfig) config.AIConfig {
+	return config.AIConfig{
+		Provider: "fake",
+		Config:   config.AIProviderConfig{Model: "fake-model", Fake: fakeCfg},
+	}
+}
+
+// newRegistry 内置分析器，使用按 cfg 创建的 AI 服务和内置提示词模板，u 不为 nil 时记录用量
+func newRegistry(t *testing.T, cfg config.AIConfig, u usage.UsageService) *analyzer.Registry {
+	t.Helper()
+	aiService, err := aiservice.NewAIService(&cfg)
+	if err != nil {
+		t.Fatalf("创建AI服务失败: %v", err)
+	}
+	if u != nil {
+		aiService.WithUsage(u)
+	}
+	prompts, err := prompt.NewStore(config.PromptConfig{})
 	if err != nil {
-		t.Fatalf("加载配置失败: %v", err)
+		t.Fatalf("加载提示词模板失败: %v", err)
 	}
+	return builtin.Registry().WithAI(aiService, prompts, 0)
+}
 
-	// 2. 创建 AI 服务
-	aiService, err := aiservice.NewAIService(&cfg.AI)
+func TestAIService(t *testing.T) {
+	cfg := fakeConfig(nil)
+	aiService, err := aiservice.NewAIService(&cfg)
 	if err != nil {
 		t.Fatalf("创建AI服务失败: %v", err)
 	}
 
-	// 3. 使用 AI 服务
-	response, err := aiService.Chat(context.Background(), []client.ChatMessage{
+	messages := []client.ChatMessage{
 		{
 			Role:    "user",
 			Content: "我是高驰设备，我会给你高驰的运动概要，你帮我分析一下",
 		},
-	})
+	}
+	response, err := aiService.Chat(context.Background(), messages)
 	if err != nil {
 		t.Fatalf("AI聊天失败: %v", err)
 	}
 
-	fmt.Println("AI回复:", response)
+	// 默认回答带有提示词摘要，相同的提示词得到相同的摘要
+	if !strings.HasPrefix(response, "[fake]") || !strings.Contains(response, fake.Digest(messages)) {
+		t.Errorf("AI回复 = %q", response)
+	}
 }
 
 func TestAIServiceSummary(t *testing.T) {
-	// 测试用的用户ID和运动ID
-	userID := "472913588747534541"
-	sportID := "100"
+	accounts, err := coros.NewAccountStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("创建账号存储失败: %v", err)
+	}
+
+	// 高驰数据来自内置夹具，模型为 fake 提供者，不访问任何真实接口
+	var corosDown atomic.Bool
+	fakeCoros := fakeserver.New(nil)
+	corosServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if corosDown.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		fakeCoros.ServeHTTP(w, r)
+	}))
+	defer corosServer.Close()
+	corosCfg := &config.CorosConfig{Username: 15659295082, Password: "test", Address: corosServer.URL, HTTP: config.CorosHTTPConfig{MaxRetries: -1}}
+	usageService := usage.NewUsageService(t.TempDir(), config.UsageConfig{})
+	registry := newRegistry(t, fakeConfig(&config.FakeConfig{
+		Template:   `{{if contains .Prompt "每公里分段"}}### 一、运动表现总结{{else}}缺少分段数据{{end}}`,
+		EchoDigest: true,
+	}), usageService)
+	corosService := coros.NewCorosService(corosCfg, accounts, coros.NewDailyStore(t.TempDir()), activity.NewActivityService(t.TempDir()))
+	reports := report.NewReportService(t.TempDir())
+
+	// 测试用的运动ID和运动类型
+	labelID := "472913588747534541"
+	sportType := sport.FromCoros(100)
 
-	// 调用 RunAnalyzer 函数
-	result, err := running.RunAnalyzer(userID, sportID)
+	// 调用 RunAnalyzer 函数，用量记在高驰账号名下，而不是请求头中的用户
+	result, err := registry.Analyze(usage.WithUser(context.Background(), "alice"), corosService, reports, coros.DefaultAccountID, labelID, sportType, analyzer.Options{})
 	if err != nil {
 		t.Fatalf("RunAnalyzer 执行失败: %v", err)
 	}
+	if !strings.HasPrefix(result.Content, "### 一、运动表现总结") || !strings.Contains(result.Content, "prompt-digest") {
+		t.Errorf("分析结果 = %q", result.Content)
+	}
+	if result.Provider != "fake" || result.Model != "fake-model" || result.Cached {
+		t.Errorf("报告 = %+v", result)
+	}
 
-	// 检查返回结果是否为空
-	if result == "" {
-		t.Error("预期得到非空的分析结果，但结果为空")
+	summary, err := usageService.Summary(usage.GroupByUser, "", "")
+	if err != nil || len(summary.Groups) != 1 || summary.Groups[0].Key != coros.DefaultAccountID {
+		t.Errorf("用量 = %+v, %v", summary, err)
 	}
 
-	// 打印分析结果（可选，用于调试）
-	t.Logf("运动数据分析结果：\n%s", result)
+	// 命中缓存时不请求高驰接口，高驰不可用时仍返回已生成的报告
+	corosDown.Store(true)
+	cached, err := registry.Analyze(context.Background(), corosService, reports, coros.DefaultAccountID, labelID, sportType, analyzer.Options{})
+	if err != nil {
+		t.Fatalf("RunAnalyzer 执行失败: %v", err)
+	}
+	if !cached.Cached || cached.Content != result.Content {
+		t.Errorf("第二次分析未命中缓存: %+v", cached)
+	}
+	t.Logf("运动数据分析结果(%s)：\n%s", result.Provider, result.Content)
 }
 
-func TestGeminiClient(t *testing.T) {
-	// 1. 加载配置
-	cfg, err := config.LoadConfig("../../configs/config.json")
+func TestAnalyzeVerifiesNumbers(t *testing.T) {
+	accounts, err := coros.NewAccountStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("创建账号存储失败: %v", err)
+	}
+	corosServer := httptest.NewServer(fakeserver.New(nil))
+	defer corosServer.Close()
+
+	// 第一次回答的总距离是错的，收到修正说明后不再引用数值
+	corosCfg := &config.CorosConfig{Username: 15659295082, Password: "test", Address: corosServer.URL}
+	registry := newRegistry(t, fakeConfig(&config.FakeConfig{
+		Template: `{{if contains .Prompt "不一致"}}### 修正后的报告{{else}}- 总距离：99.00 km{{end}}`,
+	}), nil)
+	corosService := coros.NewCorosService(corosCfg, accounts, coros.NewDailyStore(t.TempDir()), activity.NewActivityService(t.TempDir()))
+	reports := report.NewReportService(t.TempDir())
+
+	result, err := registry.Analyze(context.Background(), corosService, reports, coros.DefaultAccountID, "472913588747534541", sport.FromCoros(100), analyzer.Options{})
+	if err != nil {
+		t.Fatalf("分析失败: %v", err)
+	}
+	if result.Content != "### 修正后的报告" || result.Verification == nil || !result.Verification.Regenerated || len(result.Verification.Mismatches) != 0 {
+		t.Errorf("报告 = %q, 核对结果 = %+v", result.Content, result.Verification)
+	}
+
+	// 流式报告已经发送，只记录不符的数值
+	var streamed strings.Builder
+	result, err = registry.AnalyzeStream(context.Background(), corosService, reports, coros.DefaultAccountID, "472913588747534541", sport.FromCoros(100), analyzer.Options{Refresh: true}, func(delta string) error {
+		streamed.WriteString(delta)
+		return nil
+	})
 	if err != nil {
-		t.Fatalf("加载配置失败: %v", err)
+		t.Fatalf("流式分析失败: %v", err)
+	}
+	if v := result.Verification; streamed.String() != "- 总距离：99.00 km" || v == nil || v.Regenerated || len(v.Mismatches) != 1 || v.Mismatches[0].Reported != "99.00 km" {
+		t.Errorf("流式报告 = %q, 核对结果 = %+v", streamed.String(), v)
 	}
 
-	aiService, err := aiservice.NewAIService(&cfg.AI)
+	// 重新生成的报告错误更多时保留原来的报告
+	registry = newRegistry(t, fakeConfig(&config.FakeConfig{
+		Template: `- 总距离：99.00 km{{if contains .Prompt "不一致"}}` + "\n" + `- 平均配速：9:59 /km{{end}}`,
+	}), nil)
+	result, err = registry.Analyze(context.Background(), corosService, reports, coros.DefaultAccountID, "472913588747534541", sport.FromCoros(100), analyzer.Options{Refresh: true})
+	if err != nil {
+		t.Fatalf("分析失败: %v", err)
+	}
+	if v := result.Verification; result.Content != "- 总距离：99.00 km" || v == nil || v.Regenerated || len(v.Mismatches) != 1 {
+		t.Errorf("报告 = %q, 核对结果 = %+v", result.Content, v)
+	}
+}
+
+func TestGeminiClient(t *testing.T) {
+	// 模拟 Gemini 的 OpenAI 兼容接口
+	var model string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		model = body.Model
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"我是 Gemini"}}]}`)
+	}))
+	defer server.Close()
+
+	aiService, err := aiservice.NewAIService(&config.AIConfig{
+		Provider: "gemini",
+		Config:   config.AIProviderConfig{BaseURL: server.URL, APIKey: "test", Model: "gemini-2.5-flash"},
+	})
 	if err != nil {
 		t.Fatalf("创建AI服务失败: %v", err)
 	}
@@ -79,6 +217,7 @@ func TestGeminiClient(t *testing.T) {
 	if err != nil {
 		t.Fatalf("AI聊天失败: %v", err)
 	}
-
-	t.Logf("AI回复: %s", response)
+	if response != "我是 Gemini" || model != "gemini-2.5-flash" {
+		t.Errorf("AI回复 = %q, 模型 = %q", response, model)
+	}
 }
@@ -0,0 +1,104 @@
+package ai_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"fitgo/internal/service/ai/client"
+	"fitgo/internal/service/ai/fake"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/pkg/config"
+)
+
+func newFakeService(t *testing.T, entries ...config.AIProviderEntry) *aiservice.AIService {
+	t.Helper()
+	s, err := aiservice.NewAIService(&config.AIConfig{Providers: entries, BreakerThreshold: -1})
+	if err != nil {
+		t.Fatalf("创建 AI 服务失败: %v", err)
+	}
+	return s
+}
+
+func fakeEntry(name string, priority int, fakeCfg *config.FakeConfig) config.AIProviderEntry {
+	return config.AIProviderEntry{Name: name, Provider: "fake", Priority: priority, Config: config.AIProviderConfig{Model: name + "-model", Fake: fakeCfg}}
+}
+
+func TestFakeScriptedReplies(t *testing.T) {
+	s := newFakeService(t, fakeEntry("fake", 0, &config.FakeConfig{Replies: []string{"第一", "第二"}}))
+	messages := []client.ChatMessage{{Role: "user", Content: "hi"}}
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		resp, err := s.Complete(context.Background(), aiservice.TaskChat, messages)
+		if err != nil {
+			t.Fatalf("调用失败: %v", err)
+		}
+		got = append(got, resp.Content)
+	}
+	if strings.Join(got, ",") != "第一,第二,第一" {
+		t.Errorf("回答 = %v", got)
+	}
+
+	// 模板可以使用提示词和摘要，摘要只取决于消息
+	s = newFakeService(t, fakeEntry("fake", 0, &config.FakeConfig{Template: "{{.Call}}:{{.Prompt}}:{{.Digest}}"}))
+	resp, _ := s.Complete(context.Background(), aiservice.TaskChat, messages)
+	if resp.Content != "1:hi:"+fake.Digest(messages) || fake.Digest(messages) == fake.Digest([]client.ChatMessage{{Role: "user", Content: "hi!"}}) {
+		t.Errorf("模板回答 = %q", resp.Content)
+	}
+}
+
+func TestFakeFailureFallback(t *testing.T) {
+	// primary 每次都失败，回退到 backup
+	s := newFakeService(t,
+		fakeEntry("primary", 0, &config.FakeConfig{FailEvery: 1, Error: "upstream unavailable"}),
+		fakeEntry("backup", 1, &config.FakeConfig{Replies: []string{"备用回答"}}),
+	)
+	resp, err := s.Complete(context.Background(), aiservice.TaskChat, []client.ChatMessage{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("调用失败: %v", err)
+	}
+	if resp.Provider != "backup" || resp.Content != "备用回答" {
+		t.Errorf("响应 = %+v", resp)
+	}
+
+	// 流式输出开始后失败不再回退
+	s = newFakeService(t,
+		fakeEntry("primary", 0, &config.FakeConfig{Replies: []string{"0123456789abcdef"}, ChunkSize: 4, FailEvery: 1, FailAfter: 2}),
+		fakeEntry("backup", 1, nil),
+	)
+	var deltas []string
+	resp, err = s.CompleteStream(context.Background(), aiservice.TaskChat, []client.ChatMessage{{Role: "user", Content: "hi"}}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if !errors.Is(err, fake.ErrSimulated) || strings.Join(deltas, "|") != "0123|4567" || resp.Provider != "primary" {
+		t.Errorf("流式失败: err = %v, deltas = %v, resp = %+v", err, deltas, resp)
+	}
+}
+
+func TestFakeStreamAndLatency(t *testing.T) {
+	s := newFakeService(t, fakeEntry("fake", 0, &config.FakeConfig{Replies: []string{"逐段输出的回答"}, ChunkSize: 2, LatencyMs: 200}))
+
+	var deltas []string
+	resp, err := s.CompleteStream(context.Background(), aiservice.TaskChat, []client.ChatMessage{{Role: "user", Content: "hi"}}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil || resp.Content != "逐段输出的回答" || len(deltas) != 4 {
+		t.Errorf("流式回答 = %q, %v, err = %v", resp.Content, deltas, err)
+	}
+
+	// 延迟期间 ctx 取消时立即返回
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := s.Complete(ctx, aiservice.TaskChat, []client.ChatMessage{{Role: "user", Content: "hi"}}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("超时 err = %v", err)
+	}
+	if time.Since(start) > 150*time.Millisecond {
+		t.Errorf("取消后等待了 %v", time.Since(start))
+	}
+}
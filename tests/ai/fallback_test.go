@@ -0,0 +1,170 @@
+package ai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"fitgo/internal/service/ai/client"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/pkg/config"
+)
+
+// chatServer 模拟 OpenAI 兼容接口，fail 为 true 时返回 503，hits 记录请求次数
+func chatServer(t *testing.T, reply string, fail bool, hits *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		if fail {
+			http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Accept") == "text/event-stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", reply)
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		fmt.Fprintf(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":%q}}]}`, reply)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func entry(name string, priority int, baseURL string) config.AIProviderEntry {
+	return config.AIProviderEntry{
+		Name:     name,
+		Provider: "qwen",
+		Priority: priority,
+		Config:   config.AIProviderConfig{BaseURL: baseURL, APIKey: "test", Model: name + "-model"},
+	}
+}
+
+var hello = []client.ChatMessage{{Role: "user", Content: "hi"}}
+
+func TestProviderFallback(t *testing.T) {
+	var primaryHits, backupHits int32
+	primary := chatServer(t, "", true, &primaryHits)
+	backup := chatServer(t, "备用模型回复", false, &backupHits)
+
+	service, err := aiservice.NewAIService(&config.AIConfig{
+		// 优先级决定顺序，与声明顺序无关
+		Providers:        []config.AIProviderEntry{entry("backup", 2, backup.URL), entry("primary", 1, primary.URL)},
+		BreakerThreshold: 2,
+		BreakerCooldown:  60,
+	})
+	if err != nil {
+		t.Fatalf("创建 AI 服务失败: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		resp, err := service.Complete(context.Background(), aiservice.TaskSummary, hello)
+		if err != nil {
+			t.Fatalf("第 %d 次调用失败: %v", i+1, err)
+		}
+		if resp.Content != "备用模型回复" || resp.Provider != "backup" || resp.Model != "backup-model" {
+			t.Errorf("第 %d 次响应 = %+v", i+1, resp)
+		}
+	}
+
+	// 主提供者连续失败 2 次后熔断，第 3 次直接跳过
+	if primaryHits != 2 || backupHits != 3 {
+		t.Errorf("primary 请求 %d 次, backup 请求 %d 次", primaryHits, backupHits)
+	}
+
+	// 熔断器属于服务实例，新创建的服务从健康状态开始
+	again, _ := aiservice.NewAIService(&config.AIConfig{
+		Providers:        []config.AIProviderEntry{entry("primary", 1, primary.URL), entry("backup", 2, backup.URL)},
+		BreakerThreshold: 2,
+		BreakerCooldown:  60,
+	})
+	if _, err := again.Chat(context.Background(), hello); err != nil || primaryHits != 3 {
+		t.Errorf("重新创建后 primary 请求 %d 次, err = %v", primaryHits, err)
+	}
+}
+
+func TestProviderAllFailed(t *testing.T) {
+	var hits int32
+	down := chatServer(t, "", true, &hits)
+
+	service, _ := aiservice.NewAIService(&config.AIConfig{
+		Providers:        []config.AIProviderEntry{entry("a", 1, down.URL), entry("b", 2, down.URL)},
+		BreakerThreshold: -1,
+	})
+	_, err := service.Complete(context.Background(), aiservice.TaskSummary, hello)
+	if !errors.Is(err, aiservice.ErrNoProvider) {
+		t.Fatalf("err = %v, 期望 ErrNoProvider", err)
+	}
+	if hits != 2 {
+		t.Errorf("请求次数 = %d", hits)
+	}
+}
+
+func TestProviderRouting(t *testing.T) {
+	var smallHits, largeHits int32
+	small := chatServer(t, "小模型", false, &smallHits)
+	large := chatServer(t, "大模型", false, &largeHits)
+
+	service, err := aiservice.NewAIService(&config.AIConfig{
+		Providers: []config.AIProviderEntry{entry("large", 1, large.URL), entry("small", 2, small.URL)},
+		Routes: []config.AIRouteConfig{
+			{Task: aiservice.TaskSummary, MaxInputChars: 100, Providers: []string{"small"}},
+			{Task: aiservice.TaskReview, Providers: []string{"large"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("创建 AI 服务失败: %v", err)
+	}
+
+	long := []client.ChatMessage{{Role: "user", Content: strings.Repeat("配速", 100)}}
+	cases := []struct {
+		task     string
+		messages []client.ChatMessage
+		want     string
+	}{
+		{aiservice.TaskSummary, hello, "small"},
+		{aiservice.TaskSummary, long, "large"}, // 超过 max_input_chars，按优先级
+		{aiservice.TaskReview, hello, "large"},
+		{aiservice.TaskChat, hello, "large"},
+	}
+	for _, c := range cases {
+		resp, err := service.Complete(context.Background(), c.task, c.messages)
+		if err != nil || resp.Provider != c.want {
+			t.Errorf("任务 %s(输入 %d 字符) 由 %v 应答, err = %v, 期望 %s", c.task, len([]rune(c.messages[0].Content)), resp, err, c.want)
+		}
+	}
+
+	if _, err := aiservice.NewAIService(&config.AIConfig{
+		Providers: []config.AIProviderEntry{entry("large", 1, large.URL)},
+		Routes:    []config.AIRouteConfig{{Task: aiservice.TaskSummary, Providers: []string{"missing"}}},
+	}); err == nil {
+		t.Error("路由规则引用未知提供者时应返回错误")
+	}
+}
+
+func TestProviderStreamFallback(t *testing.T) {
+	var primaryHits, backupHits int32
+	primary := chatServer(t, "", true, &primaryHits)
+	backup := chatServer(t, "流式备用", false, &backupHits)
+
+	service, _ := aiservice.NewAIService(&config.AIConfig{
+		Providers: []config.AIProviderEntry{entry("primary", 1, primary.URL), entry("backup", 2, backup.URL)},
+	})
+
+	var deltas []string
+	resp, err := service.CompleteStream(context.Background(), aiservice.TaskSummary, hello, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("流式调用失败: %v", err)
+	}
+	if resp.Provider != "backup" || resp.Content != "流式备用" || len(deltas) != 1 {
+		t.Errorf("响应 = %+v, deltas = %q", resp, deltas)
+	}
+}
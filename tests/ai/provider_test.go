@@ -0,0 +1,131 @@
+package ai_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fitgo/internal/service/ai/client"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/pkg/config"
+)
+
+// providerConfig 带附加请求头与采样参数的配置
+func providerConfig(provider, baseURL string) *config.AIConfig {
+	temperature, topP := 0.2, 0.9
+	return &config.AIConfig{
+		Provider: provider,
+		Config: config.AIProviderConfig{
+			BaseURL:     baseURL,
+			Model:       "test-model",
+			Headers:     map[string]string{"X-Gateway-Tenant": "fitgo"},
+			Temperature: &temperature,
+			TopP:        &topP,
+			MaxTokens:   512,
+		},
+	}
+}
+
+func TestOpenAICompatibleProvider(t *testing.T) {
+	var body map[string]interface{}
+	var tenant string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		tenant = r.Header.Get("X-Gateway-Tenant")
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"兼容接口回复"}}]}`)
+	}))
+	defer server.Close()
+
+	service, err := aiservice.NewAIService(providerConfig("openai-compatible", server.URL+"/v1"))
+	if err != nil {
+		t.Fatalf("创建 AI 服务失败: %v", err)
+	}
+	reply, err := service.Chat(context.Background(), []client.ChatMessage{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("调用失败: %v", err)
+	}
+
+	if reply != "兼容接口回复" {
+		t.Errorf("reply = %q", reply)
+	}
+	if tenant != "fitgo" {
+		t.Errorf("X-Gateway-Tenant = %q", tenant)
+	}
+	if body["model"] != "test-model" || body["max_tokens"] != 512.0 || body["temperature"] != 0.2 || body["top_p"] != 0.9 {
+		t.Errorf("请求体 = %v", body)
+	}
+}
+
+func TestOllamaProvider(t *testing.T) {
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" || r.Header.Get("X-Gateway-Tenant") != "fitgo" {
+			http.NotFound(w, r)
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, body)
+
+		if body["stream"] == true {
+			for _, chunk := range []string{"本地", "模型"} {
+				fmt.Fprintf(w, "{\"message\":{\"role\":\"assistant\",\"content\":%q},\"done\":false}\n", chunk)
+			}
+			fmt.Fprint(w, "{\"message\":{\"role\":\"assistant\",\"content\":\"\"},\"done\":true}\n")
+			return
+		}
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"本地模型回复"},"done":true}`)
+	}))
+	defer server.Close()
+
+	service, err := aiservice.NewAIService(providerConfig("ollama", server.URL))
+	if err != nil {
+		t.Fatalf("创建 AI 服务失败: %v", err)
+	}
+	messages := []client.ChatMessage{{Role: "user", Content: "hi"}}
+
+	reply, err := service.Chat(context.Background(), messages)
+	if err != nil || reply != "本地模型回复" {
+		t.Fatalf("Chat = %q, %v", reply, err)
+	}
+
+	var deltas []string
+	full, err := service.ChatStream(context.Background(), messages, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil || full != "本地模型" || len(deltas) != 2 {
+		t.Fatalf("ChatStream = %q, %v, deltas = %q", full, err, deltas)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("请求次数 = %d", len(requests))
+	}
+	options, _ := requests[0]["options"].(map[string]interface{})
+	if options["temperature"] != 0.2 || options["top_p"] != 0.9 || options["num_predict"] != 512.0 {
+		t.Errorf("options = %v", options)
+	}
+}
+
+func TestOllamaProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"model 'test-model' not found"}`)
+	}))
+	defer server.Close()
+
+	service, err := aiservice.NewAIService(providerConfig("ollama", server.URL))
+	if err != nil {
+		t.Fatalf("创建 AI 服务失败: %v", err)
+	}
+	if _, err := service.Chat(context.Background(), []client.ChatMessage{{Role: "user", Content: "hi"}}); err == nil {
+		t.Error("模型不存在时应返回错误")
+	}
+}
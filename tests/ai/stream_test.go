@@ -0,0 +1,141 @@
+package ai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fitgo/internal/service/ai/client"
+	"fitgo/internal/service/ai/gemini"
+	"fitgo/internal/service/ai/qwen"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/pkg/config"
+)
+
+// sseServer 模拟 OpenAI 兼容的流式接口，逐个发送 chunks；hold 为 true 时发送完不结束，直到客户端断开
+func sseServer(t *testing.T, chunks []string, hold bool, disconnected chan<- struct{}) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", chunk)
+			flusher.Flush()
+		}
+		if hold {
+			<-r.Context().Done()
+			close(disconnected)
+			return
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func streamClients(baseURL string) map[string]client.AIClient {
+	cfg := &config.AIConfig{}
+	cfg.Config.BaseURL = baseURL
+	cfg.Config.APIKey = "test"
+	cfg.Config.Model = "test-model"
+	qwenClient, _ := qwen.NewClient(cfg)
+	geminiClient, _ := gemini.NewClient(cfg)
+	return map[string]client.AIClient{"qwen": qwenClient, "gemini": geminiClient}
+}
+
+func TestChatStream(t *testing.T) {
+	server := sseServer(t, []string{"### 一、", "运动表现", "总结"}, false, nil)
+
+	for name, c := range streamClients(server.URL) {
+		t.Run(name, func(t *testing.T) {
+			var deltas []string
+			full, err := c.ChatStream(context.Background(), []client.ChatMessage{{Role: "user", Content: "hi"}}, func(delta string) error {
+				deltas = append(deltas, delta)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("流式调用失败: %v", err)
+			}
+			if len(deltas) != 3 || full != strings.Join(deltas, "") || full != "### 一、运动表现总结" {
+				t.Errorf("deltas = %q, full = %q", deltas, full)
+			}
+		})
+	}
+}
+
+func TestChatStreamCancel(t *testing.T) {
+	for _, name := range []string{"qwen", "gemini"} {
+		t.Run(name, func(t *testing.T) {
+			disconnected := make(chan struct{})
+			server := sseServer(t, []string{"第一段"}, true, disconnected)
+			c := streamClients(server.URL)[name]
+
+			// 模拟浏览器在收到第一段后断开
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			_, err := c.ChatStream(ctx, []client.ChatMessage{{Role: "user", Content: "hi"}}, func(delta string) error {
+				cancel()
+				return ctx.Err()
+			})
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("期望 context.Canceled，实际 %v", err)
+			}
+
+			select {
+			case <-disconnected:
+			case <-time.After(2 * time.Second):
+				t.Error("取消后上游请求没有断开")
+			}
+		})
+	}
+}
+
+func TestChatStreamTimeout(t *testing.T) {
+	// 每段间隔 400 毫秒，总时长超过 1 秒的超时；stall 为 true 时第一段后停止输出
+	slowServer := func(stall bool) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			flusher := w.(http.Flusher)
+			for i := 0; i < 4; i++ {
+				fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"%d\"}}]}\n\n", i)
+				flusher.Flush()
+				if stall {
+					<-r.Context().Done()
+					return
+				}
+				time.Sleep(400 * time.Millisecond)
+			}
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	newService := func(baseURL string) *aiservice.AIService {
+		s, err := aiservice.NewAIService(&config.AIConfig{
+			Provider: "gemini",
+			Config:   config.AIProviderConfig{BaseURL: baseURL, APIKey: "test", Model: "test-model", Timeout: 1},
+		})
+		if err != nil {
+			t.Fatalf("创建AI服务失败: %v", err)
+		}
+		return s
+	}
+	messages := []client.ChatMessage{{Role: "user", Content: "hi"}}
+
+	// 超时只限制两段输出之间的间隔，长报告不会被截断
+	full, err := newService(slowServer(false).URL).ChatStream(context.Background(), messages, func(string) error { return nil })
+	if err != nil || full != "0123" {
+		t.Fatalf("流式响应 = %q, %v", full, err)
+	}
+
+	start := time.Now()
+	full, err = newService(slowServer(true).URL).ChatStream(context.Background(), messages, func(string) error { return nil })
+	if err == nil || full != "0" || time.Since(start) > 3*time.Second {
+		t.Errorf("停止输出的流式响应 = %q, %v, 耗时 %v", full, err, time.Since(start))
+	}
+}
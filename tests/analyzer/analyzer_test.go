@@ -0,0 +1,138 @@
+package analyzer_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"fitgo/internal/service/ai/prompt"
+	"fitgo/internal/service/analyzer"
+	"fitgo/internal/service/analyzer/builtin"
+	"fitgo/internal/service/analyzer/running"
+	"fitgo/internal/service/coros"
+	"fitgo/pkg/config"
+	"fitgo/pkg/sport"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := builtin.Registry()
+	for sp, want := range map[sport.Sport]string{
+		sport.TrailRun:      "running",
+		sport.IndoorBike:    "cycling",
+		sport.OpenWaterSwim: "swimming",
+		sport.Cardio:        "strength",
+		sport.Hike:          "generic",
+		sport.Unknown:       "generic",
+	} {
+		a, err := registry.Lookup(sp)
+		if err != nil || a.Name() != want {
+			t.Errorf("%s 的分析器 = %v, err = %v, 期望 %s", sp, a, err, want)
+		}
+	}
+
+	// 没有回退分析器时未注册的运动类型不支持
+	runningOnly := analyzer.NewRegistry(nil).Register(running.Analyzer{})
+	if _, err := runningOnly.Lookup(sport.Bike); !errors.Is(err, analyzer.ErrUnsupportedSport) {
+		t.Errorf("骑行 err = %v", err)
+	}
+}
+
+// render 用内置模板渲染 detail，fits 为 nil 时不限制 token
+func render(t *testing.T, detail *coros.SportsSummaryResult, lang string, fits func(string) bool) *analyzer.Prompt {
+	t.Helper()
+	store, err := prompt.NewStore(config.PromptConfig{})
+	if err != nil {
+		t.Fatalf("加载内置模板失败: %v", err)
+	}
+	tmpl, err := store.Lookup(detail.Sport, prompt.TaskSummary, lang)
+	if err != nil {
+		t.Fatalf("查找模板失败: %v", err)
+	}
+	a, _ := builtin.Registry().Lookup(detail.Sport)
+	if fits == nil {
+		fits = func(string) bool { return true }
+	}
+	p, err := a.Render(tmpl, &analyzer.Input{Detail: detail}, fits)
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	return p
+}
+
+func TestCyclingAnalyzer(t *testing.T) {
+	detail := &coros.SportsSummaryResult{
+		Sport: sport.Bike,
+		Summary: map[string]interface{}{
+			"distance": 4000000.0, "workoutTime": 480000.0, "avgSpeed": 30.0, "maxSpeed": 52.3,
+			"avgPower": 200.0, "np": 220.0, "avgCadence": 88.0, "avgHr": 140.0,
+		},
+		LapList: []map[string]interface{}{
+			{"lapIndex": 1.0, "distance": 2000000.0, "time": 240000.0, "avgPower": 210.0, "avgCadence": 90.0},
+			{"lapIndex": 2.0, "distance": 2000000.0, "time": 240000.0, "avgPower": 190.0, "avgCadence": 86.0},
+		},
+	}
+	p := render(t, detail, "", nil)
+	for _, want := range []string{"运动类型：骑行", "- 标准化功率：220 W", "- 功率变异指数：1.10", "- 做功：960 kJ", "- 平均踏频：88 rpm", "| 1 | 20.00 km | 40:00 | 30.0 km/h |  | 210 W |  | 90 rpm | 0 m |"} {
+		if !strings.Contains(p.Content, want) {
+			t.Errorf("骑行提示词缺少 %q:\n%s", want, p.Content)
+		}
+	}
+	if strings.Contains(p.Content, "/km") || p.Compaction != "" {
+		t.Errorf("骑行提示词不应包含配速:\n%s", p.Content)
+	}
+
+	// 超出预算时省略分段
+	p = render(t, detail, "en", func(content string) bool { return !strings.Contains(content, "| 1 |") })
+	if !strings.Contains(p.Content, "Laps: 2 laps omitted") || p.Compaction == "" || p.Data.(*prompt.CyclingSummary).LapsOmitted != 2 {
+		t.Errorf("省略分段后: %q\n%s", p.Compaction, p.Content)
+	}
+}
+
+func TestSwimmingAnalyzer(t *testing.T) {
+	detail := &coros.SportsSummaryResult{
+		Sport: sport.PoolSwim,
+		Summary: map[string]interface{}{
+			"distance": 150000.0, "workoutTime": 180000.0, "avgPace": 1200.0, "poolLength": 2500.0,
+			"avgSwolf": 38.0, "avgStrokeRate": 30.0, "totalStrokes": 900.0, "avgCadence": 30.0,
+		},
+		LapList: []map[string]interface{}{
+			{"distance": 10000.0, "time": 11000.0, "swolf": 36.0, "strokeRate": 31.0, "strokes": 60.0},
+			{"distance": 10000.0, "time": 12500.0, "swolf": 40.0, "strokeRate": 29.0, "strokes": 62.0},
+		},
+	}
+	p := render(t, detail, "", nil)
+	for _, want := range []string{"- 泳池长度：25 m", "- 平均配速：2:00 /100m", "- 最快分段配速：1:50 /100m", "- 平均 SWOLF：38", "- 平均划频：30 strokes/min", "- 平均每次划水距离：1.67 m", "| 2 | 100 m | 2:05 | 2:05 /100m | 40 | 29 strokes/min | 62 |  |"} {
+		if !strings.Contains(p.Content, want) {
+			t.Errorf("游泳提示词缺少 %q:\n%s", want, p.Content)
+		}
+	}
+	if strings.Contains(p.Content, "/km") || strings.Contains(p.Content, "spm") {
+		t.Errorf("游泳提示词不应包含跑步配速和步频:\n%s", p.Content)
+	}
+}
+
+func TestStrengthAndGenericAnalyzers(t *testing.T) {
+	strength := &coros.SportsSummaryResult{
+		Sport:   sport.Strength,
+		Summary: map[string]interface{}{"workoutTime": 360000.0, "avgHr": 110.0},
+		LapList: []map[string]interface{}{
+			{"name": "深蹲", "reps": 10.0, "weight": 60.0, "time": 6000.0},
+			{"name": "深蹲", "reps": 8.0, "weight": 70.0, "time": 5500.0},
+		},
+	}
+	p := render(t, strength, "", nil)
+	for _, want := range []string{"- 组数：2", "- 总次数：18", "- 总负荷量：1160.0 kg", "| 2 | 深蹲 | 8 | 70.0 kg | 0:55 |"} {
+		if !strings.Contains(p.Content, want) {
+			t.Errorf("力量训练提示词缺少 %q:\n%s", want, p.Content)
+		}
+	}
+
+	hike := &coros.SportsSummaryResult{
+		Sport:   sport.Hike,
+		Summary: map[string]interface{}{"distance": 1200000.0, "elevGain": 850.0},
+	}
+	p = render(t, hike, "", nil)
+	if !strings.Contains(p.Content, "运动类型：徒步") || !strings.Contains(p.Content, "- 累计爬升：850 m") || !strings.Contains(p.Content, "分段：无") {
+		t.Errorf("通用提示词:\n%s", p.Content)
+	}
+}
@@ -0,0 +1,194 @@
+package chat_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"fitgo/internal/service/activity"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/chat"
+	"fitgo/internal/service/report"
+	"fitgo/pkg/config"
+)
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// recorder 模拟 OpenAI 兼容接口，记录每次请求的消息，摘要请求和普通提问返回不同的内容
+type recorder struct {
+	mu       sync.Mutex
+	requests [][]chatMessage
+}
+
+func (rec *recorder) serve(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []chatMessage `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		rec.mu.Lock()
+		rec.requests = append(rec.requests, body.Messages)
+		n := len(rec.requests)
+		rec.mu.Unlock()
+
+		reply := fmt.Sprintf("回答%d", n)
+		if strings.Contains(body.Messages[0].Content, "归纳为简短的摘要") {
+			reply = "摘要内容"
+		}
+		fmt.Fprintf(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":%q}}]}`, reply)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (rec *recorder) last() []chatMessage {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.requests[len(rec.requests)-1]
+}
+
+func newService(t *testing.T, rec *recorder, limits config.ChatConfig) (chat.ChatService, report.ReportService, *activity.Activity) {
+	t.Helper()
+	dataDir := t.TempDir()
+
+	data, err := os.ReadFile("../../internal/service/coros/fakeserver/fixtures/files/472913588747534700.tcx")
+	if err != nil {
+		t.Fatalf("读取夹具失败: %v", err)
+	}
+	activities := activity.NewActivityService(dataDir)
+	a, err := activities.Ingest("run.tcx", data, activity.Source{Name: activity.SourceUpload})
+	if err != nil {
+		t.Fatalf("导入活动失败: %v", err)
+	}
+
+	ai, err := aiservice.NewAIService(&config.AIConfig{
+		Provider: "qwen",
+		Config:   config.AIProviderConfig{BaseURL: rec.serve(t).URL, APIKey: "test", Model: "chat-model"},
+	})
+	if err != nil {
+		t.Fatalf("创建 AI 服务失败: %v", err)
+	}
+
+	reports := report.NewReportService(dataDir)
+	return chat.NewChatService(dataDir, limits, activities, reports, ai), reports, a
+}
+
+func TestChatReusesHistory(t *testing.T) {
+	rec := &recorder{}
+	service, reports, a := newService(t, rec, config.ChatConfig{})
+
+	if _, err := reports.Save(&report.Report{ActivityID: a.ID, Content: "上一份报告：后半程心率漂移明显", Format: report.FormatMarkdown}); err != nil {
+		t.Fatalf("保存报告失败: %v", err)
+	}
+
+	session, err := service.Create(a.ID)
+	if err != nil {
+		t.Fatalf("创建会话失败: %v", err)
+	}
+	if !strings.Contains(session.Context, "上一份报告") || !strings.Contains(session.Context, "分段") {
+		t.Errorf("上下文缺少报告或分段:\n%s", session.Context)
+	}
+
+	reply, err := service.Send(context.Background(), session.ID, "我的配速稳定吗？")
+	if err != nil {
+		t.Fatalf("第一次提问失败: %v", err)
+	}
+	if reply.Content != "回答1" || reply.Model != "chat-model" {
+		t.Errorf("回答 = %+v", reply)
+	}
+
+	if _, err := service.Send(context.Background(), session.ID, "那心率呢？"); err != nil {
+		t.Fatalf("第二次提问失败: %v", err)
+	}
+	got := rec.last()
+	want := []string{"system", "user", "assistant", "user"}
+	if len(got) != len(want) {
+		t.Fatalf("第二次请求有 %d 条消息, 期望 %d", len(got), len(want))
+	}
+	for i, m := range got {
+		if m.Role != want[i] {
+			t.Errorf("第 %d 条消息角色 = %s, 期望 %s", i, m.Role, want[i])
+		}
+	}
+	if got[1].Content != "我的配速稳定吗？" || got[2].Content != "回答1" {
+		t.Errorf("历史没有被复用: %+v", got)
+	}
+
+	saved, err := service.Get(session.ID)
+	if err != nil {
+		t.Fatalf("读取会话失败: %v", err)
+	}
+	if len(saved.Messages) != 4 || saved.Title != "我的配速稳定吗？" {
+		t.Errorf("会话 = %d 条消息, 标题 %q", len(saved.Messages), saved.Title)
+	}
+
+	sessions, err := service.List(a.ID)
+	if err != nil || len(sessions) != 1 || sessions[0].Messages != nil {
+		t.Errorf("List = %+v, %v", sessions, err)
+	}
+
+	if err := service.Delete(session.ID); err != nil {
+		t.Fatalf("删除会话失败: %v", err)
+	}
+	if _, err := service.Get(session.ID); !errors.Is(err, chat.ErrSessionNotFound) {
+		t.Errorf("删除后 Get 错误 = %v", err)
+	}
+}
+
+func TestChatSummarizesLongHistory(t *testing.T) {
+	rec := &recorder{}
+	service, _, a := newService(t, rec, config.ChatConfig{MaxHistoryChars: 40, KeepRecent: 2})
+
+	session, err := service.Create(a.ID)
+	if err != nil {
+		t.Fatalf("创建会话失败: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := service.Send(context.Background(), session.ID, fmt.Sprintf("第%d个问题，请详细说明这一段的表现", i+1)); err != nil {
+			t.Fatalf("第 %d 次提问失败: %v", i+1, err)
+		}
+	}
+
+	saved, err := service.Get(session.ID)
+	if err != nil {
+		t.Fatalf("读取会话失败: %v", err)
+	}
+	if saved.Summary != "摘要内容" || saved.Summarized == 0 {
+		t.Errorf("Summary = %q, Summarized = %d", saved.Summary, saved.Summarized)
+	}
+	if len(saved.Messages) != 8 {
+		t.Errorf("完整历史应保留 8 条消息, 实际 %d", len(saved.Messages))
+	}
+
+	// 最后一次提问只携带摘要、最近 2 条消息和本次提问
+	got := rec.last()
+	if len(got) != 4 || !strings.Contains(got[0].Content, "摘要内容") {
+		t.Errorf("压缩后的请求 = %+v", got)
+	}
+}
+
+func TestChatRejectsEmptyMessage(t *testing.T) {
+	service, _, a := newService(t, &recorder{}, config.ChatConfig{})
+	session, err := service.Create(a.ID)
+	if err != nil {
+		t.Fatalf("创建会话失败: %v", err)
+	}
+	if _, err := service.Send(context.Background(), session.ID, "  "); !errors.Is(err, chat.ErrEmptyMessage) {
+		t.Errorf("空消息错误 = %v", err)
+	}
+	if _, err := service.Create("missing"); !errors.Is(err, activity.ErrActivityNotFound) {
+		t.Errorf("不存在的活动错误 = %v", err)
+	}
+}
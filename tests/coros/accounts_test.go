@@ -0,0 +1,28 @@
+package coros_test
+
+import (
+	"errors"
+	"testing"
+
+	"fitgo/internal/service/coros"
+)
+
+func TestAccountStoreErrors(t *testing.T) {
+	store, err := coros.NewAccountStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("创建账号存储失败: %v", err)
+	}
+
+	if _, err := store.Add(&coros.Account{Username: 1}); !errors.Is(err, coros.ErrInvalidAccount) {
+		t.Errorf("缺少密码时的错误 = %v", err)
+	}
+	if _, err := store.Add(&coros.Account{ID: "a", Username: 1, Password: "p"}); err != nil {
+		t.Fatalf("注册账号失败: %v", err)
+	}
+	if _, err := store.Add(&coros.Account{ID: "a", Username: 2, Password: "p"}); !errors.Is(err, coros.ErrAccountExists) {
+		t.Errorf("重复ID的错误 = %v", err)
+	}
+	if _, err := store.Add(&coros.Account{ID: "b", Username: 1, Password: "p"}); !errors.Is(err, coros.ErrAccountExists) {
+		t.Errorf("重复高驰账号的错误 = %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package coros_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"fitgo/internal/service/coros"
+	"fitgo/internal/service/coros/fakeserver"
+	"fitgo/pkg/config"
+	"fitgo/pkg/sport"
+)
+
+const fixtureLabelID = "472913588747534541"
+
+func TestFakeServerReplay(t *testing.T) {
+	server := httptest.NewServer(fakeserver.New(nil))
+	defer server.Close()
+
+	service := newTestService(t, server.URL, config.CorosHTTPConfig{})
+
+	token, err := service.Login(context.Background(), coros.DefaultAccountID)
+	if err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+	if token != fakeserver.FakeToken {
+		t.Errorf("token = %q", token)
+	}
+
+	list, err := service.ActivityList(context.Background(), coros.DefaultAccountID, 2, 1, 1)
+	if err != nil {
+		t.Fatalf("获取活动列表失败: %v", err)
+	}
+	data := list["data"].(map[string]interface{})
+	if got := len(data["dataList"].([]interface{})); got != 2 {
+		t.Errorf("第一页应有 2 条记录，实际 %d 条", got)
+	}
+	first := data["dataList"].([]interface{})[0].(map[string]interface{})
+	if first["sport"] != sport.Run {
+		t.Errorf("活动应附加统一运动类型，实际 %v", first["sport"])
+	}
+	if data["totalCount"].(float64) < 3 {
+		t.Errorf("totalCount = %v", data["totalCount"])
+	}
+
+	summary, err := service.SportsSummary(context.Background(), coros.DefaultAccountID, fixtureLabelID, sport.Run)
+	if err != nil {
+		t.Fatalf("获取活动详情失败: %v", err)
+	}
+	if len(summary.LapList) != 10 {
+		t.Errorf("期望 10 个每公里分段，实际 %d 个", len(summary.LapList))
+	}
+	if summary.Sport != sport.Run {
+		t.Errorf("summary.Sport = %s", summary.Sport)
+	}
+	if summary.Summary["labelId"] != fixtureLabelID {
+		t.Errorf("summary.labelId = %v", summary.Summary["labelId"])
+	}
+
+	if _, err := service.SportsSummary(context.Background(), coros.DefaultAccountID, "missing", sport.Run); err == nil {
+		t.Error("不存在的活动应返回错误")
+	}
+}
+
+func TestRecorderRedactsCredentials(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":"0000","message":"OK","data":{"accessToken":"real-token","userId":"42","regionId":1}}`))
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	recorder := httptest.NewServer(fakeserver.NewRecorder(upstream.URL, dir))
+	defer recorder.Close()
+
+	service := newTestService(t, recorder.URL, config.CorosHTTPConfig{})
+	token, err := service.Login(context.Background(), coros.DefaultAccountID)
+	if err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+	if token != "real-token" {
+		t.Errorf("录制模式应返回原始响应，token = %q", token)
+	}
+
+	saved, err := os.ReadFile(filepath.Join(dir, "login.json"))
+	if err != nil {
+		t.Fatalf("未生成夹具: %v", err)
+	}
+	if strings.Contains(string(saved), "real-token") || strings.Contains(string(saved), `"42"`) {
+		t.Errorf("夹具未脱敏: %s", saved)
+	}
+	if !strings.Contains(string(saved), "regionId") {
+		t.Errorf("非敏感字段不应被删除: %s", saved)
+	}
+}
+
+func TestDailyMetricsFromFakeServer(t *testing.T) {
+	server := httptest.NewServer(fakeserver.New(nil))
+	defer server.Close()
+
+	service := newTestService(t, server.URL, config.CorosHTTPConfig{})
+
+	days, err := service.DailyMetrics(context.Background(), coros.DefaultAccountID, "2025-10-10", "2025-10-12")
+	if err != nil {
+		t.Fatalf("获取每日数据失败: %v", err)
+	}
+	if len(days) != 3 {
+		t.Fatalf("期望 3 天数据，实际 %d 天", len(days))
+	}
+	if days[0].Date != "2025-10-10" || days[2].Date != "2025-10-12" {
+		t.Errorf("日期顺序错误: %s .. %s", days[0].Date, days[2].Date)
+	}
+	if days[2].TrainingLoad != 105 {
+		t.Errorf("10-12 训练负荷 = %d", days[2].TrainingLoad)
+	}
+	for _, day := range days {
+		if day.RestingHR == 0 || day.HRV == 0 || day.Sleep.TotalMinutes == 0 {
+			t.Errorf("%s 数据不完整: %+v", day.Date, day)
+		}
+	}
+
+	// 再次查询更大的区间，之前同步的数据仍然保留
+	days, err = service.DailyMetrics(context.Background(), coros.DefaultAccountID, "2025-10-01", "2025-10-12")
+	if err != nil {
+		t.Fatalf("获取每日数据失败: %v", err)
+	}
+	if len(days) != 12 {
+		t.Errorf("期望 12 天数据，实际 %d 天", len(days))
+	}
+
+	dashboard, err := service.Dashboard(context.Background(), coros.DefaultAccountID)
+	if err != nil {
+		t.Fatalf("获取仪表盘失败: %v", err)
+	}
+	if dashboard.HRVRange != [2]int{52, 68} || dashboard.Stamina == 0 {
+		t.Errorf("仪表盘数据错误: %+v", dashboard)
+	}
+}
+
+func TestDailyMetricsFallsBackToLocal(t *testing.T) {
+	var down atomic.Bool
+	fake := fakeserver.New(nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	service := newTestService(t, server.URL, config.CorosHTTPConfig{MaxRetries: -1})
+	if _, err := service.DailyMetrics(context.Background(), coros.DefaultAccountID, "2025-10-10", "2025-10-12"); err != nil {
+		t.Fatalf("获取每日数据失败: %v", err)
+	}
+
+	// 高驰不可用时返回已同步的数据，本地没有数据的区间仍然返回错误
+	down.Store(true)
+	days, err := service.DailyMetrics(context.Background(), coros.DefaultAccountID, "2025-10-10", "2025-10-12")
+	if err != nil || len(days) != 3 {
+		t.Fatalf("期望返回本地 3 天数据，实际 %d 天, err = %v", len(days), err)
+	}
+	if _, err := service.DailyMetrics(context.Background(), coros.DefaultAccountID, "2025-09-01", "2025-09-02"); err == nil {
+		t.Error("本地没有数据时应返回错误")
+	}
+}
@@ -0,0 +1,190 @@
+package coros_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/coros"
+	"fitgo/internal/service/workout"
+	"fitgo/pkg/config"
+	"fitgo/pkg/sport"
+)
+
+func newTestService(t *testing.T, address string, httpCfg config.CorosHTTPConfig) coros.CorosService {
+	t.Helper()
+	dataDir := t.TempDir()
+	accounts, err := coros.NewAccountStore(dataDir)
+	if err != nil {
+		t.Fatalf("创建账号存储失败: %v", err)
+	}
+	return coros.NewCorosService(&config.CorosConfig{
+		Username: 13800000000,
+		Password: "md5",
+		Address:  address,
+		HTTP:     httpCfg,
+	}, accounts, coros.NewDailyStore(dataDir), activity.NewActivityService(dataDir))
+}
+
+func TestLoginRetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"result":"0000","data":{"accessToken":"token-1"}}`))
+	}))
+	defer server.Close()
+
+	service := newTestService(t, server.URL, config.CorosHTTPConfig{BackoffBase: 1, BackoffMax: 5})
+
+	token, err := service.Login(context.Background(), coros.DefaultAccountID)
+	if err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+	if token != "token-1" {
+		t.Errorf("token = %q", token)
+	}
+	if calls != 3 {
+		t.Errorf("期望请求 3 次，实际 %d 次", calls)
+	}
+}
+
+func TestLoginGivesUpAfterMaxRetries(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	service := newTestService(t, server.URL, config.CorosHTTPConfig{MaxRetries: 2, BackoffBase: 1, BackoffMax: 5})
+
+	if _, err := service.Login(context.Background(), coros.DefaultAccountID); err == nil {
+		t.Fatal("期望登录失败")
+	}
+	if calls != 3 {
+		t.Errorf("期望请求 3 次(1 次 + 2 次重试)，实际 %d 次", calls)
+	}
+}
+
+func TestCircuitBreakerRejectsWhenOpen(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	service := newTestService(t, server.URL, config.CorosHTTPConfig{
+		MaxRetries:       -1,
+		BreakerThreshold: 2,
+		BreakerCooldown:  60,
+	})
+
+	for i := 0; i < 2; i++ {
+		service.Login(context.Background(), coros.DefaultAccountID)
+	}
+	_, err := service.Login(context.Background(), coros.DefaultAccountID)
+	if !errors.Is(err, coros.ErrCircuitOpen) {
+		t.Fatalf("期望熔断错误，实际: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("熔断后不应再请求，实际请求 %d 次", calls)
+	}
+}
+
+func TestCancelledProbeDoesNotCloseBreaker(t *testing.T) {
+	var calls int32
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 3 {
+			// 试探请求挂起，直到测试结束
+			<-done
+			return
+		}
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	defer close(done)
+
+	service := newTestService(t, server.URL, config.CorosHTTPConfig{
+		MaxRetries:       -1,
+		BreakerThreshold: 2,
+		BreakerCooldown:  1,
+	})
+	for i := 0; i < 2; i++ {
+		service.Login(context.Background(), coros.DefaultAccountID)
+	}
+	time.Sleep(1100 * time.Millisecond)
+
+	// 冷却结束后的试探请求被取消，不应关闭熔断器，也不应占用试探名额
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := service.Login(ctx, coros.DefaultAccountID); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("取消的试探请求的错误 = %v", err)
+	}
+
+	// 下一次试探请求失败后立即重新熔断
+	if _, err := service.Login(context.Background(), coros.DefaultAccountID); err == nil || errors.Is(err, coros.ErrCircuitOpen) {
+		t.Fatalf("试探请求应发送到高驰: %v", err)
+	}
+	if _, err := service.Login(context.Background(), coros.DefaultAccountID); !errors.Is(err, coros.ErrCircuitOpen) {
+		t.Errorf("试探失败后应重新熔断，实际: %v", err)
+	}
+	if calls != 4 {
+		t.Errorf("期望请求 4 次，实际 %d 次", calls)
+	}
+}
+
+func TestUploadWorkoutIsNotRetried(t *testing.T) {
+	var uploads int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/account/login" {
+			w.Write([]byte(`{"result":"0000","data":{"accessToken":"token-1"}}`))
+			return
+		}
+		// 服务端可能已经创建了课程，重试会重复创建
+		atomic.AddInt32(&uploads, 1)
+		http.Error(w, "timeout", http.StatusGatewayTimeout)
+	}))
+	defer server.Close()
+
+	service := newTestService(t, server.URL, config.CorosHTTPConfig{BackoffBase: 1, BackoffMax: 5})
+	w := &workout.Workout{
+		Name:  "easy",
+		Sport: sport.Run,
+		Steps: []workout.Step{{Type: workout.StepActive, Duration: workout.Duration{Type: workout.DurationTime, Value: 1800}}},
+	}
+	if _, err := service.UploadWorkout(context.Background(), coros.DefaultAccountID, w); err == nil {
+		t.Fatal("期望上传失败")
+	}
+	if uploads != 1 {
+		t.Errorf("新增课程只应请求 1 次，实际 %d 次", uploads)
+	}
+}
+
+func TestRequestUsesCallerContext(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	service := newTestService(t, server.URL, config.CorosHTTPConfig{BackoffBase: 1, BackoffMax: 5})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := service.Login(ctx, coros.DefaultAccountID); !errors.Is(err, context.Canceled) {
+		t.Errorf("取消的请求的错误 = %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("取消的请求不应发送，实际请求 %d 次", calls)
+	}
+}
@@ -0,0 +1,160 @@
+package prediction_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/prediction"
+	"fitgo/pkg/sport"
+)
+
+// stubActivities 内存中的活动
+type stubActivities struct {
+	activities []*activity.Activity
+}
+
+func (s *stubActivities) Ingest(string, []byte, activity.Source) (*activity.Activity, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *stubActivities) Get(id string) (*activity.Activity, error) {
+	for _, a := range s.activities {
+		if a.ID == id {
+			return a, nil
+		}
+	}
+	return nil, activity.ErrActivityNotFound
+}
+
+func (s *stubActivities) List() ([]*activity.Activity, error) {
+	return s.activities, nil
+}
+
+func (s *stubActivities) Exists(activity.Source) bool {
+	return false
+}
+
+// steadyRun 账号 alice 以 speed 米/秒匀速跑 distance 米，每 10 秒一个轨迹点
+func steadyRun(id string, sp sport.Sport, start time.Time, distance, speed float64) *activity.Activity {
+	a := &activity.Activity{ID: id, Sport: sp, StartTime: start.Format(time.RFC3339), Distance: distance, MovingTime: distance / speed}
+	a.Source = activity.Source{Name: activity.SourceCoros, ID: id, AccountID: "alice"}
+	for t := 0.0; t*speed <= distance; t += 10 {
+		a.Points = append(a.Points, activity.TrackPoint{
+			Time:     start.Add(time.Duration(t) * time.Second).Format(time.RFC3339),
+			Distance: math.Max(t*speed, 0.1),
+		})
+	}
+	return a
+}
+
+func near(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+func TestModels(t *testing.T) {
+	// Daniels 表格：5 公里 20:00 对应 VDOT 49.8，全程马拉松约 3:11
+	vdot := prediction.VDOT(5000, 1200)
+	if !near(vdot, 49.8, 0.1) {
+		t.Errorf("VDOT = %.2f", vdot)
+	}
+	if got := prediction.TimeForVDOT(vdot, 5000); !near(got, 1200, 0.5) {
+		t.Errorf("VDOT 反解 5 公里 = %.1f", got)
+	}
+	if got := prediction.TimeForVDOT(vdot, 42195); !near(got, 11477, 30) {
+		t.Errorf("VDOT 预测全程马拉松 = %.0f", got)
+	}
+	if got := prediction.Riegel(10000, 2400, 21097.5); !near(got, 2400*math.Pow(2.10975, 1.06), 0.01) {
+		t.Errorf("Riegel = %.1f", got)
+	}
+
+	// 距离 = 200 + 4 × 时间，超出 2-30 分钟的成绩不参与拟合
+	cs, dPrime, ok := prediction.CriticalSpeed([]prediction.Performance{
+		{Distance: 1000, Duration: 200},
+		{Distance: 5000, Duration: 1200},
+		{Distance: 42195, Duration: 12000},
+	})
+	if !ok || !near(cs, 4, 1e-9) || !near(dPrime, 200, 1e-6) {
+		t.Errorf("临界速度 = %v, D' = %v, ok = %v", cs, dPrime, ok)
+	}
+	if _, _, ok := prediction.CriticalSpeed([]prediction.Performance{{Distance: 5000, Duration: 1200}}); ok {
+		t.Error("只有一个距离时不应拟合临界速度")
+	}
+
+	// 训练配速从轻松跑到重复跑越来越快，区间内 Fast 不慢于 Slow
+	paces := prediction.Paces(vdot)
+	if len(paces) != 5 || paces[0].Name != "easy" || paces[4].Name != "repetition" {
+		t.Fatalf("训练配速 = %+v", paces)
+	}
+	for i, p := range paces {
+		if p.Fast > p.Slow || (i > 0 && p.Fast >= paces[i-1].Fast) {
+			t.Errorf("%s 配速 = %+v", p.Name, p)
+		}
+	}
+	if threshold := paces[2]; threshold.Fast < 250 || threshold.Slow > 275 {
+		t.Errorf("VDOT 49.8 的乳酸阈值配速 = %+v", threshold)
+	}
+}
+
+func TestPredict(t *testing.T) {
+	until := time.Date(2024, 3, 20, 0, 0, 0, 0, time.Local)
+	otherAccount := steadyRun("bob", sport.Run, until.AddDate(0, 0, -2), 10500, 5)
+	otherAccount.Source.AccountID = "bob"
+	activities := &stubActivities{activities: []*activity.Activity{
+		steadyRun("tempo", sport.Run, until.AddDate(0, 0, -3), 10500, 4),
+		steadyRun("trail", sport.TrailRun, until.AddDate(0, 0, -5), 10500, 5),
+		steadyRun("old", sport.Run, until.AddDate(0, 0, -120), 10500, 5),
+		steadyRun("later", sport.Run, until.AddDate(0, 0, 1), 10500, 5),
+		otherAccount,
+	}}
+	service := prediction.NewPredictionService(activities)
+
+	p, err := service.Predict(prediction.Options{AccountID: "alice", Until: until})
+	if err != nil {
+		t.Fatalf("预测失败: %v", err)
+	}
+	// 只使用该账号 90 天内截止日期之前的路跑：1、5、10 公里的最好成绩
+	if p.From != "2023-12-21" || p.To != "2024-03-19" || len(p.Performances) != 3 {
+		t.Fatalf("预测范围 = %s 至 %s, 成绩 = %+v", p.From, p.To, p.Performances)
+	}
+	if got := p.Performances[1]; got.ActivityID != "tempo" || got.Source != prediction.SourceBestEffort || !near(got.Duration, 1250, 1) {
+		t.Errorf("5 公里最好成绩 = %+v", got)
+	}
+	if len(p.Predictions) != 4 || len(p.Paces) != 5 || p.CriticalSpeed == 0 {
+		t.Fatalf("预测 = %+v", p)
+	}
+	for _, r := range p.Predictions {
+		if !(r.Low < r.Time && r.Time < r.High) || r.Riegel <= 0 || r.VDOT <= 0 {
+			t.Errorf("%s 预测 = %+v", r.Name, r)
+		}
+	}
+	if p.Predictions[0].CriticalSpeed == 0 || p.Predictions[3].CriticalSpeed != 0 {
+		t.Errorf("临界速度只用于一小时以内的比赛: %+v", p.Predictions)
+	}
+	// 半马由最接近的 10 公里推算
+	if half := p.Predictions[2]; half.Basis != 10000 || !near(half.Riegel, prediction.Riegel(10000, 2500, 21097.5), 1) {
+		t.Errorf("半马预测 = %+v", half)
+	}
+
+	// 比赛成绩比训练成绩快时取代同距离的最好成绩，并提高 VDOT
+	race := prediction.Performance{Distance: 10000, Duration: 2340, Date: "2024-03-10"}
+	withRace, err := service.Predict(prediction.Options{AccountID: "alice", Until: until, Races: []prediction.Performance{race}})
+	if err != nil {
+		t.Fatalf("预测失败: %v", err)
+	}
+	if withRace.VDOTBasis.Source != prediction.SourceRace || withRace.VDOT <= p.VDOT {
+		t.Errorf("VDOT = %.1f (%+v), 只用训练成绩时 %.1f", withRace.VDOT, withRace.VDOTBasis, p.VDOT)
+	}
+
+	if _, err := service.Predict(prediction.Options{Races: []prediction.Performance{{Distance: 5000}}}); !errors.Is(err, prediction.ErrInvalidPerformance) {
+		t.Errorf("非法成绩错误 = %v", err)
+	}
+	if _, err := service.Predict(prediction.Options{AccountID: "alice", Until: until.AddDate(-1, 0, 0)}); !errors.Is(err, prediction.ErrNotEnoughData) {
+		t.Errorf("没有成绩时的错误 = %v", err)
+	}
+	if _, err := service.Predict(prediction.Options{AccountID: "carol", Until: until}); !errors.Is(err, prediction.ErrNotEnoughData) {
+		t.Errorf("没有活动的账号的错误 = %v", err)
+	}
+}
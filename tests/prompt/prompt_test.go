@@ -0,0 +1,133 @@
+package prompt_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"fitgo/internal/service/ai/prompt"
+	"fitgo/pkg/config"
+	"fitgo/pkg/sport"
+)
+
+func writeTemplate(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmbeddedTemplates(t *testing.T) {
+	store, err := prompt.NewStore(config.PromptConfig{})
+	if err != nil {
+		t.Fatalf("加载内置模板失败: %v", err)
+	}
+
+	input := prompt.RunningSummary{
+		Sport:     sport.TrailRun,
+		SportName: sport.TrailRun.Name(),
+		Summary:   prompt.ActivitySummary{Distance: "10.00 km", AvgPace: "3:44 /km"},
+		Laps:      []prompt.Lap{{Index: 1, Distance: "1.00 km", Pace: "3:46 /km", AvgHR: "138 bpm"}},
+		Readiness: &prompt.Readiness{RestingHR: "48 bpm", HRV: "62 ms"},
+	}
+
+	zh, err := store.Lookup(sport.TrailRun, prompt.TaskSummary, "")
+	if err != nil {
+		t.Fatalf("查找中文模板失败: %v", err)
+	}
+	if zh.Name != "running/summary.zh" || zh.Version != "4" || !strings.HasPrefix(zh.ID, "running/summary.zh@4+") {
+		t.Errorf("模板 = %s, 版本 %s, ID %s", zh.Name, zh.Version, zh.ID)
+	}
+	content, err := zh.Render(input)
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+	for _, want := range []string{"运动类型：越野跑", "- 总距离：10.00 km", "| 1 | 1.00 km |  | 3:46 /km |", "- 静息心率：48 bpm"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("中文提示词缺少 %q", want)
+		}
+	}
+
+	// 压缩后的分段表只有 7 列，并说明合并方式
+	compacted := input
+	compacted.LapCompaction = &prompt.LapCompaction{OriginalLaps: 105, Bucket: "1.00 km"}
+	content, _ = zh.Render(compacted)
+	if !strings.Contains(content, "原有 105 个分段，为控制篇幅已按每 1.00 km 合并") || !strings.Contains(content, "| 1 | 1.00 km |  | 3:46 /km | 138 bpm |  |  |") || strings.Contains(content, "坡度调整配速 |") {
+		t.Errorf("压缩后的中文提示词:\n%s", content)
+	}
+
+	en, _ := store.Lookup(sport.Run, prompt.TaskSummary, "en")
+	if en.Name != "running/summary.en" {
+		t.Errorf("英文模板 = %s", en.Name)
+	}
+	input.Readiness = nil
+	content, _ = en.Render(input)
+	if !strings.Contains(content, "sport: trail_run") || !strings.Contains(content, "Recovery on the day: none") || strings.Contains(content, "Max heart rate") {
+		t.Errorf("英文提示词:\n%s", content)
+	}
+
+	// 没有对应语言时回退到默认语言，没有专门模板的运动大类使用 generic，没有对应任务时报错
+	if fr, _ := store.Lookup(sport.Run, prompt.TaskSummary, "fr"); fr == nil || fr.Name != "running/summary.zh" {
+		t.Errorf("fr 回退到 %v", fr)
+	}
+	if swim, _ := store.Lookup(sport.PoolSwim, prompt.TaskSummary, ""); swim == nil || swim.Name != "swimming/summary.zh" {
+		t.Errorf("游泳模板 = %v", swim)
+	}
+	if hike, _ := store.Lookup(sport.Hike, prompt.TaskStructured, "en"); hike == nil || hike.Name != "generic/structured.en" {
+		t.Errorf("徒步模板 = %v", hike)
+	}
+	if _, err := store.Lookup(sport.PoolSwim, "plan", ""); !errors.Is(err, prompt.ErrTemplateNotFound) {
+		t.Errorf("不存在的任务 err = %v", err)
+	}
+}
+
+func TestTemplateDirOverride(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "trail_run/summary.zh.tmpl", "{{/* version: 2 */}}越野跑专用：{{.SportName}}")
+
+	store, err := prompt.NewStore(config.PromptConfig{Dir: dir, HotReload: true})
+	if err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+
+	// 具体运动类型的模板优先于运动大类
+	trail, _ := store.Lookup(sport.TrailRun, prompt.TaskSummary, "")
+	road, _ := store.Lookup(sport.Run, prompt.TaskSummary, "")
+	if trail.Name != "trail_run/summary.zh" || trail.Version != "2" || road.Name != "running/summary.zh" {
+		t.Errorf("越野跑模板 %s, 路跑模板 %s", trail.Name, road.Name)
+	}
+
+	// 热加载：修改文件后版本ID随内容变化
+	writeTemplate(t, dir, "trail_run/summary.zh.tmpl", "{{/* version: 2 */}}修改后：{{.SportName}}")
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "trail_run/summary.zh.tmpl"), future, future)
+
+	reloaded, _ := store.Lookup(sport.TrailRun, prompt.TaskSummary, "")
+	content, _ := reloaded.Render(prompt.RunningSummary{SportName: "越野跑"})
+	if content != "修改后：越野跑" || reloaded.ID == trail.ID {
+		t.Errorf("热加载后内容 %q, ID %s -> %s", content, trail.ID, reloaded.ID)
+	}
+
+	// 模板有错误时保留原模板
+	writeTemplate(t, dir, "trail_run/summary.zh.tmpl", "{{/* version: 3 */}}{{.SportName")
+	os.Chtimes(filepath.Join(dir, "trail_run/summary.zh.tmpl"), future.Add(time.Minute), future.Add(time.Minute))
+	if kept, _ := store.Lookup(sport.TrailRun, prompt.TaskSummary, ""); kept.ID != reloaded.ID {
+		t.Errorf("模板错误时应保留原模板，得到 %s", kept.ID)
+	}
+}
+
+func TestTemplateVersionRequired(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "running/summary.zh.tmpl", "没有版本声明 {{.SportName}}")
+
+	if _, err := prompt.NewStore(config.PromptConfig{Dir: dir}); err == nil {
+		t.Error("缺少版本声明时应返回错误")
+	}
+}
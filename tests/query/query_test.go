@@ -0,0 +1,204 @@
+package query_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/ai/prompt"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/query"
+	"fitgo/pkg/config"
+)
+
+// scriptedServer 模拟 OpenAI 兼容接口，按顺序返回 replies 中的内容
+func scriptedServer(t *testing.T, replies ...string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		mu.Lock()
+		defer mu.Unlock()
+		prompts = append(prompts, req.Messages[len(req.Messages)-1].Content)
+		reply := replies[0]
+		if len(replies) > 1 {
+			replies = replies[1:]
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"index": 0, "message": map[string]string{"role": "assistant", "content": reply}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &prompts
+}
+
+func newAI(t *testing.T, baseURL string) *aiservice.AIService {
+	t.Helper()
+	ai, err := aiservice.NewAIService(&config.AIConfig{
+		Providers: []config.AIProviderEntry{{
+			Name:     "fake",
+			Provider: "openai-compatible",
+			Config:   config.AIProviderConfig{BaseURL: baseURL, APIKey: "test", Model: "query-model"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("创建 AI 服务失败: %v", err)
+	}
+	return ai
+}
+
+// defaultPrompts 内置的提示词模板
+func defaultPrompts(t *testing.T) *prompt.Store {
+	t.Helper()
+	store, err := prompt.NewStore(config.PromptConfig{})
+	if err != nil {
+		t.Fatalf("加载提示词模板失败: %v", err)
+	}
+	return store
+}
+
+// newActivities 把夹具中的 TCX 活动导入到账号 alice 名下
+func newActivities(t *testing.T) (activity.ActivityService, *activity.Activity) {
+	t.Helper()
+	activities := activity.NewActivityService(t.TempDir())
+	return activities, ingest(t, activities, "1", "alice")
+}
+
+// ingest 以高驰活动 labelID 导入夹具中的 TCX 活动
+func ingest(t *testing.T, activities activity.ActivityService, labelID, accountID string) *activity.Activity {
+	t.Helper()
+	data, err := os.ReadFile("../../internal/service/coros/fakeserver/fixtures/files/472913588747534700.tcx")
+	if err != nil {
+		t.Fatalf("读取夹具失败: %v", err)
+	}
+	a, err := activities.Ingest("run.tcx", data, activity.Source{Name: activity.SourceCoros, ID: labelID, AccountID: accountID})
+	if err != nil {
+		t.Fatalf("导入活动失败: %v", err)
+	}
+	return a
+}
+
+func TestExecute(t *testing.T) {
+	activities, a := newActivities(t)
+	service := query.NewQueryService(t.TempDir(), defaultPrompts(t), activities, nil)
+
+	table, err := service.Execute("alice", &query.Query{
+		Scope:      query.ScopeActivity,
+		GroupBy:    []string{"family"},
+		Aggregates: []query.Aggregate{{Func: "count"}, {Func: "sum", Field: "distance_km"}},
+	})
+	if err != nil {
+		t.Fatalf("执行查询失败: %v", err)
+	}
+	if len(table.Rows) != 1 || table.Rows[0][0] != "running" || table.Rows[0][1] != 1.0 {
+		t.Fatalf("结果 = %+v", table)
+	}
+	if got := table.Rows[0][2].(float64); got < a.Distance/1000-0.01 || got > a.Distance/1000+0.01 {
+		t.Errorf("总距离 = %v, 期望 %.2f", got, a.Distance/1000)
+	}
+
+	// point 粒度的距离之和不超过整次活动的距离
+	table, err = service.Execute("alice", &query.Query{
+		Scope:      query.ScopePoint,
+		Filters:    []query.Filter{{Field: "heart_rate", Op: "gte", Value: 0.0}},
+		Aggregates: []query.Aggregate{{Func: "sum", Field: "distance_km"}},
+	})
+	if err != nil {
+		t.Fatalf("执行查询失败: %v", err)
+	}
+	if got, ok := table.Rows[0][0].(float64); !ok || got <= 0 || got > a.Distance/1000+0.01 {
+		t.Errorf("point 粒度距离 = %v", table.Rows[0][0])
+	}
+
+	for _, q := range []*query.Query{
+		{Scope: "week", Select: []string{"date"}},
+		{Scope: query.ScopeActivity, Select: []string{"heart_rate"}},
+		{Scope: query.ScopeActivity, Select: []string{"date"}, Filters: []query.Filter{{Field: "distance_km", Op: "like", Value: 1.0}}},
+		{Scope: query.ScopeActivity, Select: []string{"date"}, Filters: []query.Filter{{Field: "distance_km", Op: "gt", Value: "10"}}},
+		{Scope: query.ScopeActivity, GroupBy: []string{"distance_km"}, Aggregates: []query.Aggregate{{Func: "count"}}},
+		{Scope: query.ScopeActivity, Aggregates: []query.Aggregate{{Func: "median", Field: "avg_hr"}}},
+	} {
+		if _, err := service.Execute("alice", q); !errors.Is(err, query.ErrInvalidQuery) {
+			t.Errorf("查询 %+v 应不合法, err = %v", q, err)
+		}
+	}
+}
+
+func TestAsk(t *testing.T) {
+	activities, _ := newActivities(t)
+	server, prompts := scriptedServer(t,
+		`{"scope":"activity","select":["date"],"unknown":1}`,
+		"```json\n{\"scope\":\"activity\",\"aggregates\":[{\"func\":\"count\"}]}\n```",
+		"一共 1 次活动。",
+	)
+	service := query.NewQueryService(t.TempDir(), defaultPrompts(t), activities, newAI(t, server.URL))
+
+	answer, err := service.Ask(context.Background(), "alice", "我一共跑了几次？")
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if answer.Answer != "一共 1 次活动。" || answer.Model != "query-model" || answer.Table.Rows[0][0] != 1.0 {
+		t.Errorf("回答 = %+v", answer)
+	}
+
+	// 第二次请求带上了校验错误，第三次请求带上了结果表格
+	if len(*prompts) != 3 || !strings.Contains((*prompts)[1], "没有通过校验") || !strings.Contains((*prompts)[2], "| count |") {
+		t.Errorf("请求 = %q", *prompts)
+	}
+
+	entries, err := service.Log("alice", 0)
+	if err != nil {
+		t.Fatalf("读取日志失败: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("日志 %d 条, 期望 2", len(entries))
+	}
+	if entries[0].Query == nil || entries[0].Rows != 1 || entries[0].Error != "" {
+		t.Errorf("最新的日志 = %+v", entries[0])
+	}
+	if entries[1].Query != nil || entries[1].Error == "" {
+		t.Errorf("不合法的查询应记录错误: %+v", entries[1])
+	}
+
+	if _, err := service.Ask(context.Background(), "alice", "  "); !errors.Is(err, query.ErrEmptyQuestion) {
+		t.Errorf("空问题 err = %v", err)
+	}
+	if entries, err := service.Log("bob", 0); err != nil || len(entries) != 0 {
+		t.Errorf("其他账号的日志 = %+v, %v", entries, err)
+	}
+}
+
+func TestQueryIsScopedToAccount(t *testing.T) {
+	activities, _ := newActivities(t)
+	ingest(t, activities, "2", "bob")
+	ingest(t, activities, "3", "bob")
+	service := query.NewQueryService(t.TempDir(), defaultPrompts(t), activities, nil)
+
+	count := &query.Query{Scope: query.ScopeActivity, Aggregates: []query.Aggregate{{Func: "count"}}}
+	for account, want := range map[string]float64{"alice": 1, "bob": 2} {
+		table, err := service.Execute(account, count)
+		if err != nil {
+			t.Fatalf("执行查询失败: %v", err)
+		}
+		if got := table.Rows[0][0]; got != want {
+			t.Errorf("%s 的活动数 = %v, 期望 %v", account, got, want)
+		}
+	}
+	if _, err := service.Execute("", count); !errors.Is(err, query.ErrNoAccount) {
+		t.Errorf("没有账号 err = %v", err)
+	}
+}
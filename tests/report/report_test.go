@@ -0,0 +1,66 @@
+package report_test
+
+import (
+	"errors"
+	"testing"
+
+	"fitgo/internal/service/report"
+)
+
+func TestReportVersions(t *testing.T) {
+	reports := report.NewReportService(t.TempDir())
+
+	first, err := reports.Save(&report.Report{ActivityID: "coros-1", InputHash: "h1", PromptVersion: "v1", Provider: "qwen", Model: "m1", Content: "第一版"})
+	if err != nil {
+		t.Fatalf("保存报告失败: %v", err)
+	}
+	second, _ := reports.Save(&report.Report{ActivityID: "coros-1", InputHash: "h1", PromptVersion: "v1", Provider: "qwen", Model: "m1", Content: "第二版"})
+	reports.Save(&report.Report{ActivityID: "coros-1", InputHash: "h1", PromptVersion: "v2", Provider: "qwen", Model: "m1", Content: "新提示词"})
+	reports.Save(&report.Report{ActivityID: "coros-2", InputHash: "h1", PromptVersion: "v1", Provider: "qwen", Model: "m1", Content: "其它活动"})
+
+	// 命中最新的匹配版本
+	found, err := reports.Find("coros-1", "h1", "v1", []string{"m1", "m2"})
+	if err != nil {
+		t.Fatalf("查找报告失败: %v", err)
+	}
+	if found.ID != second.ID || found.Content != "第二版" {
+		t.Errorf("Find = %+v, 期望第二版", found)
+	}
+
+	// 输入哈希为空时不比较输入
+	if found, err := reports.Find("coros-1", "", "v2", nil); err != nil || found.Content != "新提示词" {
+		t.Errorf("Find 不比较输入哈希 = %+v, %v", found, err)
+	}
+
+	// 输入、提示词版本或模型变化都不命中
+	misses := []struct {
+		hash, version string
+		models        []string
+	}{
+		{"h2", "v1", nil},
+		{"h1", "v3", nil},
+		{"h1", "v1", []string{"m2"}},
+	}
+	for _, m := range misses {
+		if _, err := reports.Find("coros-1", m.hash, m.version, m.models); !errors.Is(err, report.ErrReportNotFound) {
+			t.Errorf("Find(%s, %s, %v) err = %v, 期望 ErrReportNotFound", m.hash, m.version, m.models, err)
+		}
+	}
+
+	// 旧版本保留
+	versions, err := reports.List("coros-1")
+	if err != nil {
+		t.Fatalf("列出报告失败: %v", err)
+	}
+	if len(versions) != 3 || versions[0].Content != "新提示词" || versions[2].ID != first.ID {
+		t.Errorf("版本列表 = %+v", versions)
+	}
+
+	got, err := reports.Get("coros-1", first.ID)
+	if err != nil || got.Content != "第一版" {
+		t.Errorf("Get = %+v, %v", got, err)
+	}
+	if _, err := reports.Get("coros-1", "missing"); !errors.Is(err, report.ErrReportNotFound) {
+		t.Errorf("不存在的版本 err = %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package review_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/ai/prompt"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/report"
+	"fitgo/internal/service/review"
+	"fitgo/pkg/config"
+	"fitgo/pkg/sport"
+)
+
+// stubActivities 内存中的活动
+type stubActivities struct {
+	activities []*activity.Activity
+}
+
+func (s *stubActivities) Ingest(string, []byte, activity.Source) (*activity.Activity, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *stubActivities) Get(id string) (*activity.Activity, error) {
+	for _, a := range s.activities {
+		if a.ID == id {
+			return a, nil
+		}
+	}
+	return nil, activity.ErrActivityNotFound
+}
+
+func (s *stubActivities) List() ([]*activity.Activity, error) {
+	return s.activities, nil
+}
+
+func (s *stubActivities) Exists(activity.Source) bool {
+	return false
+}
+
+// at 本地时间 2024 年 3 月 day 日 hour 点
+func at(month time.Month, day, hour int) time.Time {
+	return time.Date(2024, month, day, hour, 0, 0, 0, time.Local)
+}
+
+// newActivities 账号 alice 在 2024-03-11 所在周三次活动，上一周一次跑步
+func newActivities() *stubActivities {
+	start := at(time.March, 16, 7)
+	points := []activity.TrackPoint{
+		{Time: start.Format(time.RFC3339), HeartRate: 180},
+		{Time: start.Add(10 * time.Second).Format(time.RFC3339), HeartRate: 100},
+		{Time: start.Add(110 * time.Second).Format(time.RFC3339), HeartRate: 100},
+	}
+	activities := []*activity.Activity{
+		{ID: "long", Sport: sport.Run, StartTime: start.Format(time.RFC3339), Distance: 21000, MovingTime: 7200, AvgHR: 140, Ascent: 120, Points: points},
+		{ID: "ride", Sport: sport.Bike, StartTime: at(time.March, 13, 18).Format(time.RFC3339), Distance: 30000, MovingTime: 3600, AvgHR: 120, Ascent: 200},
+		{ID: "easy", Sport: sport.Run, StartTime: at(time.March, 11, 8).Format(time.RFC3339), Distance: 10000, MovingTime: 3000, AvgHR: 150, MaxHR: 190},
+		{ID: "prev", Sport: sport.Run, StartTime: at(time.March, 5, 8).Format(time.RFC3339), Distance: 5000, MovingTime: 1800, AvgHR: 150},
+	}
+	for _, a := range activities {
+		a.Source = activity.Source{Name: activity.SourceCoros, ID: a.ID, AccountID: "alice"}
+	}
+	return &stubActivities{activities: activities}
+}
+
+// withAccount 把活动复制一份到 accountID 名下
+func withAccount(s *stubActivities, accountID string) *stubActivities {
+	result := &stubActivities{activities: s.activities}
+	for _, a := range s.activities {
+		c := *a
+		c.ID = accountID + "-" + a.ID
+		c.Source.AccountID = accountID
+		result.activities = append(result.activities, &c)
+	}
+	return result
+}
+
+// defaultPrompts 内置的提示词模板
+func defaultPrompts(t *testing.T) *prompt.Store {
+	t.Helper()
+	store, err := prompt.NewStore(config.PromptConfig{})
+	if err != nil {
+		t.Fatalf("加载提示词模板失败: %v", err)
+	}
+	return store
+}
+
+func TestWeekStats(t *testing.T) {
+	// 其他账号的活动不参与统计
+	service := review.NewReviewService(defaultPrompts(t), withAccount(newActivities(), "bob"), nil, nil)
+
+	stats, err := service.Stats("alice", review.PeriodWeek, "2024-03-13")
+	if err != nil {
+		t.Fatalf("计算统计失败: %v", err)
+	}
+	if stats.From != "2024-03-11" || stats.To != "2024-03-17" || stats.InProgress {
+		t.Errorf("周期 = %s 至 %s (进行中 %v)", stats.From, stats.To, stats.InProgress)
+	}
+	if stats.Count != 3 || stats.Distance != 61000 || stats.MovingTime != 13800 || stats.Ascent != 320 {
+		t.Errorf("训练量 = %+v", stats.Volume)
+	}
+	// 平均心率 150/190 在 Z3，120/190 在 Z2；长距离按轨迹点累计，100 秒的间隔只计 10 秒
+	if stats.MaxHR != 190 || len(stats.Zones) != 5 || stats.Zones[0] != 10 || stats.Zones[1] != 3600 || stats.Zones[2] != 3000 || stats.Zones[4] != 10 {
+		t.Errorf("心率区间 = %v (最大心率 %d)", stats.Zones, stats.MaxHR)
+	}
+	if stats.Load != 150+120+1 {
+		t.Errorf("训练负荷 = %v", stats.Load)
+	}
+	if len(stats.BySport) != 2 || stats.BySport[0].Family != sport.FamilyRunning || stats.BySport[0].Count != 2 {
+		t.Errorf("按运动汇总 = %+v", stats.BySport)
+	}
+	if stats.LongestRun == nil || stats.LongestRun.ActivityID != "long" {
+		t.Errorf("最长跑步 = %+v", stats.LongestRun)
+	}
+	if stats.Consistency != (review.Consistency{Days: 7, ActiveDays: 3, LongestBreak: 2}) {
+		t.Errorf("连续性 = %+v", stats.Consistency)
+	}
+	if stats.Previous.From != "2024-03-04" || stats.Previous.Count != 1 || stats.Previous.Load != 90 {
+		t.Errorf("上一周期 = %+v", stats.Previous)
+	}
+	if len(stats.Sessions) != 3 || stats.Sessions[0].ActivityID != "easy" || len(stats.Weeks) != 0 {
+		t.Errorf("活动 = %+v, 周 = %+v", stats.Sessions, stats.Weeks)
+	}
+}
+
+func TestMonthStats(t *testing.T) {
+	service := review.NewReviewService(defaultPrompts(t), newActivities(), nil, nil)
+
+	stats, err := service.Stats("alice", review.PeriodMonth, "2024-03-31")
+	if err != nil {
+		t.Fatalf("计算统计失败: %v", err)
+	}
+	if stats.From != "2024-03-01" || stats.To != "2024-03-31" || stats.Count != 4 || stats.Previous.From != "2024-02-01" {
+		t.Errorf("月统计 = %+v", stats.Volume)
+	}
+	// 3 月 1 日是周五，按周一划分为 5 周，首尾截断在月份内
+	if len(stats.Weeks) != 5 || stats.Weeks[0].To != "2024-03-03" || stats.Weeks[2].Count != 3 || stats.Weeks[4].To != "2024-03-31" {
+		t.Errorf("每周训练量 = %+v", stats.Weeks)
+	}
+
+	if _, err := service.Stats("alice", "year", ""); !errors.Is(err, review.ErrInvalidPeriod) {
+		t.Errorf("非法周期错误 = %v", err)
+	}
+	if _, err := service.Stats("alice", review.PeriodWeek, "2024/03/01"); !errors.Is(err, review.ErrInvalidDate) {
+		t.Errorf("非法日期错误 = %v", err)
+	}
+}
+
+func TestReview(t *testing.T) {
+	ai, err := aiservice.NewAIService(&config.AIConfig{
+		BreakerThreshold: -1,
+		Providers: []config.AIProviderEntry{{
+			Name:     "fake",
+			Provider: "fake",
+			Config:   config.AIProviderConfig{Model: "review-model", Fake: &config.FakeConfig{Template: "{{.Call}}|{{.Prompt}}"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("创建 AI 服务失败: %v", err)
+	}
+	service := review.NewReviewService(defaultPrompts(t), withAccount(newActivities(), "bob"), report.NewReportService(t.TempDir()), ai)
+	ctx := context.Background()
+
+	result, err := service.Review(ctx, "alice", review.PeriodWeek, "2024-03-13", review.Options{})
+	if err != nil {
+		t.Fatalf("生成回顾失败: %v", err)
+	}
+	content := result.Report.Content
+	if !strings.HasPrefix(content, "1|") || !strings.Contains(content, "2024-03-11") || !strings.Contains(content, "Z5") {
+		t.Errorf("回顾内容 = %q", content)
+	}
+	if result.Report.ActivityID != "review-alice-week-2024-03-11" || result.Report.Cached || result.Stats.Count != 3 {
+		t.Errorf("回顾 = %+v", result.Report)
+	}
+
+	// 数据未变化时命中缓存，refresh 时重新生成
+	cached, err := service.Review(ctx, "alice", review.PeriodWeek, "2024-03-15", review.Options{})
+	if err != nil || !cached.Report.Cached || cached.Report.ID != result.Report.ID {
+		t.Errorf("缓存回顾 = %+v, %v", cached, err)
+	}
+	refreshed, err := service.Review(ctx, "alice", review.PeriodWeek, "2024-03-13", review.Options{Refresh: true})
+	if err != nil || refreshed.Report.Cached || !strings.HasPrefix(refreshed.Report.Content, "2|") {
+		t.Errorf("重新生成的回顾 = %+v, %v", refreshed, err)
+	}
+
+	if _, err := service.Review(ctx, "alice", review.PeriodWeek, "2023-01-01", review.Options{}); !errors.Is(err, review.ErrNoActivities) {
+		t.Errorf("没有活动时的错误 = %v", err)
+	}
+
+	// 统计数据相同的其他账号不使用该账号的缓存
+	other, err := service.Review(ctx, "bob", review.PeriodWeek, "2024-03-13", review.Options{})
+	if err != nil || other.Report.Cached || other.Report.ActivityID != "review-bob-week-2024-03-11" || other.Stats.Count != 3 {
+		t.Errorf("其他账号的回顾 = %+v, %v", other, err)
+	}
+}
@@ -0,0 +1,162 @@
+package secrets_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fitgo/pkg/config"
+	"fitgo/pkg/secrets"
+)
+
+func TestVaultRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secrets.vault")
+
+	vault, err := secrets.OpenVault(path, secrets.Key{Passphrase: "correct horse"})
+	if err != nil {
+		t.Fatalf("打开保险库失败: %v", err)
+	}
+	if err := vault.Set("coros/password", "hunter2"); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if err := vault.Save(); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取保险库文件失败: %v", err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Fatalf("保险库文件包含明文")
+	}
+
+	reopened, err := secrets.OpenVault(path, secrets.Key{Passphrase: "correct horse"})
+	if err != nil {
+		t.Fatalf("重新打开保险库失败: %v", err)
+	}
+	if value, err := reopened.Get("coros/password"); err != nil || value != "hunter2" {
+		t.Fatalf("读取结果错误: %q, %v", value, err)
+	}
+
+	if _, err := secrets.OpenVault(path, secrets.Key{Passphrase: "wrong"}); err == nil {
+		t.Fatal("错误口令应打开失败")
+	}
+
+	// 重命名或挂载到其他路径后仍可解密
+	renamed := filepath.Join(dir, "mounted", "coros-secret")
+	if err := os.MkdirAll(filepath.Dir(renamed), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path, renamed); err != nil {
+		t.Fatalf("重命名保险库失败: %v", err)
+	}
+	moved, err := secrets.OpenVault(renamed, secrets.Key{Passphrase: "correct horse"})
+	if err != nil {
+		t.Fatalf("打开重命名后的保险库失败: %v", err)
+	}
+	if value, err := moved.Get("coros/password"); err != nil || value != "hunter2" {
+		t.Fatalf("读取结果错误: %q, %v", value, err)
+	}
+}
+
+func TestVaultKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "vault.key")
+	if err := secrets.GenerateKeyFile(keyFile); err != nil {
+		t.Fatalf("生成密钥文件失败: %v", err)
+	}
+
+	path := filepath.Join(dir, "secrets.vault")
+	vault, err := secrets.OpenVault(path, secrets.Key{KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("打开保险库失败: %v", err)
+	}
+	vault.Set("ai/api_key", "sk-test")
+	if err := vault.Save(); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+
+	if _, err := secrets.OpenVault(path, secrets.Key{Passphrase: "any"}); err == nil {
+		t.Fatal("密钥文件加密的保险库不应能用口令打开")
+	}
+	reopened, err := secrets.OpenVault(path, secrets.Key{KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("重新打开保险库失败: %v", err)
+	}
+	if value, _ := reopened.Get("ai/api_key"); value != "sk-test" {
+		t.Fatalf("读取结果错误: %q", value)
+	}
+}
+
+func TestLoadConfigResolvesReferences(t *testing.T) {
+	dir := t.TempDir()
+
+	vault, err := secrets.OpenVault(filepath.Join(dir, "secrets.vault"), secrets.Key{Passphrase: "pw"})
+	if err != nil {
+		t.Fatalf("打开保险库失败: %v", err)
+	}
+	vault.Set("coros/password", "from-vault")
+	if err := vault.Save(); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+
+	secretFile := filepath.Join(dir, "base_url")
+	os.WriteFile(secretFile, []byte("http://from-file\n"), 0o600)
+
+	configPath := filepath.Join(dir, "config.json")
+	os.WriteFile(configPath, []byte(`{
+  "coros": {"username": 1, "password": "secret://coros/password", "address": "http://coros"},
+  "ai": {"provider": "qwen", "config": {"base_url": "file://`+secretFile+`", "api_key": "env://FITGO_TEST_API_KEY"}}
+}`), 0o600)
+
+	t.Setenv(secrets.EnvVaultPath, "")
+	t.Setenv(secrets.EnvVaultPassphrase, "pw")
+	t.Setenv("FITGO_TEST_API_KEY", "from-env")
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	if cfg.Coros.Password != "from-vault" {
+		t.Errorf("password = %q", cfg.Coros.Password)
+	}
+	if cfg.AI.Config.BaseURL != "http://from-file" {
+		t.Errorf("base_url = %q", cfg.AI.Config.BaseURL)
+	}
+	if cfg.AI.Config.APIKey != "from-env" {
+		t.Errorf("api_key = %q", cfg.AI.Config.APIKey)
+	}
+	if cfg.Coros.Address != "http://coros" {
+		t.Errorf("普通值不应被修改: %q", cfg.Coros.Address)
+	}
+}
+
+func TestLoadConfigWithDefaultsKeepsPrimaryError(t *testing.T) {
+	dir := t.TempDir()
+	primary := filepath.Join(dir, "config.json")
+	fallback := filepath.Join(dir, "missing", "config.json")
+	os.WriteFile(primary, []byte(`{"coros": {"password": "secret://coros/password"}}`), 0o600)
+
+	t.Setenv(secrets.EnvVaultPath, "")
+	t.Setenv(secrets.EnvVaultPassphrase, "pw")
+
+	// 主配置存在但保险库不存在，应返回保险库错误而不是备用路径的错误
+	_, err := config.LoadConfigWithDefaults(primary, fallback)
+	if err == nil || !strings.Contains(err.Error(), "保险库") || strings.Contains(err.Error(), fallback) {
+		t.Errorf("错误 = %v", err)
+	}
+
+	// 主配置不存在时使用备用路径
+	cfg, err := config.LoadConfigWithDefaults(filepath.Join(dir, "none.json"), filepath.Join(dir, "other.json"))
+	if err == nil || cfg != nil {
+		t.Errorf("两个路径都不存在时应返回错误: %+v", cfg)
+	}
+	os.WriteFile(filepath.Join(dir, "other.json"), []byte(`{"coros": {"address": "http://coros"}}`), 0o600)
+	cfg, err = config.LoadConfigWithDefaults(filepath.Join(dir, "none.json"), filepath.Join(dir, "other.json"))
+	if err != nil || cfg.Coros.Address != "http://coros" {
+		t.Errorf("备用配置 = %+v, %v", cfg, err)
+	}
+}
@@ -0,0 +1,93 @@
+package sport_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"fitgo/pkg/sport"
+)
+
+func TestCorosRoundTrip(t *testing.T) {
+	for _, s := range sport.All() {
+		if got := sport.FromCoros(s.Coros()); got != s {
+			t.Errorf("%s: 高驰代码 %d 反查得到 %s", s, s.Coros(), got)
+		}
+	}
+	if got := sport.FromCoros(12345); got != sport.Unknown {
+		t.Errorf("未知代码应返回 Unknown，实际 %s", got)
+	}
+}
+
+func TestFITRoundTrip(t *testing.T) {
+	for _, s := range sport.All() {
+		fitSport, fitSub := s.FIT()
+		if got := sport.FromFIT(fitSport, fitSub); got != s {
+			t.Errorf("%s: FIT (%d, %d) 反查得到 %s", s, fitSport, fitSub, got)
+		}
+	}
+	// 未映射的子类型回退到通用类型: running/street
+	if got := sport.FromFIT(1, 2); got != sport.Run {
+		t.Errorf("FromFIT(1, 2) = %s", got)
+	}
+
+	// mountaineering、multisport，以及没有通用类型表项的 swimming/generic、training/generic
+	cases := []struct {
+		fitSport, fitSub byte
+		want             sport.Sport
+	}{
+		{16, 0, sport.MountainClimb},
+		{18, 0, sport.Triathlon},
+		{5, 0, sport.PoolSwim},
+		{10, 0, sport.Cardio},
+		{31, 0, sport.Unknown}, // rock_climbing 没有对应的运动类型
+	}
+	for _, c := range cases {
+		if got := sport.FromFIT(c.fitSport, c.fitSub); got != c.want {
+			t.Errorf("FromFIT(%d, %d) = %s", c.fitSport, c.fitSub, got)
+		}
+		if c.want == sport.Unknown {
+			continue
+		}
+		if fitSport, _ := c.want.FIT(); fitSport != c.fitSport {
+			t.Errorf("%s: FIT sport = %d", c.want, fitSport)
+		}
+	}
+	if sport.FromFIT(5, 0).Family() != sport.FamilySwimming || sport.FromFIT(10, 0).Family() != sport.FamilyStrength {
+		t.Error("generic 子类型应归入对应的运动大类")
+	}
+}
+
+func TestTCX(t *testing.T) {
+	if sport.TrailRun.TCX() != "Running" || sport.IndoorBike.TCX() != "Biking" || sport.PoolSwim.TCX() != "Other" {
+		t.Error("TCX Sport 映射错误")
+	}
+	if sport.FromTCX("Biking") != sport.Bike || sport.FromTCX("Other") != sport.Unknown {
+		t.Error("TCX Sport 反向映射错误")
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := map[string]sport.Sport{
+		"102":       sport.TrailRun,
+		"trail_run": sport.TrailRun,
+		"running":   sport.Run,
+		"Cycling":   sport.Bike,
+	}
+	for input, want := range cases {
+		if got, err := sport.Parse(input); err != nil || got != want {
+			t.Errorf("Parse(%q) = %s, %v", input, got, err)
+		}
+	}
+	for _, input := range []string{"", "unknown", "999", "curling"} {
+		if _, err := sport.Parse(input); err == nil {
+			t.Errorf("Parse(%q) 应返回错误", input)
+		}
+	}
+
+	var w struct {
+		Sport sport.Sport `json:"sport"`
+	}
+	if err := json.Unmarshal([]byte(`{"sport":"swimming"}`), &w); err != nil || w.Sport != sport.PoolSwim {
+		t.Errorf("JSON 解析别名失败: %s, %v", w.Sport, err)
+	}
+}
@@ -0,0 +1,145 @@
+package structured_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitgo/internal/service/ai/client"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/ai/structured"
+	"fitgo/pkg/config"
+)
+
+const valid = `{
+  "summary": "10.00 km 用时 37:24，平均配速 3:44 /km。",
+  "metrics": [{"name": "平均心率", "value": "146 bpm", "comment": "有氧区间"}],
+  "pace_consistency": {
+    "segments": [{"name": "前段", "pace": "3:45 /km", "heart_rate": "141 bpm"}],
+    "findings": ["后程心率漂移"]
+  },
+  "advice": [
+    {"priority": 2, "title": "跑步经济性", "detail": "每周两次步频练习"},
+    {"priority": 1, "title": "速度耐力", "detail": "每周一次 6x1 km 间歇"},
+    {"priority": 3, "title": "功率训练", "detail": "坡道冲刺"}
+  ]
+}`
+
+func TestParseRepairs(t *testing.T) {
+	cases := map[string]string{
+		"原样":    valid,
+		"代码块":   "```json\n" + valid + "\n```",
+		"前后说明":  "好的，以下是分析结果：\n" + valid + "\n希望对你有帮助。",
+		"多余尾逗号": strings.Replace(valid, `"有氧区间"}`, `"有氧区间"},`, 1),
+	}
+	for name, output := range cases {
+		a, err := structured.Parse(output)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		// 建议按优先级排序
+		if a.Advice[0].Title != "速度耐力" || a.Advice[2].Priority != 3 {
+			t.Errorf("%s: 建议顺序 %+v", name, a.Advice)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	cases := map[string]string{
+		"不是 JSON": "### 一、运动表现总结",
+		"缺少建议":    strings.Replace(valid, `"advice"`, `"tips"`, 1),
+		"语法错误":    strings.Replace(valid, `"summary":`, `"summary"`, 1),
+	}
+	for name, output := range cases {
+		if _, err := structured.Parse(output); !errors.Is(err, structured.ErrInvalidOutput) {
+			t.Errorf("%s: err = %v, 期望 ErrInvalidOutput", name, err)
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	var schema struct {
+		Required   []string                   `json:"required"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(structured.Schema()), &schema); err != nil {
+		t.Fatalf("Schema 不是合法 JSON: %v", err)
+	}
+	if strings.Join(schema.Required, ",") != "summary,metrics,pace_consistency,advice" {
+		t.Errorf("required = %v", schema.Required)
+	}
+	if !strings.Contains(string(schema.Properties["advice"]), `"priority"`) {
+		t.Errorf("advice 的 Schema 缺少 priority: %s", schema.Properties["advice"])
+	}
+}
+
+func TestGenerateRetries(t *testing.T) {
+	outputs := []string{"### 这不是 JSON", valid}
+	var requests [][]client.ChatMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []client.ChatMessage `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, body.Messages)
+
+		reply, _ := json.Marshal(outputs[len(requests)-1])
+		fmt.Fprintf(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":%s}}]}`, reply)
+	}))
+	defer server.Close()
+
+	ai, err := aiservice.NewAIService(&config.AIConfig{
+		Provider: "qwen",
+		Config:   config.AIProviderConfig{BaseURL: server.URL, Model: "test-model"},
+	})
+	if err != nil {
+		t.Fatalf("创建 AI 服务失败: %v", err)
+	}
+
+	a, resp, err := structured.Generate(context.Background(), ai, aiservice.TaskSummary, []client.ChatMessage{{Role: "user", Content: "分析"}})
+	if err != nil {
+		t.Fatalf("生成失败: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("请求次数 = %d", len(requests))
+	}
+	// 重试时带上上次的输出和校验错误
+	retry := requests[1]
+	if len(retry) != 3 || retry[1].Role != "assistant" || !strings.Contains(retry[2].Content, "没有通过校验") {
+		t.Errorf("重试消息 = %+v", retry)
+	}
+	if a.Summary == "" || !strings.HasPrefix(resp.Content, `{"summary":`) {
+		t.Errorf("结果 = %+v, 内容 = %s", a, resp.Content)
+	}
+}
+
+func TestRender(t *testing.T) {
+	a, err := structured.Parse(strings.Replace(valid, "后程心率漂移", "后程<script>alert(1)</script>漂移", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md := a.Markdown("zh")
+	for _, want := range []string{"### **一、 🎯 运动表现总结**", "| 平均心率 | 146 bpm | 有氧区间 |", "1. **速度耐力**：每周一次 6x1 km 间歇"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown 缺少 %q:\n%s", want, md)
+		}
+	}
+	if !strings.Contains(a.Markdown("en"), "Actionable Advice") {
+		t.Error("英文 Markdown 标题不正确")
+	}
+
+	html := a.HTML("zh")
+	if strings.Contains(html, "<script>") || !strings.Contains(html, "&lt;script&gt;") {
+		t.Errorf("HTML 没有转义模型输出:\n%s", html)
+	}
+	if !strings.Contains(html, "<td>146 bpm</td>") {
+		t.Errorf("HTML 缺少指标表:\n%s", html)
+	}
+}
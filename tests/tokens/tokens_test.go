@@ -0,0 +1,56 @@
+package tokens_test
+
+import (
+	"strings"
+	"testing"
+
+	"fitgo/internal/service/ai/client"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/ai/tokens"
+	"fitgo/pkg/config"
+)
+
+func TestEstimator(t *testing.T) {
+	openai := tokens.For("openai-compatible")
+	if got := openai.Count("abcdefgh"); got != 2 {
+		t.Errorf("8 个英文字符 = %d tokens, 期望 2", got)
+	}
+	if got := openai.Count("平均配速，"); got != 5 {
+		t.Errorf("4 个汉字和 1 个全角逗号 = %d tokens, 期望 5", got)
+	}
+	if tokens.For("unknown") != tokens.Default {
+		t.Error("未知提供者应使用默认估算器")
+	}
+
+	// 中文按字符估算比按字节更接近，且默认估算器不低于其他估算器
+	text := strings.Repeat("| 1 | 1.00 km | 4:56 /km | 152 bpm | 每公里分段 |\n", 50)
+	if tokens.Default.Count(text) < tokens.For("qwen").Count(text) {
+		t.Error("默认估算器应最保守")
+	}
+	messages := []client.ChatMessage{{Role: "system", Content: "abcd"}, {Role: "user", Content: "abcd"}}
+	if got := openai.Messages(messages); got != 2+2*4 {
+		t.Errorf("消息 = %d tokens", got)
+	}
+}
+
+func TestInputBudget(t *testing.T) {
+	ai, err := aiservice.NewAIService(&config.AIConfig{
+		Providers: []config.AIProviderEntry{
+			{Name: "local", Provider: "ollama", Priority: 1, Config: config.AIProviderConfig{BaseURL: "http://127.0.0.1:1", Model: "m", MaxInputTokens: 3000}},
+			{Name: "cloud", Provider: "qwen", Priority: 0, Config: config.AIProviderConfig{BaseURL: "http://127.0.0.1:1", APIKey: "k", Model: "m"}},
+		},
+		Routes: []config.AIRouteConfig{{Task: aiservice.TaskReview, Providers: []string{"local"}}},
+	})
+	if err != nil {
+		t.Fatalf("创建 AI 服务失败: %v", err)
+	}
+
+	estimator, budget := ai.InputBudget(aiservice.TaskSummary)
+	if estimator != tokens.For("qwen") || budget != aiservice.DefaultMaxInputTokens {
+		t.Errorf("summary 预算 = %+v, %d", estimator, budget)
+	}
+	estimator, budget = ai.InputBudget(aiservice.TaskReview)
+	if estimator != tokens.For("ollama") || budget != 3000 {
+		t.Errorf("review 预算 = %+v, %d", estimator, budget)
+	}
+}
@@ -0,0 +1,247 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/ai/client"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/ai/tools"
+	"fitgo/pkg/config"
+)
+
+// request 模拟服务收到的请求中与工具相关的部分
+type request struct {
+	Messages []client.ChatMessage `json:"messages"`
+	Tools    []struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	} `json:"tools"`
+}
+
+// scriptedServer 模拟 OpenAI 兼容接口：请求带工具时返回 calls 中的下一组工具调用，
+// 用完或请求不带工具时返回 final
+func scriptedServer(t *testing.T, calls [][]client.ToolCall, final string) (*httptest.Server, *[]request) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		json.NewDecoder(r.Body).Decode(&req)
+
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+
+		message := client.ChatMessage{Role: "assistant", Content: final}
+		if len(req.Tools) > 0 && len(calls) > 0 {
+			message = client.ChatMessage{Role: "assistant", ToolCalls: calls[0]}
+			calls = calls[1:]
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"index": 0, "message": message}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func call(id, name, args string) client.ToolCall {
+	return client.ToolCall{ID: id, Type: "function", Function: client.FunctionCall{Name: name, Arguments: args}}
+}
+
+func newAI(t *testing.T, provider, baseURL string) *aiservice.AIService {
+	t.Helper()
+	ai, err := aiservice.NewAIService(&config.AIConfig{
+		Providers: []config.AIProviderEntry{{
+			Name:     provider,
+			Provider: provider,
+			Config:   config.AIProviderConfig{BaseURL: baseURL, APIKey: "test", Model: "tool-model"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("创建 AI 服务失败: %v", err)
+	}
+	return ai
+}
+
+// newActivities 导入夹具中的 TCX 活动
+func newActivities(t *testing.T) (activity.ActivityService, *activity.Activity) {
+	t.Helper()
+	data, err := os.ReadFile("../../internal/service/coros/fakeserver/fixtures/files/472913588747534700.tcx")
+	if err != nil {
+		t.Fatalf("读取夹具失败: %v", err)
+	}
+	activities := activity.NewActivityService(t.TempDir())
+	a, err := activities.Ingest("run.tcx", data, activity.Source{Name: activity.SourceUpload})
+	if err != nil {
+		t.Fatalf("导入活动失败: %v", err)
+	}
+	return activities, a
+}
+
+func TestToolLoop(t *testing.T) {
+	activities, a := newActivities(t)
+	start, _ := time.Parse(time.RFC3339, a.StartTime)
+
+	for _, provider := range []string{"qwen", "openai-compatible"} {
+		t.Run(provider, func(t *testing.T) {
+			server, requests := scriptedServer(t, [][]client.ToolCall{
+				{call("c1", "list_recent_activities", `{"days":7}`), call("c2", "get_personal_bests", `{}`)},
+				{call("c3", "get_activity_splits", fmt.Sprintf(`{"activity_id":%q}`, a.ID)), call("c4", "no_such_tool", `{}`)},
+			}, "最终报告")
+
+			registry := tools.NewAthleteTools(activities, nil, "", start.Add(time.Hour))
+			result, err := tools.Run(context.Background(), newAI(t, provider, server.URL), aiservice.TaskSummary,
+				[]client.ChatMessage{{Role: "user", Content: "分析这次跑步"}}, registry, 5)
+			if err != nil {
+				t.Fatalf("工具调用失败: %v", err)
+			}
+			if result.Content != "最终报告" || result.Model != "tool-model" {
+				t.Errorf("结果 = %+v", result.Response)
+			}
+
+			if len(result.Calls) != 4 {
+				t.Fatalf("记录了 %d 次工具调用, 期望 4", len(result.Calls))
+			}
+			if c := result.Calls[0]; c.Iteration != 1 || c.Error != "" || !strings.Contains(c.Result, a.ID) {
+				t.Errorf("list_recent_activities = %+v", c)
+			}
+			if c := result.Calls[2]; c.Iteration != 2 || c.Error != "" || !strings.Contains(c.Result, `"index":1`) {
+				t.Errorf("get_activity_splits = %+v", c)
+			}
+			if c := result.Calls[3]; c.Error == "" {
+				t.Errorf("未知工具应记录错误: %+v", c)
+			}
+
+			// 第三次请求带上了两轮的 assistant 工具调用和 4 条 tool 结果
+			reqs := *requests
+			if len(reqs) != 3 {
+				t.Fatalf("请求了 %d 次, 期望 3", len(reqs))
+			}
+			var toolMessages int
+			for _, m := range reqs[2].Messages {
+				if m.Role == "tool" {
+					toolMessages++
+					if m.ToolCallID == "" {
+						t.Errorf("tool 消息缺少 tool_call_id: %+v", m)
+					}
+				}
+			}
+			if toolMessages != 4 || len(reqs[0].Tools) != 4 {
+				t.Errorf("tool 消息 %d 条, 工具定义 %d 个", toolMessages, len(reqs[0].Tools))
+			}
+		})
+	}
+}
+
+func TestToolLoopIterationCap(t *testing.T) {
+	activities, _ := newActivities(t)
+	loop := []client.ToolCall{call("c", "list_recent_activities", `{}`)}
+	server, requests := scriptedServer(t, [][]client.ToolCall{loop, loop, loop, loop}, "受限后的回答")
+
+	result, err := tools.Run(context.Background(), newAI(t, "qwen", server.URL), aiservice.TaskSummary,
+		[]client.ChatMessage{{Role: "user", Content: "分析"}}, tools.NewAthleteTools(activities, nil, "", time.Time{}), 2)
+	if err != nil {
+		t.Fatalf("工具调用失败: %v", err)
+	}
+	if result.Content != "受限后的回答" || len(result.Calls) != 2 {
+		t.Errorf("结果 = %q, %d 次调用", result.Content, len(result.Calls))
+	}
+	// 两轮工具调用后的最后一次请求不再提供工具
+	reqs := *requests
+	if len(reqs) != 3 || len(reqs[2].Tools) != 0 {
+		t.Errorf("请求 %d 次, 最后一次带 %d 个工具", len(reqs), len(reqs[len(reqs)-1].Tools))
+	}
+}
+
+func TestTrainingLoadWithoutAccount(t *testing.T) {
+	activities, _ := newActivities(t)
+	server, _ := scriptedServer(t, [][]client.ToolCall{{call("c", "get_training_load", `{"days":7}`)}}, "完成")
+
+	result, err := tools.Run(context.Background(), newAI(t, "qwen", server.URL), aiservice.TaskSummary,
+		[]client.ChatMessage{{Role: "user", Content: "分析"}}, tools.NewAthleteTools(activities, nil, "", time.Time{}), 0)
+	if err != nil {
+		t.Fatalf("工具调用失败: %v", err)
+	}
+	if len(result.Calls) != 1 || result.Calls[0].Error == "" {
+		t.Errorf("没有账号时训练负荷应返回错误: %+v", result.Calls)
+	}
+}
+
+func TestToolsOnlySeeOwnAccount(t *testing.T) {
+	data, err := os.ReadFile("../../internal/service/coros/fakeserver/fixtures/files/472913588747534700.tcx")
+	if err != nil {
+		t.Fatalf("读取夹具失败: %v", err)
+	}
+	activities := activity.NewActivityService(t.TempDir())
+	own, err := activities.Ingest("run.tcx", data, activity.Source{Name: activity.SourceCoros, ID: "1", AccountID: "alice"})
+	if err != nil {
+		t.Fatalf("导入活动失败: %v", err)
+	}
+	other, err := activities.Ingest("run.tcx", data, activity.Source{Name: activity.SourceCoros, ID: "2", AccountID: "bob"})
+	if err != nil {
+		t.Fatalf("导入活动失败: %v", err)
+	}
+	start, _ := time.Parse(time.RFC3339, own.StartTime)
+
+	server, _ := scriptedServer(t, [][]client.ToolCall{{
+		call("c1", "list_recent_activities", `{"days":7}`),
+		call("c2", "get_personal_bests", `{}`),
+		call("c3", "get_activity_splits", fmt.Sprintf(`{"activity_id":%q}`, own.ID)),
+		call("c4", "get_activity_splits", fmt.Sprintf(`{"activity_id":%q}`, other.ID)),
+	}}, "完成")
+	result, err := tools.Run(context.Background(), newAI(t, "qwen", server.URL), aiservice.TaskSummary,
+		[]client.ChatMessage{{Role: "user", Content: "分析"}}, tools.NewAthleteTools(activities, nil, "alice", start.Add(time.Hour)), 5)
+	if err != nil {
+		t.Fatalf("工具调用失败: %v", err)
+	}
+	if len(result.Calls) != 4 {
+		t.Fatalf("记录了 %d 次工具调用, 期望 4", len(result.Calls))
+	}
+	for _, c := range result.Calls[:2] {
+		if c.Error != "" || !strings.Contains(c.Result, own.ID) || strings.Contains(c.Result, other.ID) {
+			t.Errorf("%s 应只包含本账号的活动: %+v", c.Name, c)
+		}
+	}
+	if c := result.Calls[2]; c.Error != "" {
+		t.Errorf("本账号活动的分段 = %+v", c)
+	}
+	if c := result.Calls[3]; c.Error == "" || strings.Contains(c.Result, `"index"`) {
+		t.Errorf("其他账号活动的分段应返回错误: %+v", c)
+	}
+}
+
+func TestBestEfforts(t *testing.T) {
+	base := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	a := &activity.Activity{ID: "a", StartTime: base.Format(time.RFC3339)}
+	// 前 1 公里 5:00，后 1 公里 4:00
+	for i := 0; i <= 20; i++ {
+		seconds := float64(i) * 30
+		if i > 10 {
+			seconds = 300 + float64(i-10)*24
+		}
+		a.Points = append(a.Points, activity.TrackPoint{
+			Time:     base.Add(time.Duration(seconds) * time.Second).Format(time.RFC3339),
+			Distance: float64(i) * 100,
+		})
+	}
+
+	efforts := activity.BestEfforts(a, []float64{1000, 5000})
+	if len(efforts) != 1 {
+		t.Fatalf("efforts = %+v", efforts)
+	}
+	if efforts[0].Duration != 240 {
+		t.Errorf("1 公里最好成绩 = %v 秒, 期望 240", efforts[0].Duration)
+	}
+}
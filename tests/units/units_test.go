@@ -0,0 +1,50 @@
+package units_test
+
+import (
+	"testing"
+
+	"fitgo/pkg/units"
+)
+
+func TestDuration(t *testing.T) {
+	cases := map[float64]string{
+		0:       "0:00",
+		45:      "0:45",
+		373:     "6:13",
+		3490.38: "58:10",
+		3896.78: "1:04:57", // 旧提示词中模型常算错的例子
+		7560:    "2:06:00",
+		59.6:    "1:00",
+	}
+	for seconds, want := range cases {
+		if got := units.Duration(seconds); got != want {
+			t.Errorf("Duration(%v) = %q, 期望 %q", seconds, got, want)
+		}
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	cases := []struct{ got, want string }{
+		{units.Pace(224), "3:44 /km"},
+		{units.Pace(0), ""},
+		{units.PaceFromSpeed(1000.0 / 300), "5:00 /km"},
+		{units.Distance(10011.79), "10.01 km"},
+		{units.Distance(850.4), "850 m"},
+		{units.Speed(16.04), "16.0 km/h"},
+		{units.HeartRate(145.6), "146 bpm"},
+		{units.HeartRate(0), ""},
+		{units.Cadence(168), "168 spm"},
+		{units.Power(203), "203 W"},
+		{units.Calories(650), "650 kcal"},
+		{units.Elevation(0), "0 m"},
+		{units.StrideLength(159), "159 cm"},
+		{units.Decimal(3.44), "3.4"},
+		{units.Milliseconds(62), "62 ms"},
+		{units.Minutes(452), "7:32"},
+	}
+	for i, c := range cases {
+		if c.got != c.want {
+			t.Errorf("case %d = %q, 期望 %q", i, c.got, c.want)
+		}
+	}
+}
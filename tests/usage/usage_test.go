@@ -0,0 +1,184 @@
+package usage_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fitgo/internal/handler"
+	"fitgo/internal/service/ai/client"
+	aiservice "fitgo/internal/service/ai/service"
+	"fitgo/internal/service/usage"
+	"fitgo/pkg/config"
+)
+
+// usageServer 模拟 OpenAI 兼容接口，withUsage 为 false 时不返回用量。
+// 流式请求按 stream_options 在最后一个数据块返回用量
+func usageServer(t *testing.T, withUsage bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Stream        bool `json:"stream"`
+			StreamOptions *struct {
+				IncludeUsage bool `json:"include_usage"`
+			} `json:"stream_options"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		counts := map[string]int{"prompt_tokens": 1000, "completion_tokens": 500, "total_tokens": 1500}
+
+		if !req.Stream {
+			body := map[string]interface{}{
+				"choices": []map[string]interface{}{{"index": 0, "message": map[string]string{"role": "assistant", "content": "回答"}}},
+			}
+			if withUsage {
+				body["usage"] = counts
+			}
+			json.NewEncoder(w).Encode(body)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"choices":[{"index":0,"delta":{"content":"流式回答"}}]}`+"\n\n")
+		if withUsage && req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+			data, _ := json.Marshal(map[string]interface{}{"choices": []interface{}{}, "usage": counts})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newAI(t *testing.T, provider, baseURL string, u usage.UsageService) *aiservice.AIService {
+	t.Helper()
+	ai, err := aiservice.NewAIService(&config.AIConfig{
+		Providers: []config.AIProviderEntry{{
+			Name:     provider,
+			Provider: provider,
+			Config:   config.AIProviderConfig{BaseURL: baseURL, APIKey: "test", Model: "priced-model"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("创建 AI 服务失败: %v", err)
+	}
+	return ai.WithUsage(u)
+}
+
+var prices = config.UsageConfig{
+	Currency: "CNY",
+	Prices:   map[string]config.ModelPrice{"priced-model": {Input: 2, Output: 8}},
+}
+
+func TestRecordAndSummary(t *testing.T) {
+	dir := t.TempDir()
+	meter := usage.NewUsageService(dir, prices)
+	messages := []client.ChatMessage{{Role: "user", Content: "你好"}}
+
+	for _, provider := range []string{"qwen", "openai-compatible"} {
+		ai := newAI(t, provider, usageServer(t, true).URL, meter)
+		ctx := usage.WithUser(context.Background(), "alice")
+		if _, err := ai.Complete(ctx, aiservice.TaskChat, messages); err != nil {
+			t.Fatalf("%s 调用失败: %v", provider, err)
+		}
+		if _, err := ai.CompleteStream(ctx, aiservice.TaskChat, messages, func(string) error { return nil }); err != nil {
+			t.Fatalf("%s 流式调用失败: %v", provider, err)
+		}
+	}
+	// 提供者没有返回用量时按估算记录，记在匿名用户名下
+	ai := newAI(t, "qwen", usageServer(t, false).URL, meter)
+	if _, err := ai.Complete(context.Background(), aiservice.TaskSummary, messages); err != nil {
+		t.Fatalf("调用失败: %v", err)
+	}
+
+	summary, err := meter.Summary(usage.GroupByUser, "", "")
+	if err != nil {
+		t.Fatalf("汇总失败: %v", err)
+	}
+	if summary.Currency != "CNY" || len(summary.Groups) != 2 || summary.Total.Requests != 5 {
+		t.Fatalf("汇总 = %+v", summary)
+	}
+	alice, anonymous := summary.Groups[1], summary.Groups[0]
+	// 每次 1000 输入 + 500 输出，费用 0.002 + 0.004
+	if alice.Key != "alice" || alice.Requests != 4 || alice.PromptTokens != 4000 || alice.CompletionTokens != 2000 || alice.Cost != 0.024 {
+		t.Errorf("alice = %+v", alice)
+	}
+	if anonymous.Key != "-" || anonymous.PromptTokens == 0 || anonymous.PromptTokens == 1000 {
+		t.Errorf("估算的用量 = %+v", anonymous)
+	}
+
+	byDay, _ := meter.Summary(usage.GroupByDay, "", "")
+	if len(byDay.Groups) != 1 || byDay.Groups[0].Key != time.Now().Format("2006-01-02") {
+		t.Errorf("按天汇总 = %+v", byDay.Groups)
+	}
+	byModel, _ := meter.Summary(usage.GroupByModel, "", "")
+	if len(byModel.Groups) != 1 || byModel.Groups[0].Key != "priced-model" {
+		t.Errorf("按模型汇总 = %+v", byModel.Groups)
+	}
+	if _, err := meter.Summary("provider", "", ""); !errors.Is(err, usage.ErrInvalidGroupBy) {
+		t.Errorf("不支持的分组 err = %v", err)
+	}
+	if _, err := meter.Summary(usage.GroupByDay, "2024-02-01", "2024-01-01"); !errors.Is(err, usage.ErrInvalidRange) {
+		t.Errorf("倒序的区间 err = %v", err)
+	}
+}
+
+func TestDailyCaps(t *testing.T) {
+	dir := t.TempDir()
+	cfg := prices
+	cfg.UserDailyCap = 0.01
+	cfg.DailyCap = 0.015
+	ai := newAI(t, "qwen", usageServer(t, true).URL, usage.NewUsageService(dir, cfg))
+	messages := []client.ChatMessage{{Role: "user", Content: "你好"}}
+	alice := usage.WithUser(context.Background(), "alice")
+	bob := usage.WithUser(context.Background(), "bob")
+
+	// 每次 0.006，alice 第三次超过个人上限，bob 仍然可用，之后合计 0.018 超过 0.015
+	for i := 0; i < 2; i++ {
+		if _, err := ai.Complete(alice, aiservice.TaskChat, messages); err != nil {
+			t.Fatalf("第 %d 次调用失败: %v", i+1, err)
+		}
+	}
+	if _, err := ai.Complete(alice, aiservice.TaskChat, messages); !errors.Is(err, usage.ErrSpendCapExceeded) {
+		t.Fatalf("超过个人上限 err = %v", err)
+	}
+	if _, err := ai.Complete(bob, aiservice.TaskChat, messages); err != nil {
+		t.Fatalf("bob 调用失败: %v", err)
+	}
+	if _, err := ai.CompleteStream(bob, aiservice.TaskChat, messages, func(string) error { return nil }); !errors.Is(err, usage.ErrSpendCapExceeded) {
+		t.Errorf("超过合计上限 err = %v", err)
+	}
+
+	// 重新创建的服务从文件恢复当天的花费
+	restarted := usage.NewUsageService(dir, cfg)
+	if err := restarted.Allow(bob); !errors.Is(err, usage.ErrSpendCapExceeded) {
+		t.Errorf("重启后 err = %v", err)
+	}
+}
+
+func TestUsageRequiresAdminToken(t *testing.T) {
+	service := usage.NewUsageService(t.TempDir(), prices)
+	for _, tc := range []struct {
+		token, header string
+		want          int
+	}{
+		{"admin", "Bearer admin", http.StatusOK},
+		{"admin", "", http.StatusUnauthorized},
+		{"admin", "Bearer other", http.StatusUnauthorized},
+		{"", "Bearer ", http.StatusUnauthorized}, // 未配置令牌时拒绝访问
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/ai/usage", nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.NewUsageHandler(service, tc.token).GetUsage(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("令牌 %q, 请求头 %q: 状态码 %d, 期望 %d", tc.token, tc.header, rec.Code, tc.want)
+		}
+	}
+}
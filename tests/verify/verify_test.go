@@ -0,0 +1,87 @@
+package verify_test
+
+import (
+	"strings"
+	"testing"
+
+	"fitgo/internal/service/ai/verify"
+)
+
+var facts = []verify.Fact{
+	{Metric: verify.Distance, Value: "10.00 km"},
+	{Metric: verify.MovingTime, Value: "52:30"},
+	{Metric: verify.TotalTime, Value: "1:04:57"},
+	{Metric: verify.AvgPace, Value: "5:15 /km"},
+	{Metric: verify.AvgHR, Value: "146 bpm"},
+	{Metric: verify.MaxHR, Value: "171 bpm"},
+	{Metric: verify.AvgPower, Value: "203 W"},
+}
+
+func TestMarkdown(t *testing.T) {
+	report := strings.Join([]string{
+		"### **一、 🎯 运动表现总结 (Summary)**",
+		"| 指标 | 数值 | 点评 |",
+		"|---|---|---|",
+		"| **总距离** | 10.0 km | 完成目标 |",
+		"| 运动时间 | 1:02:30 | |",
+		"| 平均配速 | 5'15\" | 稳定 |",
+		"",
+		"| 平均心率 | 最大心率 |",
+		"|---|---|",
+		"| 146 bpm | 175 bpm |",
+		"",
+		"- **平均功率**：203 W，比上次高 5 W",
+		"- Total time: 1:04:57",
+		"",
+		"每公里分段（不参与核对）：",
+		"| 圈 | 距离 | 用时 | 配速 |",
+		"|---|---|---|---|",
+		"| 1 | 1.00 km | 5:01 | 5:01 /km |",
+		"前 5 公里配速 5:05 /km，后程 5:25 /km。",
+	}, "\n")
+
+	claims := verify.Markdown(report)
+	result := verify.Compare(claims, facts)
+	if result.Checked != 7 {
+		t.Errorf("核对了 %d 个数值: %+v", result.Checked, claims)
+	}
+	// 运动时间换算错误是关键错误，最大心率不符只标记
+	if len(result.Mismatches) != 2 || !result.Critical() {
+		t.Fatalf("不符的数值 = %+v", result.Mismatches)
+	}
+	moving, maxHR := result.Mismatches[0], result.Mismatches[1]
+	if moving.Metric != verify.MovingTime || moving.Reported != "1:02:30" || moving.Expected != "52:30" || !moving.Critical {
+		t.Errorf("运动时间 = %+v", moving)
+	}
+	if maxHR.Metric != verify.MaxHR || maxHR.Reported != "175 bpm" || maxHR.Critical {
+		t.Errorf("最大心率 = %+v", maxHR)
+	}
+	if feedback := result.Feedback(); !strings.Contains(feedback, "运动时间：报告中为 1:02:30，数据中为 52:30") {
+		t.Errorf("反馈 = %q", feedback)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	claims := []verify.Claim{
+		{Label: "Distance", Value: "10.04 km"},             // 1% 以内
+		{Label: "Average pace", Value: "5:17 /km"},         // 2 秒以内
+		{Label: "Avg HR", Value: "about 146 bpm"},          // 数值前后的文字不影响
+		{Label: "平均配速（坡度调整前）", Value: "5:30 /km"},          // 括号中的注释被忽略
+		{Label: "平均速度", Value: "11.4 km/h"},                // 无法识别的指标
+		{Label: "总距离", Value: "约十公里"},                      // 无法解析的数值
+		{Label: "Max power", Value: "400 W"},               // 没有真实值
+		{Label: "运动时间", Value: "52:30（不含暂停）"},              // 正确
+		{Label: "Average power", Value: "200 W/kg, 203 W"}, // W/kg 不是功率
+	}
+	result := verify.Compare(claims, facts)
+	if result.Checked != 6 || len(result.Mismatches) != 1 {
+		t.Fatalf("核对结果 = %+v", result)
+	}
+	if m := result.Mismatches[0]; m.Label != "平均配速（坡度调整前）" || m.Reported != "5:30 /km" || !m.Critical {
+		t.Errorf("不符的数值 = %+v", m)
+	}
+
+	if result := verify.Compare(claims[:3], facts); result.Critical() || len(result.Mismatches) != 0 {
+		t.Errorf("容差内的数值 = %+v", result)
+	}
+}
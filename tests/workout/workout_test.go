@@ -0,0 +1,147 @@
+package workout_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitgo/internal/service/activity"
+	"fitgo/internal/service/coros"
+	"fitgo/internal/service/coros/fakeserver"
+	"fitgo/internal/service/workout"
+	"fitgo/pkg/config"
+	"fitgo/pkg/fit"
+	"fitgo/pkg/sport"
+)
+
+func intervals() *workout.Workout {
+	return &workout.Workout{
+		Name:  "6x800m",
+		Sport: sport.Run,
+		Steps: []workout.Step{
+			{Type: workout.StepWarmup, Duration: workout.Duration{Type: workout.DurationTime, Value: 900}},
+			{Type: workout.StepRepeat, Repeat: 6, Steps: []workout.Step{
+				{Type: workout.StepActive, Duration: workout.Duration{Type: workout.DurationDistance, Value: 800},
+					Target: workout.Target{Type: workout.TargetPace, Low: 225, High: 235}},
+				{Type: workout.StepRecovery, Duration: workout.Duration{Type: workout.DurationTime, Value: 90}},
+			}},
+			{Type: workout.StepCooldown, Duration: workout.Duration{Type: workout.DurationOpen}},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	w := intervals()
+	if err := w.Validate(); err != nil {
+		t.Fatalf("合法训练课校验失败: %v", err)
+	}
+	if w.Steps[0].Target.Type != workout.TargetNone {
+		t.Errorf("缺省目标应归一为 none，实际 %q", w.Steps[0].Target.Type)
+	}
+
+	nested := intervals()
+	nested.Steps[1].Steps = append(nested.Steps[1].Steps, workout.Step{Type: workout.StepRepeat, Repeat: 2})
+	if err := nested.Validate(); err == nil {
+		t.Error("嵌套重复块应校验失败")
+	}
+
+	badTarget := intervals()
+	badTarget.Steps[1].Steps[0].Target.High = 200
+	if err := badTarget.Validate(); err == nil {
+		t.Error("high 小于 low 应校验失败")
+	}
+}
+
+func TestStoreAndExport(t *testing.T) {
+	service := workout.NewWorkoutService(t.TempDir())
+
+	saved, err := service.Create(intervals())
+	if err != nil {
+		t.Fatalf("保存训练课失败: %v", err)
+	}
+	if saved.ID == "" {
+		t.Fatal("保存后应生成ID")
+	}
+
+	tcx, err := service.ExportTCX(saved.ID)
+	if err != nil {
+		t.Fatalf("导出 TCX 失败: %v", err)
+	}
+	for _, want := range []string{`Sport="Running"`, `xsi:type="Repeat_t"`, "<Repetitions>6</Repetitions>", "<Meters>800</Meters>"} {
+		if !strings.Contains(string(tcx), want) {
+			t.Errorf("TCX 缺少 %s", want)
+		}
+	}
+
+	data, err := service.ExportFIT(saved.ID)
+	if err != nil {
+		t.Fatalf("导出 FIT 失败: %v", err)
+	}
+	if len(data) < 16 || string(data[8:12]) != ".FIT" {
+		t.Fatal("FIT 文件头无效")
+	}
+	if fit.CRC(data) != 0 {
+		t.Error("FIT 文件 CRC 校验失败")
+	}
+
+	if err := service.Delete(saved.ID); err != nil {
+		t.Fatalf("删除训练课失败: %v", err)
+	}
+	if _, err := service.Get(saved.ID); !errors.Is(err, workout.ErrWorkoutNotFound) {
+		t.Errorf("删除后应返回 ErrWorkoutNotFound，实际 %v", err)
+	}
+}
+
+func TestFITTruncatesOnRuneBoundary(t *testing.T) {
+	w := intervals()
+	w.Name = "周二间歇跑：六组八百米间歇加热身放松"  // 超过 32 字节
+	w.Steps[0].Name = "1 慢跑热身十五分钟" // 26 字节，超过 16 字节
+	data, err := workout.EncodeFIT(w)
+	if err != nil {
+		t.Fatalf("编码 FIT 失败: %v", err)
+	}
+	// 名称最多 31、15 字节加结束符，截断在完整的汉字之后
+	if !bytes.Contains(data, append([]byte("1 慢跑热身"), 0)) {
+		t.Error("步骤名称没有在字符边界截断")
+	}
+	if !bytes.Contains(data, append([]byte("周二间歇跑：六组八百"), 0)) {
+		t.Error("训练课名称没有在字符边界截断")
+	}
+}
+
+func TestUploadToCoros(t *testing.T) {
+	fake := fakeserver.New(nil)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	dataDir := t.TempDir()
+	accounts, err := coros.NewAccountStore(dataDir)
+	if err != nil {
+		t.Fatalf("创建账号存储失败: %v", err)
+	}
+	service := coros.NewCorosService(&config.CorosConfig{
+		Username: 13800000000,
+		Password: "md5",
+		Address:  server.URL,
+	}, accounts, coros.NewDailyStore(dataDir), activity.NewActivityService(dataDir))
+
+	programID, err := service.UploadWorkout(context.Background(), coros.DefaultAccountID, intervals())
+	if err != nil {
+		t.Fatalf("上传训练课失败: %v", err)
+	}
+	if programID != "fake-program-1" {
+		t.Errorf("programID = %q", programID)
+	}
+
+	programs := fake.Programs()
+	if len(programs) != 1 {
+		t.Fatalf("期望上传 1 个训练课，实际 %d", len(programs))
+	}
+	// 热身 + 重复块 + 2 个子步骤 + 放松
+	if exercises := programs[0]["exercises"].([]interface{}); len(exercises) != 5 {
+		t.Errorf("期望 5 个课程步骤，实际 %d", len(exercises))
+	}
+}